@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"goHeadache/pkg/zutool"
+)
+
+// writeAccessible prints the forecast of data as plain sentences, one per
+// hour, for screen readers and braille displays: no colors, tables or
+// symbols to make sense of. Without dayFilter it reads from the current
+// hour to the end of the forecast.
+func writeAccessible(w io.Writer, data zutool.WeatherData, dayFilter string, now time.Time) error {
+	days, err := dayIndices(dayFilter)
+	if err != nil {
+		return err
+	}
+	if issued, ok := issuedAt(data); ok {
+		fmt.Fprintln(w, trf("Pressure forecast for %s, issued %s at %s.", data.PlaceName, formatDate(issued), formatHour(issued.Hour())))
+	} else {
+		fmt.Fprintln(w, trf("Pressure forecast for %s.", data.PlaceName))
+	}
+	switch status, _ := checkSummary(data, now, 6); status {
+	case checkSevere:
+		fmt.Fprintln(w, trf("Next %d hours: severe pressure warning.", 6))
+	case checkModerate:
+		fmt.Fprintln(w, trf("Next %d hours: moderate pressure warning.", 6))
+	default:
+		fmt.Fprintln(w, trf("Next %d hours: no pressure warnings.", 6))
+	}
+
+	selected := map[int]bool{}
+	for _, d := range days {
+		selected[d] = true
+	}
+	m := model{weatherData: data}
+	today := midnight(now)
+	all, _ := forecastHours(data, now)
+	lastDay := -1
+	for _, u := range all {
+		day := int(math.Round(midnight(u.at).Sub(today).Hours()/24)) + 1
+		if dayFilter == "" && u.at.Before(now.Truncate(time.Hour)) || dayFilter != "" && !selected[day] {
+			continue
+		}
+		if day != lastDay {
+			fmt.Fprintln(w)
+			lastDay = day
+		}
+		dayName, _ := m.getDayData(day)
+		fmt.Fprintln(w, accessibleHour(tr(dayName), u))
+	}
+	return nil
+}
+
+// accessibleHour describes an hour in a sentence, such as "Today 15:00,
+// sunny, 20.0 °C, pressure 1003.2 hPa, falling, level 3 caution.". Missing
+// values are left out.
+func accessibleHour(dayName string, u upcomingHour) string {
+	parts := []string{dayName + " " + formatHour(u.at.Hour())}
+	if weather := strings.TrimSpace(u.entry.Weather); weather != "" {
+		parts = append(parts, weatherDescription(weather))
+	}
+	_, _, temp, pressure := displayHourlyData(u.entry)
+	if temp != "N/A" {
+		parts = append(parts, temp+" "+units.tempSymbol())
+	}
+	if pressure != "N/A" {
+		parts = append(parts, trf("pressure %s %s", pressure, units.pressure))
+	}
+	if u.deltaOK {
+		switch {
+		case u.delta <= dropSevereHPa:
+			parts = append(parts, trf("falling fast, %s in an hour", units.formatFall(-u.delta)))
+		case u.delta <= -0.05:
+			parts = append(parts, tr("falling"))
+		case u.delta >= 0.05:
+			parts = append(parts, tr("rising"))
+		default:
+			parts = append(parts, tr("steady"))
+		}
+	}
+	if level := strings.TrimSpace(u.entry.PressureLevel); level != "" {
+		if _, err := strconv.Atoi(level); err == nil {
+			parts = append(parts, trf("level %s %s", level, levelName(level)))
+		}
+	}
+	return strings.Join(parts, tr(", ")) + tr(".")
+}
+
+// printAccessible fetches the forecast for areaCode and prints it with
+// writeAccessible.
+func printAccessible(areaCode, dayFilter string) error {
+	if _, err := dayIndices(dayFilter); err != nil {
+		return err
+	}
+	w, err := loadWeather(context.Background(), areaCode, true)
+	if err != nil {
+		return err
+	}
+	if w.substitute != "" {
+		fmt.Println(trf("Area code %s has no forecast; this is the forecast of the nearest known area, %s %s.", areaCode, w.substitute, w.data.PlaceName))
+	}
+	if w.stale != nil && !errors.Is(w.stale, errOffline) {
+		fmt.Println(trf("The forecast could not be updated (%s); this is the forecast cached %s ago.", trError(w.stale), formatAge(time.Since(w.fetchedAt))))
+	}
+	return writeAccessible(os.Stdout, w.data, dayFilter, clockNow())
+}
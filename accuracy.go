@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// measuredHours returns the measured hours of records by time in UTC; the
+// newest recorded value of an hour wins.
+func measuredHours(records []historyRecord) map[time.Time]historyHour {
+	measured := map[time.Time]historyHour{}
+	for _, r := range records {
+		for _, h := range r.Observed {
+			measured[h.Time.UTC()] = h
+		}
+	}
+	return measured
+}
+
+// forecastKey is an hour, in UTC, as forecast some days ahead.
+type forecastKey struct {
+	at   time.Time
+	lead int // days between the day of fetching and the hour
+}
+
+// forecastErrors are the differences between forecast and measured hours.
+type forecastErrors struct {
+	hours       int
+	sum, absSum float64
+	maxAbs      float64
+	levels      int // hours with both levels
+	levelExact  int
+	levelNear   int // off by at most one
+}
+
+func (e *forecastErrors) add(forecast, measured historyHour) {
+	if forecast.Pressure == nil || measured.Pressure == nil {
+		return
+	}
+	diff := *forecast.Pressure - *measured.Pressure
+	e.hours++
+	e.sum += diff
+	e.absSum += math.Abs(diff)
+	e.maxAbs = math.Max(e.maxAbs, math.Abs(diff))
+	if forecast.Level != nil && measured.Level != nil {
+		e.levels++
+		switch abs(*forecast.Level - *measured.Level) {
+		case 0:
+			e.levelExact++
+			e.levelNear++
+		case 1:
+			e.levelNear++
+		}
+	}
+}
+
+// accuracyRow is a line of the accuracy report: the errors of the forecasts
+// made LeadDays ahead, or of those for Hour of the day.
+type accuracyRow struct {
+	LeadDays *int `json:"lead_days,omitempty"`
+	Hour     *int `json:"hour,omitempty"`
+	Hours    int  `json:"hours"`
+	// MeanAbsError and Bias are in hPa; a positive bias means the pressure
+	// was forecast too high.
+	MeanAbsError float64 `json:"mean_abs_error"`
+	Bias         float64 `json:"bias"`
+	MaxAbsError  float64 `json:"max_abs_error"`
+	// LevelExact and LevelWithinOne are the shares of hours whose forecast
+	// pressure level was right, or off by at most one, in percent.
+	LevelExact     *float64 `json:"level_exact,omitempty"`
+	LevelWithinOne *float64 `json:"level_within_one,omitempty"`
+}
+
+func (e forecastErrors) row() accuracyRow {
+	r := accuracyRow{Hours: e.hours}
+	if e.hours == 0 {
+		return r
+	}
+	r.MeanAbsError = math.Round(e.absSum/float64(e.hours)*100) / 100
+	r.Bias = math.Round(e.sum/float64(e.hours)*100) / 100
+	r.MaxAbsError = roundTenth(e.maxAbs)
+	if e.levels > 0 {
+		exact := math.Round(float64(e.levelExact) / float64(e.levels) * 100)
+		near := math.Round(float64(e.levelNear) / float64(e.levels) * 100)
+		r.LevelExact, r.LevelWithinOne = &exact, &near
+	}
+	return r
+}
+
+// accuracyReport compares the recorded forecasts of an area with what was
+// measured later.
+type accuracyReport struct {
+	Area          string        `json:"area"`
+	Place         string        `json:"place"`
+	From          string        `json:"from"`
+	To            string        `json:"to"`
+	MeasuredHours int           `json:"measured_hours"`
+	ByLead        []accuracyRow `json:"by_lead,omitempty"`
+	// ByHour holds the forecasts made Lead days ahead.
+	Lead   *int          `json:"lead,omitempty"`
+	ByHour []accuracyRow `json:"by_hour,omitempty"`
+}
+
+// newAccuracyReport compares the forecasts of records for the hours from
+// from on with the measured values. Of the forecasts made the same number
+// of days ahead only the last one of each day of fetching counts, so
+// fetching often does not weigh more. With byHour set the errors of the
+// forecasts made lead days ahead are broken down by hour of the day,
+// otherwise all are broken down by how many days ahead they were made.
+func newAccuracyReport(areaCode string, records []historyRecord, from time.Time, byHour bool, lead int) accuracyReport {
+	rep := accuracyReport{Area: areaCode}
+	measured := measuredHours(records)
+	var first, last time.Time
+	for key, h := range measured {
+		if h.Time.Before(from) {
+			delete(measured, key)
+			continue
+		}
+		if first.IsZero() || h.Time.Before(first) {
+			first = h.Time
+		}
+		if h.Time.After(last) {
+			last = h.Time
+		}
+	}
+	rep.MeasuredHours = len(measured)
+	if len(measured) == 0 {
+		return rep
+	}
+	rep.From, rep.To = first.Format(time.DateOnly), last.Format(time.DateOnly)
+
+	forecasts := map[forecastKey]historyHour{}
+	for _, r := range records {
+		rep.Place = r.Place
+		fetched := midnight(r.FetchedAt)
+		for _, h := range r.Forecast {
+			if _, ok := measured[h.Time.UTC()]; ok {
+				days := int(math.Round(midnight(h.Time).Sub(fetched).Hours() / 24))
+				forecasts[forecastKey{h.Time.UTC(), days}] = h
+			}
+		}
+	}
+
+	groups := map[int]*forecastErrors{}
+	for k, f := range forecasts {
+		group := k.lead
+		if byHour {
+			if k.lead != lead {
+				continue
+			}
+			group = f.Time.Hour()
+		}
+		if groups[group] == nil {
+			groups[group] = &forecastErrors{}
+		}
+		groups[group].add(f, measured[k.at])
+	}
+	keys := make([]int, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	for _, k := range keys {
+		row := groups[k].row()
+		if row.Hours == 0 {
+			continue
+		}
+		if byHour {
+			row.Hour = &k
+			rep.ByHour = append(rep.ByHour, row)
+		} else {
+			row.LeadDays = &k
+			rep.ByLead = append(rep.ByLead, row)
+		}
+	}
+	if byHour {
+		rep.Lead = &lead
+	}
+	return rep
+}
+
+// leadName describes forecasts made days ahead.
+func leadName(days int) string {
+	switch days {
+	case 0:
+		return "same day"
+	case 1:
+		return "1 day ahead"
+	}
+	return fmt.Sprintf("%d days ahead", days)
+}
+
+// writeTable prints rep for people.
+func (rep accuracyReport) writeTable(w io.Writer) {
+	fmt.Fprintf(w, "%s (%s): forecasts for %s to %s against %d measured hours\n",
+		rep.Place, rep.Area, rep.From, rep.To, rep.MeasuredHours)
+	rows, title := rep.ByLead, "FORECAST"
+	if rep.Lead != nil {
+		rows, title = rep.ByHour, "HOUR"
+		fmt.Fprintf(w, "Forecasts made %s, by hour of the day\n", leadName(*rep.Lead))
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "No recorded forecast covers the measured hours yet.")
+		return
+	}
+	fmt.Fprintf(w, "\n  %-14s  %5s  %9s  %10s  %9s  %6s  %6s\n", title, "HOURS", "MAE", "BIAS", "MAX", "LEVEL", "±1")
+	for _, r := range rows {
+		name := ""
+		if r.LeadDays != nil {
+			name = leadName(*r.LeadDays)
+		} else {
+			name = fmt.Sprintf("%02d:00", *r.Hour)
+		}
+		exact, near := "-", "-"
+		if r.LevelExact != nil {
+			exact, near = fmt.Sprintf("%.0f%%", *r.LevelExact), fmt.Sprintf("%.0f%%", *r.LevelWithinOne)
+		}
+		fmt.Fprintf(w, "  %-14s  %5d  %5.2f hPa  %+6.2f hPa  %5.1f hPa  %6s  %6s\n",
+			name, r.Hours, r.MeanAbsError, r.Bias, r.MaxAbsError, exact, near)
+	}
+	fmt.Fprintln(w, "\nMAE is the mean absolute error of the pressure; a positive bias means it was forecast too high.")
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// alert is a pressure warning about one area, ready to be sent.
+type alert struct {
+	areaCode  string
+	placeName string
+	// rule and severity are those of the rule that raised the alert.
+	rule     string
+	severity string
+	warning  pressureWarning
+	// title and body replace the message of warning when set, for digests.
+	title, body string
+}
+
+// key identifies an alert in alertState by the area, the rule and the hour
+// it is about, so a warning is sent once however many polls see it.
+func (a alert) key() string {
+	return a.areaCode + "@" + a.warning.at.Format(time.RFC3339) + " " + a.rule
+}
+
+// message returns the notification title and body of a. The title says
+// how severe the rule is, as in "Severe pressure warning: 千代田区".
+func (a alert) message() (title, body string) {
+	if a.title != "" {
+		return a.title, a.body
+	}
+	title, body = a.warning.message(a.placeName)
+	switch a.severity {
+	case "info":
+		title = "Pressure notice: " + a.placeName
+	case "severe":
+		title = "Severe pressure warning: " + a.placeName
+	}
+	return title, body
+}
+
+// isDigest reports whether a sums up several alerts.
+func (a alert) isDigest() bool { return a.title != "" }
+
+// notifier delivers alerts over one channel.
+type notifier interface {
+	// name is used in log messages.
+	name() string
+	send(ctx context.Context, a alert) error
+	// preview describes what send would deliver, for -dry-run.
+	preview(a alert) string
+}
+
+// desktopNotifier shows alerts as desktop notifications.
+type desktopNotifier struct{}
+
+func (desktopNotifier) name() string { return "desktop" }
+
+func (desktopNotifier) send(_ context.Context, a alert) error {
+	title, body := a.message()
+	return sendNotification(title, body)
+}
+
+func (desktopNotifier) preview(a alert) string {
+	title, body := a.message()
+	return title + ": " + body
+}
+
+// alertStateKeep is how long sent alerts are remembered. Warnings are about
+// the next hours, so anything older can no longer come up again.
+const alertStateKeep = 48 * time.Hour
+
+// alertState is what the daemon remembers across polls and restarts.
+type alertState struct {
+	// Sent maps the keys of sent alerts to when they were sent.
+	Sent map[string]time.Time `json:"sent"`
+	// Queued are the alerts held back by quiet hours, to be sent together
+	// once they are over.
+	Queued []queuedAlert `json:"queued,omitempty"`
+}
+
+// alertStateFile returns where the daemon keeps its state:
+// <user cache dir>/goHeadache/daemon-state.json.
+func alertStateFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating cache directory: %v", err)
+	}
+	return filepath.Join(dir, "goHeadache", "daemon-state.json"), nil
+}
+
+// loadAlertState reads the daemon state. A missing or unreadable file is an
+// empty state: at worst an alert is sent twice.
+func loadAlertState() alertState {
+	s := alertState{Sent: map[string]time.Time{}}
+	path, err := alertStateFile()
+	if err != nil {
+		return s
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s
+	}
+	if json.Unmarshal(data, &s) != nil || s.Sent == nil {
+		s.Sent = map[string]time.Time{}
+	}
+	return s
+}
+
+// save writes s after dropping alerts older than alertStateKeep.
+func (s *alertState) save(now time.Time) error {
+	for key, at := range s.Sent {
+		if now.Sub(at) > alertStateKeep {
+			delete(s.Sent, key)
+		}
+	}
+	path, err := alertStateFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	out, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0o644)
+}
+
+// dispatch sends a over every notifier that has not sent it yet and
+// records each delivery, so a notifier that failed tries again on the next
+// poll without the others repeating it. Failures are returned together so
+// the caller can log them.
+func (s *alertState) dispatch(ctx context.Context, a alert, notifiers []notifier, now time.Time) (sent bool, errs []error) {
+	if _, ok := s.Sent[a.key()+" queued"]; ok {
+		return false, nil
+	}
+	for _, n := range notifiers {
+		key := a.key() + " " + n.name()
+		if _, ok := s.Sent[key]; ok {
+			continue
+		}
+		if err := n.send(ctx, a); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", n.name(), err))
+			continue
+		}
+		s.Sent[key] = now
+		sent = true
+	}
+	return sent, errs
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// episodeCondition is a condition of the weather in the hours before a
+// headache that analyze looks for.
+type episodeCondition struct {
+	key  string
+	name string
+	// holds reports whether the condition holds in window, the recorded
+	// hours before an episode, oldest first.
+	holds func(window []historyHour) bool
+}
+
+// episodeConditions are the conditions analyze compares, covering pressure
+// drops, level transitions and temperature swings.
+var episodeConditions = []episodeCondition{
+	{"severe_drop", fmt.Sprintf("Pressure fell %.1f hPa or more within an hour", -dropSevereHPa), func(w []historyHour) bool {
+		return maxHourlyFall(w) >= -dropSevereHPa
+	}},
+	{"drop", fmt.Sprintf("Pressure fell %.1f hPa or more within an hour", -dropWarnHPa), func(w []historyHour) bool {
+		return maxHourlyFall(w) >= -dropWarnHPa
+	}},
+	{"fall_3hpa", "Pressure fell 3 hPa or more in all", func(w []historyHour) bool {
+		return pressureSwing(w, -1) >= 3
+	}},
+	{"rise_3hpa", "Pressure rose 3 hPa or more in all", func(w []historyHour) bool {
+		return pressureSwing(w, 1) >= 3
+	}},
+	{"level_up", "Pressure level rose to 3 or higher", func(w []historyHour) bool {
+		for i := 1; i < len(w); i++ {
+			if w[i].Level != nil && w[i-1].Level != nil && *w[i].Level >= 3 && *w[i].Level > *w[i-1].Level {
+				return true
+			}
+		}
+		return false
+	}},
+	{"level_4", "Pressure level reached 4", func(w []historyHour) bool {
+		for _, h := range w {
+			if h.Level != nil && *h.Level >= 4 {
+				return true
+			}
+		}
+		return false
+	}},
+	{"temp_swing", "Temperature changed 5 °C or more", func(w []historyHour) bool {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, h := range w {
+			if h.Temp != nil {
+				lo, hi = math.Min(lo, *h.Temp), math.Max(hi, *h.Temp)
+			}
+		}
+		return hi-lo >= 5
+	}},
+}
+
+// maxHourlyFall returns the largest fall of the pressure from an hour of w
+// to the next.
+func maxHourlyFall(w []historyHour) float64 {
+	var largest float64
+	for i := 1; i < len(w); i++ {
+		if fall, ok := hourlyFall(w[i-1], w[i]); ok {
+			largest = math.Max(largest, fall)
+		}
+	}
+	return largest
+}
+
+// pressureSwing returns how far the pressure fell (direction -1) or rose
+// (direction 1) from an earlier hour of w to a later one.
+func pressureSwing(w []historyHour, direction float64) float64 {
+	var largest float64
+	for i, from := range w {
+		if from.Pressure == nil {
+			continue
+		}
+		for _, to := range w[i+1:] {
+			if to.Pressure != nil {
+				largest = math.Max(largest, (*to.Pressure-*from.Pressure)*direction)
+			}
+		}
+	}
+	return largest
+}
+
+// episodeWindow returns the recorded hours of the window before at, oldest
+// first, and whether they cover at least half of it.
+func episodeWindow(hours map[time.Time]recordedHour, at time.Time, window time.Duration) ([]historyHour, bool) {
+	var w []historyHour
+	end := at.Truncate(time.Hour)
+	n := int(window / time.Hour)
+	for t := end.Add(-time.Duration(n) * time.Hour); !t.After(end); t = t.Add(time.Hour) {
+		if h, ok := hours[t.UTC()]; ok {
+			w = append(w, h.historyHour)
+		}
+	}
+	return w, len(w)*2 >= n+1
+}
+
+// conditionResult is how often a condition preceded the episodes, and how
+// often it holds at any hour.
+type conditionResult struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	// Episodes is how many episodes it preceded; Share is that in percent of
+	// the episodes analyzed.
+	Episodes int     `json:"episodes"`
+	Share    float64 `json:"share"`
+	// BaseRate is the share of all recorded hours it holds before, in
+	// percent, and Lift is Share over BaseRate: above 1 it comes before
+	// headaches more often than chance.
+	BaseRate float64  `json:"base_rate"`
+	Lift     *float64 `json:"lift"`
+}
+
+// episodeAnalysis relates the diary to the history of an area.
+type episodeAnalysis struct {
+	Area   string `json:"area"`
+	Window string `json:"window"`
+	// Episodes is how many episodes were analyzed; Skipped those without
+	// enough history before them.
+	Episodes     int               `json:"episodes"`
+	Skipped      int               `json:"skipped"`
+	MeanSeverity float64           `json:"mean_severity"`
+	Conditions   []conditionResult `json:"conditions"`
+}
+
+// analyzeEpisodes evaluates episodeConditions in the window before each
+// episode and before every recorded hour. The conditions that came before
+// the most episodes come first, then those with the highest lift.
+func analyzeEpisodes(areaCode string, entries []diaryEntry, hours map[time.Time]recordedHour, window time.Duration) episodeAnalysis {
+	a := episodeAnalysis{Area: areaCode, Window: fmt.Sprintf("%gh", window.Hours())}
+	counts := make([]int, len(episodeConditions))
+	var severity int
+	for _, e := range entries {
+		w, ok := episodeWindow(hours, e.Time, window)
+		if !ok {
+			a.Skipped++
+			continue
+		}
+		a.Episodes++
+		severity += e.Severity
+		for i, c := range episodeConditions {
+			if c.holds(w) {
+				counts[i]++
+			}
+		}
+	}
+	if a.Episodes > 0 {
+		a.MeanSeverity = roundTenth(float64(severity) / float64(a.Episodes))
+	}
+
+	base := make([]int, len(episodeConditions))
+	var baseHours int
+	for at := range hours {
+		w, ok := episodeWindow(hours, at, window)
+		if !ok {
+			continue
+		}
+		baseHours++
+		for i, c := range episodeConditions {
+			if c.holds(w) {
+				base[i]++
+			}
+		}
+	}
+
+	for i, c := range episodeConditions {
+		r := conditionResult{Key: c.key, Name: c.name, Episodes: counts[i]}
+		if a.Episodes > 0 {
+			r.Share = math.Round(float64(counts[i]) / float64(a.Episodes) * 100)
+		}
+		if baseHours > 0 {
+			r.BaseRate = math.Round(float64(base[i]) / float64(baseHours) * 100)
+		}
+		if base[i] > 0 && a.Episodes > 0 {
+			lift := math.Round(float64(counts[i])/float64(a.Episodes)/(float64(base[i])/float64(baseHours))*10) / 10
+			r.Lift = &lift
+		}
+		a.Conditions = append(a.Conditions, r)
+	}
+	sort.SliceStable(a.Conditions, func(i, j int) bool {
+		ci, cj := a.Conditions[i], a.Conditions[j]
+		if ci.Episodes != cj.Episodes {
+			return ci.Episodes > cj.Episodes
+		}
+		return ci.Lift != nil && (cj.Lift == nil || *ci.Lift > *cj.Lift)
+	})
+	return a
+}
+
+// writeTable prints a for people.
+func (a episodeAnalysis) writeTable(w io.Writer) {
+	fmt.Fprintf(w, "%d headaches in %s analyzed (mean severity %.1f), looking %s back", a.Episodes, a.Area, a.MeanSeverity, a.Window)
+	if a.Skipped > 0 {
+		fmt.Fprintf(w, "; %d without enough history skipped", a.Skipped)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "\n  %-46s  %9s  %9s  %5s\n", "CONDITION BEFORE", "HEADACHES", "ANY HOUR", "LIFT")
+	for _, c := range a.Conditions {
+		lift := "-"
+		if c.Lift != nil {
+			lift = fmt.Sprintf("%.1f×", *c.Lift)
+		}
+		fmt.Fprintf(w, "  %-46s  %4d %3.0f%%  %8.0f%%  %5s\n", c.Name, c.Episodes, c.Share, c.BaseRate, lift)
+	}
+	fmt.Fprintln(w, "\nLift compares how often a condition came before your headaches with how often it comes before any hour;")
+	fmt.Fprintln(w, "above 1× it precedes them more often than chance.")
+}
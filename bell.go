@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// alertHours is how far ahead the forecast screen looks for severe hours
+// to alert about.
+const alertHours = 6
+
+// flashDuration is how long the header stays inverted for a flash alert.
+const flashDuration = time.Second
+
+// parseScreenAlert validates the screen_alert setting of the config file:
+// none, bell, flash or both.
+func parseScreenAlert(s string) (string, error) {
+	switch a := strings.ToLower(strings.TrimSpace(s)); a {
+	case "", "none":
+		return "", nil
+	case "bell", "flash", "both":
+		return a, nil
+	}
+	return "", fmt.Errorf("invalid screen_alert %q in the config file (use none, bell, flash or both)", s)
+}
+
+type flashEndMsg struct{}
+
+// checkScreenAlert rings the terminal bell or flashes the header, as
+// m.screenAlert says, when the next alertHours turn severe by the rating of
+// check. It alerts once until they are no longer severe, so watch-mode
+// refreshes do not repeat it.
+func (m *model) checkScreenAlert() tea.Cmd {
+	if m.screenAlert == "" {
+		return nil
+	}
+	if status, _ := checkSummary(m.weatherData, clockNow(), alertHours); status != checkSevere {
+		m.alerted = false
+		return nil
+	}
+	if m.alerted {
+		return nil
+	}
+	m.alerted = true
+	var cmds []tea.Cmd
+	if m.screenAlert == "bell" || m.screenAlert == "both" {
+		cmds = append(cmds, tea.Raw("\a"))
+	}
+	if m.screenAlert == "flash" || m.screenAlert == "both" {
+		m.flashing = true
+		cmds = append(cmds, tea.Tick(flashDuration, func(time.Time) tea.Msg { return flashEndMsg{} }))
+	}
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"goHeadache/pkg/zutool"
+)
+
+// defaultCacheTTL is how long a cached forecast is used without asking the
+// API again, unless cache_ttl in the config file says otherwise.
+const defaultCacheTTL = 10 * time.Minute
+
+// cacheTTL is the cache lifetime in use; zero always asks the API.
+var cacheTTL = defaultCacheTTL
+
+// cachedWeather is the on-disk form of a cached forecast.
+type cachedWeather struct {
+	FetchedAt time.Time          `json:"fetched_at"`
+	Data      zutool.WeatherData `json:"data"`
+}
+
+// cacheFile returns where the forecast of areaCode is cached:
+// <user cache dir>/goHeadache/weather/<area code>.json, with the name of
+// the source in front for sources other than zutool.
+func cacheFile(areaCode string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating cache directory: %v", err)
+	}
+	name := filepath.Base(areaCode) + ".json"
+	if !usesZutool() {
+		name = strings.ToLower(weatherSource.Name()) + "-" + name
+	}
+	return filepath.Join(dir, "goHeadache", "weather", name), nil
+}
+
+// readCache returns the cached forecast of areaCode, however old it is.
+func readCache(areaCode string) (cachedWeather, error) {
+	var c cachedWeather
+	path, err := cacheFile(areaCode)
+	if err != nil {
+		return c, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("error parsing cache %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// writeCache stores the forecast of areaCode fetched at fetchedAt. The file
+// is replaced atomically so a concurrent reader never sees half of it.
+func writeCache(areaCode string, data zutool.WeatherData, fetchedAt time.Time) error {
+	path, err := cacheFile(areaCode)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	out, err := json.Marshal(cachedWeather{FetchedAt: fetchedAt, Data: data})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".weather-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(out); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// parseCacheTTL parses the cache_ttl setting. An empty value is the
+// default.
+func parseCacheTTL(s string) (time.Duration, error) {
+	if s == "" {
+		return defaultCacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(s)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid cache_ttl %q in the config file (use a duration such as \"10m\", or \"0\" to always ask the API)", s)
+	}
+	return ttl, nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+)
+
+const areaCodeHint = "Please visit https://geoshape.ex.nii.ac.jp/ka/resource/ to find the appropriate area code."
+
+// command is a goHeadache subcommand.
+type command struct {
+	name  string
+	args  string // argument synopsis shown after the command name
+	short string // one-line description for the command list
+	// setup registers the command's flags on fs and returns the function
+	// that runs the command with the remaining positional arguments.
+	setup func(fs *flag.FlagSet) func(args []string) error
+	// failCode replaces the exit codes of errors (1) and usage errors (2)
+	// for commands whose exit code reports a result.
+	failCode int
+}
+
+// commands lists every subcommand in the order shown by help.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		forecastCommand,
+		searchCommand,
+		checkCommand,
+		statusCommand,
+		batchCommand,
+		historyCommand,
+		statsCommand,
+		accuracyCommand,
+		logCommand,
+		analyzeCommand,
+		modelCommand,
+		exportCommand,
+		importCommand,
+		daemonCommand,
+		serviceCommand,
+		exporterCommand,
+		serveCommand,
+		mapCommand,
+		configCommand,
+		versionCommand,
+		helpCommand,
+	}
+}
+
+var helpCommand = &command{
+	name:  "help",
+	args:  "[command]",
+	short: "Show help for a command",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		return func(args []string) error {
+			if len(args) == 0 {
+				printUsage()
+				return nil
+			}
+			cmd := findCommand(args[0])
+			if cmd == nil {
+				return fmt.Errorf("unknown command %q", args[0])
+			}
+			fs := cmd.newFlagSet()
+			cmd.setup(fs)
+			fs.SetOutput(os.Stdout)
+			fs.Usage()
+			return nil
+		}
+	},
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func printUsage() {
+	w := os.Stderr
+	fmt.Fprintln(w, "Usage:  goHeadache <command> [arguments]")
+	fmt.Fprintln(w, "        goHeadache <area_code> [flags]   (shorthand for forecast)")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.short)
+	}
+	fmt.Fprintln(w, "\nRun 'goHeadache help <command>' for details on a command.")
+	fmt.Fprintln(w, "\n"+areaCodeHint)
+}
+
+// newFlagSet returns a flag set for the command with a usage message that
+// lists the command's synopsis and flags.
+func (c *command) newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("goHeadache "+c.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintf(w, "Usage:  goHeadache %s %s\n\n%s\n", c.name, c.args, c.short)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(w, "\nOptions:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// parseInterspersed parses flags that may appear before, between or after
+// positional arguments, returning the positional arguments in order.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// run dispatches args to a subcommand and returns the process exit code. A
+// panic outside the TUI, which runProgram handles, also leaves a crash
+// report.
+func run(args []string) (code int) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintln(os.Stderr, trf("Error: %s", crashError(r, debug.Stack()).Error()))
+			code = 1
+		}
+	}()
+	cmd := forecastCommand
+	if len(args) > 0 {
+		switch {
+		case args[0] == "-h" || args[0] == "-help" || args[0] == "--help":
+			printUsage()
+			return 0
+		case args[0] == "-version" || args[0] == "--version":
+			cmd = versionCommand
+			args = args[1:]
+		case findCommand(args[0]) != nil:
+			cmd = findCommand(args[0])
+			args = args[1:]
+		case !strings.HasPrefix(args[0], "-") && !isAreaCode(args[0]):
+			fmt.Fprintf(os.Stderr, "Error: unknown command %q\n\n", args[0])
+			printUsage()
+			return 2
+		}
+	}
+
+	exitCode := func(code int) int {
+		if cmd.failCode != 0 {
+			return cmd.failCode
+		}
+		return code
+	}
+	fs := cmd.newFlagSet()
+	runFn := cmd.setup(fs)
+	positional, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		return exitCode(2)
+	}
+
+	if err := runFn(positional); err != nil {
+		var status exitStatus
+		if errors.As(err, &status) {
+			return int(status)
+		}
+		var uerr usageError
+		if errors.As(err, &uerr) {
+			fmt.Fprintf(os.Stderr, "%s\n\n", trf("Error: %s", trError(err)))
+			fs.Usage()
+			return exitCode(2)
+		}
+		fmt.Fprintln(os.Stderr, trf("Error: %s", trError(err)))
+		return exitCode(1)
+	}
+	return 0
+}
+
+// exitStatus makes run exit with the status without printing anything, for
+// commands whose exit code is their result.
+type exitStatus int
+
+func (e exitStatus) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+
+// usageError reports invalid command-line usage; run prints the command's
+// usage after it.
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+// isAreaCode reports whether s looks like a numeric area code, which lets
+// "goHeadache 13101" keep working as a shorthand for the forecast command.
+// Full-width digits count, as areas.Normalize takes them.
+func isAreaCode(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < '０' || r > '９') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+
+	"goHeadache/pkg/zutool"
+)
+
+// clockNow is the time the forecast screens take as now. -now fixes it, so
+// that a -fixture shows the same hours whenever it is run.
+var clockNow = time.Now
+
+// hour12 is whether times are shown on the 12-hour clock, selected by
+// setupClock. CSV and other machine-readable output stay on the 24-hour
+// clock.
+var hour12 bool
+
+// setupClock selects the clock of the config file, overridden by flagValue.
+func setupClock(cfg Config, flagValue string) error {
+	name := cfg.Clock
+	if flagValue != "" {
+		name = flagValue
+	}
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "24h", "24":
+		hour12 = false
+	case "12h", "12":
+		hour12 = true
+	default:
+		return fmt.Errorf("unknown clock %q (use 12h or 24h)", name)
+	}
+	return nil
+}
+
+// formatHour formats an hour of the day, such as "14:00" or "2 PM".
+func formatHour(h int) string {
+	if !hour12 {
+		return fmt.Sprintf("%02d:00", h)
+	}
+	h12 := h % 12
+	if h12 == 0 {
+		h12 = 12
+	}
+	if h < 12 {
+		return trf("%d AM", h12)
+	}
+	return trf("%d PM", h12)
+}
+
+// hourWidth is the width of the widest hour formatHour returns.
+func hourWidth() int {
+	return max(lipgloss.Width(formatHour(10)), lipgloss.Width(formatHour(22)))
+}
+
+// formatClock formats the time of day of t, such as "14:05" or "2:05 PM".
+func formatClock(t time.Time) string {
+	if !hour12 {
+		return t.Format("15:04")
+	}
+	if t.Hour() < 12 {
+		return trf("%s AM", t.Format("3:04"))
+	}
+	return trf("%s PM", t.Format("3:04"))
+}
+
+// formatDate formats the day of t in the language of the UI, such as
+// "Wed, 14 Oct".
+func formatDate(t time.Time) string {
+	return trf("%s, %s", tr(t.Format("Mon")), t.Format(tr("2 Jan")))
+}
+
+// issuedAt returns when the forecast of data was issued, from its dateTime
+// such as "2026-10-14 11".
+func issuedAt(data zutool.WeatherData) (time.Time, bool) {
+	t, err := time.ParseInLocation("2006-01-02 15", strings.TrimSpace(data.DateTime), time.Local)
+	return t, err == nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+var accuracyCommand = &command{
+	name:  "accuracy",
+	args:  "[area_code] [flags]",
+	short: "Compare the forecasts recorded in the history with the pressure measured later",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		sinceFlag := fs.String("since", "30d", "How far back to look, such as 30d, 2w or 0 for the whole history")
+		formatFlag := fs.String("format", "table", "Output format: table or json")
+		byFlag := fs.String("by", "lead", "Break the errors down by lead (days ahead) or hour (of the day)")
+		leadFlag := fs.Int("lead", 1, "With -by hour, the forecasts made this many days ahead")
+		locationFlag := fs.String("location", "", "Use a saved location from the config file by name")
+		return func(args []string) error {
+			if len(args) > 1 {
+				return usageError("too many arguments")
+			}
+			if *formatFlag != "table" && *formatFlag != "json" {
+				return usageError(fmt.Sprintf("unknown format %q (use table or json)", *formatFlag))
+			}
+			if *byFlag != "lead" && *byFlag != "hour" {
+				return usageError(fmt.Sprintf("unknown breakdown %q (use lead or hour)", *byFlag))
+			}
+			if *leadFlag < 0 {
+				return usageError("-lead must not be negative")
+			}
+			since, err := parseDays(*sinceFlag)
+			if err != nil {
+				return usageError(err.Error())
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := setupHistory(cfg.History); err != nil {
+				return err
+			}
+			if history == nil {
+				return errHistoryDisabled
+			}
+			areaCode, err := cfg.selectArea(args, *locationFlag)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			var from, readFrom time.Time
+			if since > 0 {
+				from = midnight(now.Add(-since))
+				// Forecasts go up to three days ahead, so the hours from
+				// from on were also forecast before it.
+				readFrom = from.AddDate(0, 0, -3)
+			}
+			records, err := history.records(areaCode, readFrom, now)
+			if err != nil {
+				return fmt.Errorf("error reading the history: %v", err)
+			}
+			rep := newAccuracyReport(areaCode, records, from, *byFlag == "hour", *leadFlag)
+			if rep.MeasuredHours == 0 {
+				return fmt.Errorf("no measured hours are recorded for area code %s yet; they are recorded with the forecasts of the day after, when observed is on under [history]", areaCode)
+			}
+			if *formatFlag == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(rep)
+			}
+			rep.writeTable(os.Stdout)
+			return nil
+		}
+	},
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+var analyzeCommand = &command{
+	name:  "analyze",
+	args:  "[area_code] [flags]",
+	short: "Find which weather conditions most often come before the headaches in the diary",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		sinceFlag := fs.String("since", "0", "How far back to look, such as 90d; 0 uses the whole history")
+		windowFlag := fs.Duration("window", 6*time.Hour, "How long before a headache to look at the weather")
+		minSeverityFlag := fs.Int("min-severity", 1, "Only analyze headaches at least this severe")
+		formatFlag := fs.String("format", "table", "Output format: table or json")
+		locationFlag := fs.String("location", "", "Use a saved location from the config file by name")
+		return func(args []string) error {
+			if len(args) > 1 {
+				return usageError("too many arguments")
+			}
+			if *formatFlag != "table" && *formatFlag != "json" {
+				return usageError(fmt.Sprintf("unknown format %q (use table or json)", *formatFlag))
+			}
+			if *windowFlag < time.Hour || *windowFlag > 72*time.Hour {
+				return usageError("-window must be between 1h and 72h")
+			}
+			since, err := parseDays(*sinceFlag)
+			if err != nil {
+				return usageError(err.Error())
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := setupHistory(cfg.History); err != nil {
+				return err
+			}
+			if history == nil {
+				return errHistoryDisabled
+			}
+			areaCode, err := cfg.selectArea(args, *locationFlag)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			var from time.Time
+			if since > 0 {
+				from = midnight(now.Add(-since))
+			}
+			diary, err := readDiary()
+			if err != nil {
+				return err
+			}
+			// Episodes logged without an area are taken to be in the one
+			// analyzed.
+			var entries []diaryEntry
+			for _, e := range diary {
+				if (e.Area == areaCode || e.Area == "") && !e.Time.Before(from) && e.Severity >= *minSeverityFlag {
+					entries = append(entries, e)
+				}
+			}
+			if len(entries) == 0 {
+				return fmt.Errorf("no headaches are logged for area code %s yet (record them with `goHeadache log`)", areaCode)
+			}
+			records, err := history.records(areaCode, from.Add(-*windowFlag), now)
+			if err != nil {
+				return fmt.Errorf("error reading the history: %v", err)
+			}
+			a := analyzeEpisodes(areaCode, entries, recordedHours(records), *windowFlag)
+			if a.Episodes == 0 {
+				return fmt.Errorf("none of the %d headaches logged for area code %s has enough history before it", len(entries), areaCode)
+			}
+			if *formatFlag == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(a)
+			}
+			a.writeTable(os.Stdout)
+			return nil
+		}
+	},
+}
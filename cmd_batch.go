@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"goHeadache/internal/areas"
+)
+
+var batchCommand = &command{
+	name:  "batch",
+	args:  "[area_code...] [flags]",
+	short: "Fetch the forecasts of many areas and print one JSON line per area, for your own aggregations",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		fileFlag := fs.String("file", "", "Read the areas from this file, one area code or saved location name per line; - reads standard input")
+		outputFlag := fs.String("output", "json", "Output format: json, one object per line (NDJSON), or csv, one row per hour")
+		dayFlag := fs.String("day", "", "Only output the hours of this day: yesterday, today, tomorrow or dayafter")
+		workersFlag := fs.Int("workers", maxConcurrentFetches, "How many areas to fetch at once")
+		rateFlag := fs.Float64("rate", 0, "How many requests per second to send to zutool at most (default rate_limit from config, else 5)")
+		offlineFlag := fs.Bool("offline", false, "Output the last cached forecasts without using the network")
+		nearestFlag := fs.Bool("nearest", false, "Output the nearest known area when an area code has no forecast")
+		network := addNetworkFlags(fs)
+		return func(args []string) error {
+			if *outputFlag != "json" && *outputFlag != "csv" {
+				return usageError(fmt.Sprintf("unknown output %q (use json or csv)", *outputFlag))
+			}
+			if _, err := dayIndices(*dayFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if *workersFlag < 1 {
+				return usageError("-workers must be at least 1")
+			}
+			if *rateFlag < 0 {
+				return usageError("-rate must not be negative")
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			offlineMode = *offlineFlag
+			useNearest = *nearestFlag
+			if err := setupFetching(cfg, network); err != nil {
+				return err
+			}
+			if err := setupRateLimit(cfg, *rateFlag); err != nil {
+				return err
+			}
+
+			areaCodes, err := batchAreas(cfg, args, *fileFlag)
+			if err != nil {
+				return err
+			}
+			if len(areaCodes) == 0 {
+				return usageError("no areas to fetch (pass area codes, or a file of them with -file)")
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			b := batch{workers: *workersFlag, day: *dayFlag, weights: cfg.Risk}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			b.out = &batchJSON{enc: enc}
+			if *outputFlag == "csv" {
+				b.out = &batchCSV{w: csv.NewWriter(os.Stdout)}
+			}
+			failed, done := b.run(ctx, areaCodes)
+			switch {
+			case done < len(areaCodes):
+				return fmt.Errorf("interrupted after %d of %d areas", done, len(areaCodes))
+			case failed > 0:
+				return fmt.Errorf("%d of %d areas failed", failed, len(areaCodes))
+			}
+			return nil
+		}
+	},
+}
+
+// batchAreas returns the area codes of args and of the lines of the file at
+// path, in order and without repeats. A line is an area code or a saved
+// location name; blank lines and text after # are skipped.
+func batchAreas(cfg Config, args []string, path string) ([]string, error) {
+	var codes []string
+	seen := map[string]bool{}
+	add := func(name string) error {
+		code := name
+		if loc, found := cfg.findLocation(name); found {
+			code = loc.Area
+		}
+		code, err := areas.Normalize(code)
+		if err != nil {
+			return err
+		}
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+		return nil
+	}
+	for _, arg := range args {
+		if err := add(arg); err != nil {
+			return nil, err
+		}
+	}
+	if path == "" {
+		return codes, nil
+	}
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		if err := add(line); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return codes, scanner.Err()
+}
+
+// batchLine is a line of batch -output json: the forecast of an area as
+// /v1/forecast/{area} of serve has it, or why it could not be loaded.
+type batchLine struct {
+	AreaCode  string `json:"area_code"`
+	Place     string `json:"place,omitempty"`
+	FetchedAt string `json:"fetched_at,omitempty"`
+	Stale     string `json:"stale,omitempty"`
+	// Substitute is the area code whose forecast this is with -nearest,
+	// when AreaCode has none.
+	Substitute string    `json:"substitute,omitempty"`
+	Hours      []apiHour `json:"hours,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// batch loads the forecasts of many areas for the batch command.
+type batch struct {
+	// workers is how many areas are loaded at once; the requests they send
+	// are spaced out by the limiter of apiClient.
+	workers int
+	// day is the only day whose hours are written, unless it is empty.
+	day     string
+	weights RiskWeights
+	out     batchWriter
+}
+
+// run loads the forecasts of areaCodes and writes each as soon as it is
+// loaded, so not in the order of areaCodes. It returns how many areas
+// failed and how many were done before ctx was cancelled.
+func (b batch) run(ctx context.Context, areaCodes []string) (failed, done int) {
+	var mu sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(b.workers)
+	for _, code := range areaCodes {
+		if ctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			w, err := loadWeather(ctx, code, true)
+			if ctx.Err() != nil {
+				return nil
+			}
+			line := b.line(code, w, err)
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if err != nil {
+				failed++
+			}
+			if err := b.out.write(line); err != nil {
+				debugLog.Warn("error writing batch output", "area", code, "error", err)
+			}
+			return nil
+		})
+	}
+	g.Wait()
+	return failed, done
+}
+
+// line returns the line of areaCode, whose forecast w was loaded with err.
+func (b batch) line(areaCode string, w weatherResult, err error) batchLine {
+	line := batchLine{AreaCode: areaCode}
+	if err != nil {
+		line.Error = err.Error()
+		return line
+	}
+	line.Place = w.data.PlaceName
+	line.FetchedAt = w.fetchedAt.Format(time.RFC3339)
+	if w.stale != nil {
+		line.Stale = w.stale.Error()
+	}
+	line.Substitute = w.substitute
+	for _, h := range apiHours(b.weights, w.data, time.Now()) {
+		if b.day == "" || strings.EqualFold(h.Day, b.day) {
+			line.Hours = append(line.Hours, h)
+		}
+	}
+	return line
+}
+
+// batchWriter writes the lines of batch in an output format.
+type batchWriter interface {
+	write(line batchLine) error
+}
+
+// batchJSON writes each line as a JSON object on its own line.
+type batchJSON struct {
+	enc *json.Encoder
+}
+
+func (b *batchJSON) write(line batchLine) error {
+	return b.enc.Encode(line)
+}
+
+// batchCSV writes a row per hour of each line, and the areas that failed
+// to stderr.
+type batchCSV struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (b *batchCSV) write(line batchLine) error {
+	if line.Error != "" {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", line.AreaCode, line.Error)
+		return nil
+	}
+	if !b.header {
+		b.header = true
+		b.w.Write([]string{"area_code", "place", "time", "day", "weather", "temp", "pressure", "pressure_level", "pressure_change", "risk"})
+	}
+	value := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	}
+	for _, h := range line.Hours {
+		level := ""
+		if h.PressureLevel != nil {
+			level = strconv.Itoa(*h.PressureLevel)
+		}
+		b.w.Write([]string{line.AreaCode, line.Place, h.Time, h.Day, h.Weather,
+			value(h.Temp), value(h.Pressure), level, value(h.PressureChange), strconv.Itoa(h.Risk)})
+	}
+	b.w.Flush()
+	return b.w.Error()
+}
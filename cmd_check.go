@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"goHeadache/pkg/zutool"
+)
+
+// checkStatus is the result of check, and its exit code.
+type checkStatus int
+
+const (
+	checkOK checkStatus = iota
+	checkModerate
+	checkSevere
+	// checkUnknown is the exit code when the forecast could not be checked.
+	checkUnknown
+)
+
+func (s checkStatus) String() string {
+	switch s {
+	case checkOK:
+		return "ok"
+	case checkModerate:
+		return "moderate"
+	case checkSevere:
+		return "severe"
+	}
+	return "unknown"
+}
+
+var checkCommand = &command{
+	name:  "check",
+	args:  "[area_code] [flags]",
+	short: "Print a one-line pressure summary; exit 0 (ok), 1 (moderate) or 2 (severe)",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		hoursFlag := fs.Int("hours", 6, "How many hours ahead to check")
+		locationFlag := fs.String("location", "", "Use a saved location from the config file by name")
+		unitsFlag := fs.String("units", "", "Units to show, such as F,inHg, mmHg or imperial (default from config, else metric)")
+		clockFlag := fs.String("clock", "", "Clock of the times shown: 12h or 24h (default from config, else 24h)")
+		quietFlag := fs.Bool("quiet", false, "Print nothing; only set the exit code")
+		notifyFlag := fs.Bool("notify", false, "Also send a desktop notification when the [notify] thresholds are crossed")
+		offlineFlag := fs.Bool("offline", false, "Check the last cached forecast without using the network")
+		nearestFlag := fs.Bool("nearest", false, "Check the nearest known area when the area code has no forecast")
+		network := addNetworkFlags(fs)
+		return func(args []string) error {
+			if len(args) > 1 {
+				return usageError("too many arguments")
+			}
+			if *hoursFlag < 1 {
+				return usageError("-hours must be at least 1")
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := setupUnits(cfg, *unitsFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := setupClock(cfg, *clockFlag); err != nil {
+				return usageError(err.Error())
+			}
+			offlineMode = *offlineFlag
+			useNearest = *nearestFlag
+			if err := setupFetching(cfg, network); err != nil {
+				return err
+			}
+
+			areaCode, err := cfg.selectArea(args, *locationFlag)
+			if err != nil {
+				return err
+			}
+
+			w, err := loadWeather(context.Background(), areaCode, true)
+			if err != nil {
+				return err
+			}
+			noteSubstitute(areaCode, w)
+			data := w.data
+			now := time.Now()
+			status, summary := checkSummary(data, now, *hoursFlag)
+			if !*quietFlag {
+				fmt.Println(summary)
+			}
+			if *notifyFlag {
+				if w, ok := findWarning(data, cfg.Notify, now); ok {
+					title, body := w.message(data.PlaceName)
+					if err := sendNotification(title, body); err != nil {
+						fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+					}
+				}
+			}
+			if status == checkOK {
+				return nil
+			}
+			return exitStatus(status)
+		}
+	},
+	failCode: int(checkUnknown),
+}
+
+// checkSummary rates the next hours of w: severe when an hour reaches level
+// 4 or the pressure falls 1 hPa or more within an hour, moderate for level 3
+// or a drop of 0.5 hPa, else ok. The summary reads like
+// "千代田区: severe · level 4 at 15:00 · -1.2 hPa/h at 14:00 (next 6h)".
+func checkSummary(w zutool.WeatherData, now time.Time, hours int) (checkStatus, string) {
+	var (
+		maxLevel, levelAt = -1, time.Time{}
+		maxDrop, dropAt   = 0.0, time.Time{}
+	)
+	for _, u := range upcomingHours(w, now, hours) {
+		if level, err := strconv.Atoi(strings.TrimSpace(u.entry.PressureLevel)); err == nil && level > maxLevel {
+			maxLevel, levelAt = level, u.at
+		}
+		if u.deltaOK && -u.delta > maxDrop {
+			maxDrop, dropAt = -u.delta, u.at
+		}
+	}
+
+	status := checkOK
+	switch {
+	case maxLevel >= 4 || maxDrop >= -dropSevereHPa:
+		status = checkSevere
+	case maxLevel >= 3 || maxDrop >= -dropWarnHPa:
+		status = checkModerate
+	}
+
+	parts := []string{fmt.Sprintf("%s: %s", w.PlaceName, status)}
+	if maxLevel >= 0 {
+		parts = append(parts, fmt.Sprintf("level %d (%s) at %s", maxLevel, levelName(strconv.Itoa(maxLevel)), formatClock(levelAt)))
+	} else {
+		parts = append(parts, "no pressure levels")
+	}
+	if maxDrop > 0 {
+		parts = append(parts, fmt.Sprintf("%s at %s", units.formatDropRate(maxDrop), formatClock(dropAt)))
+	}
+	return status, fmt.Sprintf("%s (next %dh)", strings.Join(parts, " · "), hours)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+var configCommand = &command{
+	name:  "config",
+	args:  "[path|show|init]",
+	short: "Show or create the configuration file",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		force := fs.Bool("force", false, "Overwrite an existing file with init")
+		return func(args []string) error {
+			action := "show"
+			if len(args) > 0 {
+				action = args[0]
+			}
+			if len(args) > 1 {
+				return usageError("too many arguments")
+			}
+
+			path, err := configPath()
+			if err != nil {
+				return err
+			}
+
+			switch action {
+			case "path":
+				fmt.Println(path)
+				return nil
+			case "show":
+				cfg, err := loadConfig()
+				if err != nil {
+					return err
+				}
+				out, err := toml.Marshal(cfg.redacted())
+				if err != nil {
+					return err
+				}
+				fmt.Printf("# %s\n%s", path, out)
+				return nil
+			case "init":
+				return initConfig(path, *force)
+			default:
+				return usageError(fmt.Sprintf("unknown config action %q", action))
+			}
+		}
+	},
+}
+
+// redacted returns cfg without its secrets, to keep them out of terminal
+// scrollback, pasted output and crash reports.
+func (cfg Config) redacted() Config {
+	if cfg.OWMAPIKey != "" {
+		cfg.OWMAPIKey = "(set)"
+	}
+	if cfg.MQTT.Password != "" {
+		cfg.MQTT.Password = "(set)"
+	}
+	// Webhook URLs carry their token in the path.
+	cfg.Webhooks = append([]WebhookConfig(nil), cfg.Webhooks...)
+	for i := range cfg.Webhooks {
+		cfg.Webhooks[i].URL = redactURL(cfg.Webhooks[i].URL)
+	}
+	return cfg
+}
+
+// initConfig writes the commented default config to path.
+func initConfig(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists (use -force to overwrite)", path)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating config directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(defaultConfigTemplate), 0o644); err != nil {
+		return fmt.Errorf("error writing config: %v", err)
+	}
+	fmt.Printf("Wrote %s\n", path)
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"goHeadache/internal/areas"
+)
+
+var daemonCommand = &command{
+	name:  "daemon",
+	args:  "[area_code...] [flags]",
+	short: "Check the forecast periodically in the background and send alerts",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		intervalFlag := fs.Duration("interval", 0, "How often to check the forecast (default from config, else 30m)")
+		onceFlag := fs.Bool("once", false, "Check once and exit, e.g. from cron")
+		dryRunFlag := fs.Bool("dry-run", false, "Log the alerts and webhook payloads that would be sent instead of sending them")
+		network := addNetworkFlags(fs)
+		return func(args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := setupUnits(cfg, ""); err != nil {
+				return err
+			}
+			if err := setupClock(cfg, ""); err != nil {
+				return err
+			}
+			if err := setupFetching(cfg, network); err != nil {
+				return err
+			}
+			interval, err := parseDaemonInterval(cfg.Daemon.Interval)
+			if err != nil {
+				return err
+			}
+			if isFlagSet(fs, "interval") {
+				if *intervalFlag < minDaemonInterval {
+					return usageError("-interval must be at least 1m")
+				}
+				interval = *intervalFlag
+			}
+
+			areaCodes := cfg.watchedAreas(args)
+			if len(areaCodes) == 0 {
+				return usageError("no areas to watch (pass area codes, or set areas under [daemon], locations or area in the config file)")
+			}
+			for i, code := range areaCodes {
+				var err error
+				if areaCodes[i], err = areas.Normalize(code); err != nil {
+					return err
+				}
+			}
+
+			publisher, err := newMQTTPublisher(cfg.MQTT, notifyHTTPClient)
+			if err != nil {
+				return err
+			}
+
+			rules, err := alertRules(cfg)
+			if err != nil {
+				return err
+			}
+			// Publishing to MQTT is enough for a daemon without alerts.
+			if len(rules) == 0 && publisher == nil {
+				return usageError("no alert rules: add [[rules]] to the config file, or set level or drop under [notify]")
+			}
+
+			quiet, err := parseQuietHours(cfg.Daemon.QuietHours)
+			if err != nil {
+				return fmt.Errorf("[daemon]: %v", err)
+			}
+
+			digestAt, digest, err := parseDigestAt(cfg.Daemon.DigestAt)
+			if err != nil {
+				return fmt.Errorf("[daemon]: %v", err)
+			}
+
+			var notifiers []notifier
+			if cfg.Daemon.Desktop {
+				notifiers = append(notifiers, desktopNotifier{})
+			}
+			webhooks, err := daemonWebhooks(cfg)
+			if err != nil {
+				return err
+			}
+			notifiers = append(notifiers, webhooks...)
+			hooks, err := daemonHooks(cfg)
+			if err != nil {
+				return err
+			}
+			notifiers = append(notifiers, hooks...)
+			if len(notifiers) == 0 {
+				if publisher == nil {
+					return usageError("no alert channels are enabled under [daemon] in the config file")
+				}
+				// Without channels the daemon only publishes to MQTT.
+				rules, digest = nil, false
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			d := &daemon{
+				areaCodes: areaCodes,
+				interval:  interval,
+				rules:     rules,
+				quiet:     quiet,
+				digestAt:  digestAt,
+				digest:    digest,
+				risk:      cfg.Risk,
+				notifiers: notifiers,
+				mqtt:      publisher,
+				state:     loadAlertState(),
+				log:       log.New(os.Stderr, "", log.LstdFlags),
+				dryRun:    *dryRunFlag,
+			}
+			return d.run(ctx, *onceFlag)
+		}
+	},
+}
+
+// daemonWebhooks returns the notifiers of the webhooks named under [daemon],
+// or of every webhook when it names none.
+func daemonWebhooks(cfg Config) ([]notifier, error) {
+	byName := map[string]WebhookConfig{}
+	for _, w := range cfg.Webhooks {
+		byName[w.Name] = w
+	}
+	selected := cfg.Webhooks
+	if len(cfg.Daemon.Webhooks) > 0 {
+		selected = nil
+		for _, name := range cfg.Daemon.Webhooks {
+			w, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("no webhook named %q in the config file", name)
+			}
+			selected = append(selected, w)
+		}
+	}
+	var notifiers []notifier
+	for _, w := range selected {
+		n, err := newWebhookNotifier(w, notifyHTTPClient)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
+
+// daemonHooks returns the notifiers of the hooks named under [daemon], or
+// of every hook when it names none.
+func daemonHooks(cfg Config) ([]notifier, error) {
+	byName := map[string]HookConfig{}
+	for _, h := range cfg.Hooks {
+		byName[h.Name] = h
+	}
+	selected := cfg.Hooks
+	if len(cfg.Daemon.Hooks) > 0 {
+		selected = nil
+		for _, name := range cfg.Daemon.Hooks {
+			h, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("no hook named %q in the config file", name)
+			}
+			selected = append(selected, h)
+		}
+	}
+	var notifiers []notifier
+	for _, h := range selected {
+		n, err := newHookNotifier(h)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+var exportCommand = &command{
+	name:  "export",
+	args:  "[area_code...] [flags]",
+	short: "Dump the diary and the history as JSON or CSV, for backups, other machines or spreadsheets",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		whatFlag := fs.String("what", "all", "What to export: all, diary or history")
+		formatFlag := fs.String("format", "json", "Output format: json, or csv with -what diary or history")
+		fileFlag := fs.String("file", "", "Write to this file instead of standard output")
+		sinceFlag := fs.String("since", "0", "How far back to export, such as 30d; 0 exports everything")
+		return func(args []string) error {
+			if *whatFlag != "all" && *whatFlag != "diary" && *whatFlag != "history" {
+				return usageError(fmt.Sprintf("unknown -what %q (use all, diary or history)", *whatFlag))
+			}
+			if *formatFlag != "json" && *formatFlag != "csv" {
+				return usageError(fmt.Sprintf("unknown format %q (use json or csv)", *formatFlag))
+			}
+			if *formatFlag == "csv" && *whatFlag == "all" {
+				return usageError("-format csv needs -what diary or -what history")
+			}
+			if len(args) > 0 && *whatFlag == "diary" {
+				return usageError("area codes only select the history")
+			}
+			since, err := parseDays(*sinceFlag)
+			if err != nil {
+				return usageError(err.Error())
+			}
+			now := time.Now()
+			var from time.Time
+			if since > 0 {
+				from = midnight(now.Add(-since))
+			}
+
+			d := dataDump{Version: dumpVersion, ExportedAt: now}
+			if *whatFlag != "history" {
+				entries, err := readDiary()
+				if err != nil {
+					return err
+				}
+				for _, e := range entries {
+					if !e.Time.Before(from) {
+						d.Diary = append(d.Diary, e)
+					}
+				}
+			}
+			if *whatFlag != "diary" {
+				if d.History, err = exportHistory(args, from, now); err != nil {
+					return err
+				}
+			}
+
+			w := os.Stdout
+			if *fileFlag != "" {
+				if w, err = os.Create(*fileFlag); err != nil {
+					return err
+				}
+				defer w.Close()
+			}
+			switch {
+			case *formatFlag == "json":
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(d)
+			case *whatFlag == "diary":
+				err = writeDiaryCSV(w, d.Diary)
+			default:
+				err = writeHistoryCSV(w, d.History)
+			}
+			if err != nil {
+				return err
+			}
+			if w != os.Stdout {
+				return w.Close()
+			}
+			return nil
+		}
+	},
+}
+
+// exportHistory returns the records of the areas fetched from from until
+// to. Without area codes it exports every area in the history.
+func exportHistory(areaCodes []string, from, to time.Time) (map[string][]historyRecord, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := setupHistory(cfg.History); err != nil {
+		return nil, err
+	}
+	if history == nil {
+		return nil, errHistoryDisabled
+	}
+	if len(areaCodes) == 0 {
+		if areaCodes, err = history.areas(); err != nil {
+			return nil, fmt.Errorf("error reading the history: %v", err)
+		}
+	}
+	out := map[string][]historyRecord{}
+	for _, code := range areaCodes {
+		records, err := history.records(code, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the history of %s: %v", code, err)
+		}
+		if len(records) > 0 {
+			out[code] = records
+		}
+	}
+	return out, nil
+}
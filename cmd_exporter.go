@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"goHeadache/internal/areas"
+)
+
+var exporterCommand = &command{
+	name:  "exporter",
+	args:  "[area_code...] [flags]",
+	short: "Serve the forecast as Prometheus metrics",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		listenFlag := fs.String("listen", ":9109", "Address to serve /metrics on")
+		hoursFlag := fs.Int("hours", 24, "How many hours ahead the forecast metrics go")
+		network := addNetworkFlags(fs)
+		return func(args []string) error {
+			if *hoursFlag < 0 || *hoursFlag > maxRuleWindow {
+				return usageError(fmt.Sprintf("-hours must be between 0 and %d", maxRuleWindow))
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := setupFetching(cfg, network); err != nil {
+				return err
+			}
+			areaCodes := cfg.watchedAreas(args)
+			if len(areaCodes) == 0 {
+				return usageError("no areas to export (pass area codes, or set areas under [daemon], locations or area in the config file)")
+			}
+			for i, code := range areaCodes {
+				var err error
+				if areaCodes[i], err = areas.Normalize(code); err != nil {
+					return err
+				}
+			}
+
+			e := &exporter{areaCodes: areaCodes, hours: *hoursFlag, risk: cfg.Risk, errors: map[string]int{}}
+			return listenAndServe(*listenFlag, e, "exporting %d area(s) on http://%s/metrics", len(areaCodes), *listenFlag)
+		}
+	},
+}
+
+// listenAndServe serves handler on addr until SIGINT or SIGTERM, logging the
+// message first.
+func listenAndServe(addr string, handler http.Handler, format string, args ...any) error {
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	srv := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second, ErrorLog: logger}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	logger.Printf(format, args...)
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	logger.Print("stopped")
+	return nil
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/term"
+
+	"goHeadache/internal/areas"
+	"goHeadache/internal/source"
+)
+
+var forecastCommand = &command{
+	name:  "forecast",
+	args:  "[area_code...] [flags]",
+	short: "Show the hourly weather and pressure forecast",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		dayFlag := fs.String("day", "", "Filter output by day (yesterday, today, tomorrow, dayafter)")
+		outputFlag := fs.String("output", "tui", "Output mode (tui, csv, json)")
+		fileFlag := fs.String("file", "", "Write csv or json output to this file instead of stdout")
+		placeFlag := fs.String("place", "", "Look up the area code by place name (e.g. \"Osaka\" or \"東京\")")
+		locationFlag := fs.String("location", "", "Use a saved location from the config file by name")
+		postalFlag := fs.String("postal", "", "Look up the area code by Japanese postal code (e.g. 160-0022)")
+		autoLocateFlag := fs.Bool("auto-locate", false, "Guess the area from the IP address with ipapi.co and ask to confirm it")
+		iconsFlag := fs.String("icons", "", "Weather icons: none, emoji, nerd or ascii (default from config, else none)")
+		themeFlag := fs.String("theme", "", "Color theme: auto, light, dark, high-contrast or a theme from the config file (default from config, else auto)")
+		paletteFlag := fs.String("palette", "", "Severity colors: default, deuteranopia, protanopia or tritanopia (default from config)")
+		langFlag := fs.String("lang", "", "Language of the screens: auto, en, ja or zh (default from config, else en)")
+		unitsFlag := fs.String("units", "", "Units to show, such as F,inHg, mmHg or imperial (default from config, else metric)")
+		clockFlag := fs.String("clock", "", "Clock of the times shown: 12h or 24h (default from config, else 24h)")
+		hoursFlag := fs.String("hours", "", "Show only these hours of the day in the table, such as 7-23; n toggles the rest (default from config, else all)")
+		rainFlag := fs.Bool("rain", false, "Add a column with the chance of precipitation from Open-Meteo (default from config)")
+		renderFlag := fs.Bool("render-once", false, "Print the first screen of the TUI with its colors and exit, for screenshots and pipes")
+		sizeFlag := fs.String("size", "", "Terminal size for -render-once, such as 100x30 (default the terminal's, else 100x30)")
+		accessibleFlag := fs.Bool("accessible", false, "Print the forecast as plain sentences for screen readers instead of opening the TUI")
+		compactFlag := fs.Bool("compact", false, "Show only time, pressure and level (used automatically below 50 columns)")
+		notifyFlag := fs.Bool("notify", false, "Send a desktop notification when an upcoming hour crosses the [notify] thresholds (default from config)")
+		watchFlag := fs.Bool("watch", false, "Refresh the forecast periodically")
+		intervalFlag := fs.Duration("interval", 10*time.Minute, "Refresh interval for -watch")
+		network := addNetworkFlags(fs)
+		offlineFlag := fs.Bool("offline", false, "Show the last cached forecast without using the network")
+		nearestFlag := fs.Bool("nearest", false, "Show the nearest known area when the area code has no forecast, saying which")
+		fixtureFlag := fs.String("fixture", "", "Show the forecast saved in this JSON file (zutool's getweatherstatus response) instead of fetching one")
+		stdinFlag := fs.Bool("stdin", false, "Show the forecast JSON read from stdin, as with -fixture")
+		nowFlag := fs.String("now", "", "Take this time as now, such as 2026-10-14T15:00 (default the time the -fixture was issued, else the current time)")
+		sourceFlag := fs.String("source", "", "Forecast source: zutool, jma, owm, or open-meteo for anywhere in the world with -lat and -lon (default from config, else zutool)")
+		latFlag := fs.Float64("lat", 0, "Latitude of the forecast: of the nearest area with zutool and jma, of the point itself with open-meteo and owm")
+		lonFlag := fs.Float64("lon", 0, "Longitude of the forecast, as for -lat")
+		compareFlag := fs.Bool("compare", false, "Compare the pressure forecast of several area codes (default: all saved locations)")
+		freshFlag := fs.Bool("fresh", false, "Start from the config file instead of the area, day, theme and view the last session left off with")
+		return func(args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			var session sessionState
+			resume := false
+			if !*freshFlag {
+				session, resume = loadSession()
+			}
+			theme := *themeFlag
+			if theme == "" {
+				theme = session.Theme
+			}
+			if err := setupTheme(cfg, theme); err != nil {
+				if *themeFlag != "" {
+					return usageError(err.Error())
+				}
+				// The remembered theme may have been removed from the config file.
+				theme = ""
+				if err := setupTheme(cfg, ""); err != nil {
+					return usageError(err.Error())
+				}
+			}
+			if err := setupPalette(cfg, *paletteFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := setupUnits(cfg, *unitsFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := setupClock(cfg, *clockFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := setupLang(cfg, *langFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if cacheTTL, err = parseCacheTTL(cfg.CacheTTL); err != nil {
+				return err
+			}
+			if err := setupHistory(cfg.History); err != nil {
+				return err
+			}
+			offlineMode = *offlineFlag
+			useNearest = *nearestFlag
+			fixture := *fixtureFlag
+			if *stdinFlag {
+				if fixture != "" {
+					return usageError("use either -fixture or -stdin")
+				}
+				fixture = "-"
+			}
+			if err := setupFixture(fixture, *nowFlag); err != nil {
+				return err
+			}
+			fetchRain = cfg.Rain
+			if isFlagSet(fs, "rain") {
+				fetchRain = *rainFlag
+			}
+			if err := network.setup(cfg); err != nil {
+				return err
+			}
+			sourceName := cfg.Source
+			if *sourceFlag != "" {
+				sourceName = *sourceFlag
+			}
+			owmClient.APIKey = cfg.owmAPIKey()
+			if weatherSource, err = newWeatherSource(sourceName); err != nil {
+				return usageError(err.Error())
+			}
+			coords := isFlagSet(fs, "lat") || isFlagSet(fs, "lon")
+			_, openMeteo := weatherSource.(source.OpenMeteo)
+			_, owmSource := weatherSource.(source.OWM)
+			switch {
+			case openMeteo && !coords:
+				return usageError("-source open-meteo needs -lat and -lon")
+			}
+			if *accessibleFlag {
+				switch {
+				case isFlagSet(fs, "output"):
+					return usageError("-accessible prints sentences; it cannot be combined with -output")
+				case *compareFlag:
+					return usageError("-accessible cannot be combined with -compare")
+				case *watchFlag:
+					return usageError("-accessible cannot be combined with -watch")
+				}
+			}
+			if *renderFlag {
+				switch {
+				case isFlagSet(fs, "output"):
+					return usageError("-render-once prints the TUI; it cannot be combined with -output")
+				case *accessibleFlag:
+					return usageError("-render-once cannot be combined with -accessible")
+				case *compareFlag:
+					return usageError("-render-once cannot be combined with -compare")
+				case *watchFlag:
+					return usageError("-render-once cannot be combined with -watch")
+				}
+			} else if *sizeFlag != "" {
+				return usageError("-size only works with -render-once")
+			}
+			if *compareFlag {
+				if coords {
+					return usageError("-compare takes area codes, not -lat and -lon")
+				}
+				return runCompare(cfg, args, *dayFlag)
+			}
+			if len(args) > 1 {
+				return usageError("too many arguments (use -compare to view several area codes)")
+			}
+
+			areaCode := cfg.Area
+			if areaCode == "" && len(cfg.Locations) > 0 {
+				areaCode = cfg.Locations[0].Area
+			}
+			if areaCode == "" {
+				areaCode = session.Area
+			}
+			sources := 0
+			for _, set := range []bool{len(args) == 1, *placeFlag != "", *postalFlag != "", *locationFlag != "", *autoLocateFlag} {
+				if set {
+					sources++
+				}
+			}
+			if sources > 1 {
+				return usageError("use only one of an area code, -place, -postal, -location or -auto-locate")
+			}
+			locations := cfg.Locations
+			switch {
+			case coords:
+				if sources > 0 {
+					return usageError("use either an area code, -place, -postal, -location or -auto-locate, or -lat and -lon")
+				}
+				loc, err := source.AtCoords(*latFlag, *lonFlag)
+				if err != nil {
+					return usageError(err.Error())
+				}
+				if !openMeteo && !owmSource {
+					// The other sources take area codes.
+					if a, km, ok := areaAt(loc.Lat, loc.Lon); ok {
+						fmt.Fprintf(os.Stderr, "Using %s %s, the nearest area, %.0f km from %s\n", a.Code, a.FullName(), km, source.FormatCoords(loc.Lat, loc.Lon))
+						areaCode, coords = a.Code, false
+						break
+					}
+					fmt.Fprintf(os.Stderr, "No known area is within %.0f km of %s, using Open-Meteo\n", nearestAreaLimit, source.FormatCoords(loc.Lat, loc.Lon))
+					weatherSource = source.OpenMeteo{Client: openMeteoClient}
+				}
+				areaCode = loc.Key()
+				// Saved locations are zutool area codes.
+				locations = nil
+			case len(args) == 1:
+				areaCode = args[0]
+			case *placeFlag != "":
+				if areaCode, err = resolvePlace(*placeFlag); err != nil {
+					return err
+				}
+			case *postalFlag != "":
+				if areaCode, err = resolvePostal(*postalFlag); err != nil {
+					return err
+				}
+			case *locationFlag != "":
+				loc, ok := cfg.findLocation(*locationFlag)
+				if !ok {
+					return fmt.Errorf("no saved location named %q in the config file", *locationFlag)
+				}
+				areaCode = loc.Area
+			case *autoLocateFlag:
+				if areaCode, err = autoLocate(); err != nil {
+					return err
+				}
+			}
+			if areaCode == "" && fixtureData == nil && (*outputFlag != "tui" || *accessibleFlag || *renderFlag || !term.IsTerminal(os.Stdin.Fd())) {
+				return usageError("area code is required (pass it as an argument or set area in the config file)\n" + areaCodeHint)
+			}
+			if areaCode != "" && !coords {
+				if areaCode, err = areas.Normalize(areaCode); err != nil {
+					return err
+				}
+			}
+			day := cfg.Day
+			if isFlagSet(fs, "day") {
+				day = *dayFlag
+			}
+
+			icons := cfg.Icons
+			if isFlagSet(fs, "icons") {
+				icons = *iconsFlag
+			}
+			if err := validateIconSet(icons); err != nil {
+				return usageError(err.Error())
+			}
+
+			notify := cfg.Notify
+			if isFlagSet(fs, "notify") {
+				notify.Enabled = *notifyFlag
+			}
+
+			screenAlert, err := parseScreenAlert(cfg.ScreenAlert)
+			if err != nil {
+				return err
+			}
+
+			hoursValue := cfg.Hours
+			if isFlagSet(fs, "hours") {
+				hoursValue = *hoursFlag
+			}
+			var hours *hourRange
+			if hoursValue != "" {
+				r, err := parseHourRange(hoursValue)
+				if err != nil {
+					return usageError(err.Error())
+				}
+				hours = &r
+			}
+			hiddenColumns, err := parseHiddenColumns(cfg.HideColumns)
+			if err != nil {
+				return err
+			}
+
+			var watch time.Duration
+			if *watchFlag {
+				if *intervalFlag < time.Minute {
+					return usageError("-interval must be at least 1m")
+				}
+				watch = *intervalFlag
+			}
+
+			if *accessibleFlag {
+				return printAccessible(areaCode, day)
+			}
+			opts := forecastOptions{
+				areaCode:    areaCode,
+				day:         day,
+				output:      *outputFlag,
+				file:        *fileFlag,
+				locations:   locations,
+				watch:       watch,
+				risk:        cfg.Risk,
+				icons:       icons,
+				compact:     *compactFlag,
+				notify:      notify,
+				screenAlert: screenAlert,
+				hours:       hours,
+				hideColumns: hiddenColumns,
+			}
+			if resume {
+				opts.resume = &session
+			}
+			// A fixture and coordinates are not areas to come back to.
+			if fixtureData == nil && !coords {
+				opts.remember = &sessionState{Theme: theme}
+			}
+			if *renderFlag {
+				width, height, err := renderSize(*sizeFlag)
+				if err != nil {
+					return usageError(err.Error())
+				}
+				return renderOnce(os.Stdout, opts, width, height)
+			}
+			return runForecast(opts)
+		}
+	},
+}
+
+// runCompare opens the side-by-side comparison of areaCodes, or of every
+// saved location when none are given.
+func runCompare(cfg Config, areaCodes []string, day string) error {
+	if len(areaCodes) == 0 {
+		for _, loc := range cfg.Locations {
+			areaCodes = append(areaCodes, loc.Area)
+		}
+	}
+	if len(areaCodes) < 2 {
+		return usageError("-compare needs at least two area codes or saved locations")
+	}
+	for i, code := range areaCodes {
+		var err error
+		if areaCodes[i], err = areas.Normalize(code); err != nil {
+			return err
+		}
+	}
+	if _, err := dayIndices(day); err != nil {
+		return err
+	}
+
+	return runProgram(newCompareModel(areaCodes, day))
+}
+
+// forecastOptions are the resolved settings for a forecast run.
+type forecastOptions struct {
+	areaCode string // empty opens the location picker
+	day      string
+	output   string // "tui", "csv" or "json"
+	file     string // csv or json destination, stdout when empty
+	// locations are the saved locations reachable with alt and the number keys.
+	locations []Location
+	// watch is the auto-refresh interval; zero fetches once.
+	watch   time.Duration
+	risk    RiskWeights
+	icons   string
+	compact bool
+	notify  NotifyConfig
+	// screenAlert is how the screen signals a severe forecast; see
+	// parseScreenAlert.
+	screenAlert string
+	// hours hides the other hours of the day from the table when set.
+	hours *hourRange
+	// hideColumns are the names of the table columns left out.
+	hideColumns map[string]bool
+	// resume is the last session to set the screen up as, if any, and
+	// remember what to save as the session on quitting; see sessionState.
+	resume, remember *sessionState
+}
+
+// newForecastModel returns the forecast TUI model for opts.
+func newForecastModel(opts forecastOptions) model {
+	m := initialModel(opts.areaCode, opts.day)
+	m.locations = opts.locations
+	m.watchInterval = opts.watch
+	m.nextRefresh = clockNow().Add(opts.watch)
+	m.riskWeights = opts.risk
+	m.iconSet = opts.icons
+	m.compact = opts.compact
+	m.notify = opts.notify
+	m.screenAlert = opts.screenAlert
+	if opts.resume != nil {
+		m = m.restore(*opts.resume)
+	}
+	// The hours and columns of the flags and the config file win over those
+	// of the last session.
+	if opts.resume == nil || len(opts.hideColumns) > 0 {
+		m.hiddenColumns = opts.hideColumns
+	}
+	if opts.hours != nil {
+		m.hours, m.hideNight = *opts.hours, true
+	}
+	m.remember = opts.remember
+	return m
+}
+
+// runForecast shows the forecast in the TUI or writes it in the selected
+// output format.
+func runForecast(opts forecastOptions) error {
+	switch opts.output {
+	case "tui":
+	case "csv", "json":
+		return exportForecast(opts.areaCode, opts.day, opts.file, opts.output == "json")
+	default:
+		return usageError(fmt.Sprintf("unknown output mode %q (use tui, csv or json)", opts.output))
+	}
+
+	var m tea.Model = newForecastModel(opts)
+	// A fixture is shown whatever the area, so it needs none.
+	if opts.areaCode == "" && fixtureData == nil {
+		m = newPickerModel(opts)
+	}
+	return runProgram(m)
+}
+
+// isFlagSet reports whether the named flag was given on the command line.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
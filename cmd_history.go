@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"goHeadache/internal/areas"
+)
+
+var historyCommand = &command{
+	name:  "history",
+	args:  "[area_code] [flags]",
+	short: "List the areas recorded in the history, or the days recorded for an area",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		sinceFlag := fs.String("since", "0", "How far back to list days, such as 30d; 0 lists every day kept")
+		formatFlag := fs.String("format", "table", "Output format: table or json")
+		locationFlag := fs.String("location", "", "Use a saved location from the config file by name")
+		return func(args []string) error {
+			if len(args) > 1 {
+				return usageError("too many arguments")
+			}
+			if *formatFlag != "table" && *formatFlag != "json" {
+				return usageError(fmt.Sprintf("unknown format %q (use table or json)", *formatFlag))
+			}
+			since, err := parseDays(*sinceFlag)
+			if err != nil {
+				return usageError(err.Error())
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := setupHistory(cfg.History); err != nil {
+				return err
+			}
+			if history == nil {
+				return errHistoryDisabled
+			}
+			now := time.Now()
+			var from time.Time
+			if since > 0 {
+				from = midnight(now.Add(-since))
+			}
+
+			if len(args) == 0 && *locationFlag == "" {
+				summaries, err := historyAreas(from)
+				if err != nil {
+					return err
+				}
+				if *formatFlag == "json" {
+					return writeHistoryJSON(os.Stdout, summaries)
+				}
+				writeHistoryAreas(os.Stdout, summaries)
+				return nil
+			}
+			areaCode, err := cfg.selectArea(args, *locationFlag)
+			if err != nil {
+				return err
+			}
+			days, err := historyDays(areaCode, from, now)
+			if err != nil {
+				return err
+			}
+			if len(days) == 0 {
+				return fmt.Errorf("no forecasts are recorded for area code %s", areaCode)
+			}
+			if *formatFlag == "json" {
+				return writeHistoryJSON(os.Stdout, days)
+			}
+			writeHistoryDays(os.Stdout, areaCode, days)
+			return nil
+		}
+	},
+}
+
+// historyAreaSummary is an area in the history and the days recorded for it.
+type historyAreaSummary struct {
+	Area  string `json:"area"`
+	Place string `json:"place,omitempty"`
+	Days  int    `json:"days"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// historyDaySummary is a day file of the history: the forecasts fetched on
+// that day.
+type historyDaySummary struct {
+	Day       string    `json:"day"`
+	Forecasts int       `json:"forecasts"`
+	First     time.Time `json:"first_fetched_at"`
+	Last      time.Time `json:"last_fetched_at"`
+	Sources   []string  `json:"sources"`
+}
+
+// historyAreas summarizes every area with days recorded from from on.
+func historyAreas(from time.Time) ([]historyAreaSummary, error) {
+	codes, err := history.areas()
+	if err != nil {
+		return nil, fmt.Errorf("error reading the history: %v", err)
+	}
+	out := []historyAreaSummary{}
+	for _, code := range codes {
+		days, err := history.days(code)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the history of %s: %v", code, err)
+		}
+		days = slices.DeleteFunc(days, func(d time.Time) bool { return d.Before(from) })
+		if len(days) == 0 {
+			continue
+		}
+		s := historyAreaSummary{Area: code, Days: len(days), From: days[0].Format(time.DateOnly), To: days[len(days)-1].Format(time.DateOnly)}
+		if a, ok := areas.Lookup(code); ok {
+			s.Place = a.FullName()
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// historyDays summarizes the days recorded for areaCode from from until to.
+func historyDays(areaCode string, from, to time.Time) ([]historyDaySummary, error) {
+	records, err := history.records(areaCode, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the history: %v", err)
+	}
+	var out []historyDaySummary
+	for _, r := range records {
+		fetched := r.FetchedAt.Local()
+		day := fetched.Format(time.DateOnly)
+		if len(out) == 0 || out[len(out)-1].Day != day {
+			out = append(out, historyDaySummary{Day: day, First: fetched})
+		}
+		d := &out[len(out)-1]
+		d.Forecasts++
+		d.Last = fetched
+		if !slices.Contains(d.Sources, r.Source) {
+			d.Sources = append(d.Sources, r.Source)
+		}
+	}
+	return out, nil
+}
+
+// writeHistoryJSON writes v as indented JSON.
+func writeHistoryJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeHistoryAreas prints the areas of the history as a table.
+func writeHistoryAreas(w io.Writer, summaries []historyAreaSummary) {
+	if len(summaries) == 0 {
+		fmt.Fprintln(w, "No forecasts are recorded yet.")
+		return
+	}
+	fmt.Fprintf(w, "%-6s  %-10s  %-10s  %5s  %s\n", "AREA", "FROM", "TO", "DAYS", "PLACE")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "%-6s  %-10s  %-10s  %5d  %s\n", s.Area, s.From, s.To, s.Days, s.Place)
+	}
+}
+
+// writeHistoryDays prints the days recorded for areaCode as a table.
+func writeHistoryDays(w io.Writer, areaCode string, days []historyDaySummary) {
+	place := areaCode
+	if a, ok := areas.Lookup(areaCode); ok {
+		place = a.FullName() + " (" + areaCode + ")"
+	}
+	fmt.Fprintf(w, "%s: %d day(s) recorded\n", place, len(days))
+	fmt.Fprintf(w, "  %-10s  %9s  %-11s  %s\n", "DAY", "FORECASTS", "FETCHED", "SOURCES")
+	for _, d := range days {
+		fetched := d.First.Format("15:04") + "-" + d.Last.Format("15:04")
+		fmt.Fprintf(w, "  %-10s  %9d  %-11s  %s\n", d.Day, d.Forecasts, fetched, strings.Join(d.Sources, ", "))
+	}
+}
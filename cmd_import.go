@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+var importCommand = &command{
+	name:  "import",
+	args:  "<file|->",
+	short: "Add a dump written by export to the diary and the history",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		return func(args []string) error {
+			if len(args) != 1 {
+				return usageError("import needs a file, or - for standard input")
+			}
+			var r io.Reader = os.Stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+			d, err := readDump(r)
+			if err != nil {
+				return err
+			}
+
+			if len(d.Diary) > 0 {
+				added, err := importDiary(d.Diary)
+				if err != nil {
+					return fmt.Errorf("error importing the diary: %v", err)
+				}
+				fmt.Fprintf(os.Stderr, "Diary: imported %d entries, %d already present\n", added, len(d.Diary)-added)
+			}
+			if len(d.History) == 0 {
+				return nil
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := setupHistory(cfg.History); err != nil {
+				return err
+			}
+			if history == nil {
+				return errHistoryDisabled
+			}
+			codes := make([]string, 0, len(d.History))
+			for code := range d.History {
+				codes = append(codes, code)
+			}
+			sort.Strings(codes)
+			for _, code := range codes {
+				if !isAreaCode(code) {
+					return fmt.Errorf("invalid area code %q in the dump", code)
+				}
+				added, err := history.importRecords(code, d.History[code])
+				if err != nil {
+					return fmt.Errorf("error importing the history of %s: %v", code, err)
+				}
+				fmt.Fprintf(os.Stderr, "History of %s: imported %d forecasts, %d already present\n", code, added, len(d.History[code])-added)
+			}
+			return nil
+		}
+	},
+}
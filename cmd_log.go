@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+var logCommand = &command{
+	name:  "log",
+	args:  "[area_code] -severity <1-10> [flags]",
+	short: "Record a headache episode in the diary",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		severityFlag := fs.Int("severity", 0, fmt.Sprintf("How bad the headache is, from 1 to %d", maxSeverity))
+		noteFlag := fs.String("note", "", "A note about the episode, such as \"aura\"")
+		atFlag := fs.String("at", "", "When it began: 15:04, 2006-01-02 15:04 or a duration ago such as 2h (default now)")
+		locationFlag := fs.String("location", "", "Use a saved location from the config file by name")
+		listFlag := fs.Bool("list", false, "Print the diary instead of recording an episode")
+		return func(args []string) error {
+			if len(args) > 1 {
+				return usageError("too many arguments")
+			}
+			if *listFlag {
+				entries, err := readDiary()
+				if err != nil {
+					return err
+				}
+				for _, e := range entries {
+					fmt.Printf("%s  %2d  %-5s  %s\n", e.Time.Format("2006-01-02 15:04"), e.Severity, e.Area, e.Note)
+				}
+				return nil
+			}
+			if !validSeverity(*severityFlag) {
+				return usageError(fmt.Sprintf("-severity from 1 to %d is required", maxSeverity))
+			}
+			now := time.Now()
+			at := now
+			if *atFlag != "" {
+				var err error
+				if at, err = parseEpisodeTime(*atFlag, now); err != nil {
+					return usageError(err.Error())
+				}
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			// The area is only context for analyzing the diary, so an
+			// episode is recorded without one when none is configured.
+			areaCode, err := cfg.selectArea(args, *locationFlag)
+			if err != nil && (len(args) > 0 || *locationFlag != "") {
+				return err
+			}
+			e := diaryEntry{Time: at, Severity: *severityFlag, Note: *noteFlag, Area: areaCode}
+			if err := appendDiary(e); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Logged a headache of severity %d at %s\n", e.Severity, e.Time.Format("2006-01-02 15:04"))
+			return nil
+		}
+	},
+}
@@ -0,0 +1,59 @@
+package main
+
+import "flag"
+
+var mapCommand = &command{
+	name:  "map",
+	args:  "[flags]",
+	short: "Show today's headache reports on a map of Japan",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		themeFlag := fs.String("theme", "", "Color theme (default from config, else auto)")
+		paletteFlag := fs.String("palette", "", "Severity colors: default, deuteranopia, protanopia or tritanopia (default from config)")
+		langFlag := fs.String("lang", "", "Language of the screens: auto, en, ja or zh (default from config, else en)")
+		unitsFlag := fs.String("units", "", "Units to show, such as F,inHg, mmHg or imperial (default from config, else metric)")
+		iconsFlag := fs.String("icons", "", "Weather icons for the forecast opened from the map (default from config, else none)")
+		network := addNetworkFlags(fs)
+		return func(args []string) error {
+			if len(args) > 0 {
+				return usageError("map takes no arguments")
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := setupTheme(cfg, *themeFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := setupPalette(cfg, *paletteFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := setupUnits(cfg, *unitsFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := setupLang(cfg, *langFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if cacheTTL, err = parseCacheTTL(cfg.CacheTTL); err != nil {
+				return err
+			}
+			if err := network.setup(cfg); err != nil {
+				return err
+			}
+			icons := cfg.Icons
+			if isFlagSet(fs, "icons") {
+				icons = *iconsFlag
+			}
+			if err := validateIconSet(icons); err != nil {
+				return usageError(err.Error())
+			}
+
+			return runProgram(newPainMapModel(forecastOptions{
+				day:       cfg.Day,
+				output:    "tui",
+				locations: cfg.Locations,
+				risk:      cfg.Risk,
+				icons:     icons,
+			}))
+		}
+	},
+}
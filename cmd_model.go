@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+var modelCommand = &command{
+	name:  "model",
+	args:  "[train|show|reset] [flags]",
+	short: "Train the personal risk model on the diary and history, or inspect it",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		horizonFlag := fs.Duration("horizon", 6*time.Hour, "With train, how far ahead of an hour a headache counts for it")
+		sinceFlag := fs.String("since", "0", "With train, how far back to learn from, such as 90d; 0 uses the whole history")
+		return func(args []string) error {
+			action := "show"
+			if len(args) > 0 {
+				action, args = args[0], args[1:]
+			}
+			if len(args) > 0 {
+				return usageError("too many arguments")
+			}
+			switch action {
+			case "train":
+				if *horizonFlag < time.Hour || *horizonFlag > 48*time.Hour {
+					return usageError("-horizon must be between 1h and 48h")
+				}
+				since, err := parseDays(*sinceFlag)
+				if err != nil {
+					return usageError(err.Error())
+				}
+				return trainModel(*horizonFlag, since)
+			case "show":
+				m, err := loadRiskModel()
+				if err != nil {
+					return err
+				}
+				if m == nil {
+					return errors.New("no personal risk model is trained yet (run `goHeadache model train`)")
+				}
+				m.writeSummary(os.Stdout)
+				return nil
+			case "reset":
+				path, err := riskModelFile()
+				if err != nil {
+					return err
+				}
+				if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return err
+				}
+				fmt.Fprintln(os.Stderr, "Removed the personal risk model; the risk score uses the [risk] weights again")
+				return nil
+			}
+			return usageError(fmt.Sprintf("unknown action %q (use train, show or reset)", action))
+		}
+	},
+}
+
+// trainModel trains the personal risk model on the headaches of the diary
+// and the history of the areas they happened in, and saves it.
+func trainModel(horizon, since time.Duration) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if err := setupHistory(cfg.History); err != nil {
+		return err
+	}
+	if history == nil {
+		return errHistoryDisabled
+	}
+	diary, err := readDiary()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var from time.Time
+	if since > 0 {
+		from = midnight(now.Add(-since))
+	}
+	// Headaches logged without an area happened in the default one.
+	defaultArea, _ := cfg.selectArea(nil, "")
+	episodes := map[string][]time.Time{}
+	for _, e := range diary {
+		area := e.Area
+		if area == "" {
+			area = defaultArea
+		}
+		if area != "" && !e.Time.Before(from) {
+			episodes[area] = append(episodes[area], e.Time)
+		}
+	}
+
+	var (
+		samples   []trainingSample
+		areaCodes []string
+		count     int
+	)
+	for area, times := range episodes {
+		records, err := history.records(area, from, now)
+		if err != nil {
+			return fmt.Errorf("error reading the history: %v", err)
+		}
+		hours := recordedHours(records)
+		if len(hours) == 0 {
+			continue
+		}
+		for _, at := range times {
+			if _, ok := hours[at.Truncate(time.Hour).UTC()]; ok {
+				count++
+			}
+		}
+		samples = append(samples, trainingSamples(hours, times, horizon)...)
+		areaCodes = append(areaCodes, area)
+	}
+	if count < minModelEpisodes {
+		return fmt.Errorf("training needs at least %d headaches within the recorded history, found %d (log them with `goHeadache log`)", minModelEpisodes, count)
+	}
+	sort.Strings(areaCodes)
+	m := trainRiskModel(samples, areaCodes, count, horizon)
+	if err := m.save(); err != nil {
+		return fmt.Errorf("error saving the personal risk model: %v", err)
+	}
+	m.writeSummary(os.Stdout)
+	if !cfg.Risk.Personal {
+		fmt.Fprintln(os.Stderr, "\nNote: the risk score keeps using the weights until you set personal = true under [risk]")
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+)
+
+var searchCommand = &command{
+	name:  "search",
+	args:  "<place name> [flags]",
+	short: "Find area codes by place name and open the forecast",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		list := fs.Bool("list", false, "Only print the matching places")
+		offline := fs.Bool("offline", false, "Search the embedded area list instead of the API")
+		network := addNetworkFlags(fs)
+		themeFlag := fs.String("theme", "", "Color theme for the forecast view (default from config, else auto)")
+		paletteFlag := fs.String("palette", "", "Severity colors: default, deuteranopia, protanopia or tritanopia (default from config)")
+		langFlag := fs.String("lang", "", "Language of the screens: auto, en, ja or zh (default from config, else en)")
+		unitsFlag := fs.String("units", "", "Units to show, such as F,inHg, mmHg or imperial (default from config, else metric)")
+		clockFlag := fs.String("clock", "", "Clock of the times shown: 12h or 24h (default from config, else 24h)")
+		return func(args []string) error {
+			if len(args) == 0 {
+				return usageError("place name is required")
+			}
+			keyword := strings.Join(args, " ")
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := setupTheme(cfg, *themeFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := setupPalette(cfg, *paletteFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := setupUnits(cfg, *unitsFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := setupClock(cfg, *clockFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := setupLang(cfg, *langFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := network.setup(cfg); err != nil {
+				return err
+			}
+
+			points, err := searchPlaces(keyword, *offline)
+			if err != nil {
+				return err
+			}
+			if len(points) == 0 {
+				return fmt.Errorf("no places found for %q", keyword)
+			}
+			if *list || !term.IsTerminal(os.Stdin.Fd()) {
+				printWeatherPoints(os.Stdout, points)
+				return nil
+			}
+
+			point, ok, err := pickWeatherPoint(os.Stdin, os.Stdout, points)
+			if err != nil || !ok {
+				return err
+			}
+			return runForecast(forecastOptions{areaCode: point.CityCode, output: "tui"})
+		}
+	},
+}
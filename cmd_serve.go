@@ -0,0 +1,29 @@
+package main
+
+import "flag"
+
+var serveCommand = &command{
+	name:  "serve",
+	args:  "[flags]",
+	short: "Serve forecasts as a JSON API and a web dashboard",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		listenFlag := fs.String("listen", ":8080", "Address to serve the API on")
+		network := addNetworkFlags(fs)
+		return func(args []string) error {
+			if len(args) > 0 {
+				return usageError("serve takes no arguments; areas are part of the request path")
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := setupPalette(cfg, ""); err != nil {
+				return err
+			}
+			if err := setupFetching(cfg, network); err != nil {
+				return err
+			}
+			return listenAndServe(*listenFlag, newAPIServer(cfg), "serving the API on http://%s/v1/", *listenFlag)
+		}
+	},
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"goHeadache/internal/areas"
+)
+
+var serviceCommand = &command{
+	name:  "service",
+	args:  "[install|uninstall|print] [area_code...] [flags]",
+	short: "Run the daemon or check in the background as a systemd, launchd or scheduled task service",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		modeFlag := fs.String("mode", "daemon", "What the service runs: daemon, or check with -notify every -interval")
+		intervalFlag := fs.Duration("interval", 30*time.Minute, "How often -mode check runs")
+		noEnableFlag := fs.Bool("no-enable", false, "Only write the service files; do not enable and start them")
+		return func(args []string) error {
+			action := "print"
+			if len(args) > 0 {
+				action, args = args[0], args[1:]
+			}
+			for i, code := range args {
+				var err error
+				if args[i], err = areas.Normalize(code); err != nil {
+					return err
+				}
+			}
+
+			spec := serviceSpec{}
+			switch *modeFlag {
+			case "daemon":
+				spec.args = append([]string{"daemon"}, args...)
+			case "check":
+				if len(args) > 1 {
+					return usageError("-mode check takes at most one area code")
+				}
+				if *intervalFlag < time.Minute {
+					return usageError("-interval must be at least 1m")
+				}
+				spec.args = append([]string{"check", "-notify", "-quiet"}, args...)
+				spec.interval = *intervalFlag
+			default:
+				return usageError(fmt.Sprintf("unknown mode %q (use daemon or check)", *modeFlag))
+			}
+			var err error
+			if spec.exe, err = serviceExecutable(); err != nil {
+				return err
+			}
+			if spec.configPath, err = configPath(); err != nil {
+				return err
+			}
+			if spec.configPath, err = filepath.Abs(spec.configPath); err != nil {
+				return err
+			}
+
+			switch action {
+			case "print":
+				return printService(spec)
+			case "install":
+				return installService(spec, !*noEnableFlag)
+			case "uninstall":
+				return uninstallService(spec)
+			}
+			return usageError(fmt.Sprintf("unknown service action %q (use install, uninstall or print)", action))
+		}
+	},
+}
+
+// printService shows what install would write or run.
+func printService(s serviceSpec) error {
+	if runtime.GOOS == "windows" {
+		_, args := windowsTask(s)
+		fmt.Printf("schtasks %q\n", args)
+		return nil
+	}
+	files, _, err := serviceFiles(s)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		fmt.Printf("# %s\n%s\n", f.path, f.content)
+	}
+	return nil
+}
+
+// installService writes the service of s and, with enable set, starts it
+// now and at every login.
+func installService(s serviceSpec, enable bool) error {
+	if runtime.GOOS == "windows" {
+		name, args := windowsTask(s)
+		if err := runService("schtasks", args...); err != nil {
+			return err
+		}
+		if enable {
+			return runService("schtasks", "/Run", "/TN", name)
+		}
+		return nil
+	}
+	files, unit, err := serviceFiles(s)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(f.path, []byte(f.content), 0o644); err != nil {
+			return fmt.Errorf("error writing %s: %v", f.path, err)
+		}
+		fmt.Printf("Wrote %s\n", f.path)
+	}
+	if !enable {
+		return nil
+	}
+	if runtime.GOOS == "darwin" {
+		// Unloading first picks up changes to an agent that is already loaded.
+		_ = runService("launchctl", "unload", files[0].path)
+		return runService("launchctl", "load", "-w", files[0].path)
+	}
+	if err := runService("systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	return runService("systemctl", "--user", "enable", "--now", unit)
+}
+
+// uninstallService stops the service of s and removes its files.
+func uninstallService(s serviceSpec) error {
+	if runtime.GOOS == "windows" {
+		name, _ := windowsTask(s)
+		return runService("schtasks", "/Delete", "/F", "/TN", name)
+	}
+	files, unit, err := serviceFiles(s)
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS == "darwin" {
+		_ = runService("launchctl", "unload", "-w", files[0].path)
+	} else {
+		_ = runService("systemctl", "--user", "disable", "--now", unit)
+	}
+	for _, f := range files {
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		fmt.Printf("Removed %s\n", f.path)
+	}
+	if runtime.GOOS != "darwin" {
+		return runService("systemctl", "--user", "daemon-reload")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+var statsCommand = &command{
+	name:  "stats",
+	args:  "[area_code] [flags]",
+	short: "Print pressure statistics of the past days recorded in the history",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		sinceFlag := fs.String("since", "30d", "How far back to look, such as 30d, 2w or 0 for the whole history")
+		formatFlag := fs.String("format", "table", "Output format: table or json")
+		worstFlag := fs.Int("worst", 5, "How many of the worst days to list")
+		locationFlag := fs.String("location", "", "Use a saved location from the config file by name")
+		unitsFlag := fs.String("units", "", "Units to show, such as F,inHg, mmHg or imperial (default from config, else metric)")
+		return func(args []string) error {
+			if len(args) > 1 {
+				return usageError("too many arguments")
+			}
+			if *formatFlag != "table" && *formatFlag != "json" {
+				return usageError(fmt.Sprintf("unknown format %q (use table or json)", *formatFlag))
+			}
+			if *worstFlag < 0 {
+				return usageError("-worst must not be negative")
+			}
+			since, err := parseDays(*sinceFlag)
+			if err != nil {
+				return usageError(err.Error())
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := setupUnits(cfg, *unitsFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := setupHistory(cfg.History); err != nil {
+				return err
+			}
+			if history == nil {
+				return errHistoryDisabled
+			}
+			areaCode, err := cfg.selectArea(args, *locationFlag)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			today := midnight(now)
+			var from time.Time
+			if since > 0 {
+				from = midnight(now.Add(-since))
+			}
+			records, err := history.records(areaCode, from, now)
+			if err != nil {
+				return fmt.Errorf("error reading the history: %v", err)
+			}
+			var days []historyDay
+			for _, d := range pastHistoryDays(records, today) {
+				if !d.day.Before(from) {
+					days = append(days, d)
+				}
+			}
+			if len(days) == 0 && from.IsZero() {
+				return fmt.Errorf("no past days are recorded for area code %s", areaCode)
+			}
+			if len(days) == 0 {
+				return fmt.Errorf("no past days are recorded for area code %s since %s", areaCode, from.Format(time.DateOnly))
+			}
+
+			stats := newHistoryStats(areaCode, days, *worstFlag)
+			if *formatFlag == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(stats)
+			}
+			stats.writeTable(os.Stdout)
+			return nil
+		}
+	},
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+var statusCommand = &command{
+	name:  "status",
+	args:  "[area_code] [flags]",
+	short: "Print a short pressure status for status bars such as waybar, tmux, i3blocks or polybar",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		formatFlag := fs.String("format", "text", "Output format: "+statusFormatNames())
+		hoursFlag := fs.Int("hours", 6, "How many hours ahead to rate, as for check")
+		locationFlag := fs.String("location", "", "Use a saved location from the config file by name")
+		unitsFlag := fs.String("units", "", "Units to show, such as F,inHg, mmHg or imperial (default from config, else metric)")
+		paletteFlag := fs.String("palette", "", "Severity colors: default, deuteranopia, protanopia or tritanopia (default from config)")
+		maxAgeFlag := fs.Duration("max-age", 0, "How old the cached forecast may be before asking the API (default cache_ttl, at least 5m)")
+		offlineFlag := fs.Bool("offline", false, "Show the last cached forecast without using the network")
+		network := addNetworkFlags(fs)
+		return func(args []string) error {
+			format, ok := statusFormats[*formatFlag]
+			if !ok {
+				return usageError(fmt.Sprintf("unknown format %q (use %s)", *formatFlag, statusFormatNames()))
+			}
+			if len(args) > 1 {
+				return usageError("too many arguments")
+			}
+			if *hoursFlag < 1 {
+				return usageError("-hours must be at least 1")
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := setupUnits(cfg, *unitsFlag); err != nil {
+				return usageError(err.Error())
+			}
+			if err := setupPalette(cfg, *paletteFlag); err != nil {
+				return usageError(err.Error())
+			}
+			offlineMode = *offlineFlag
+			if err := setupFetching(cfg, network); err != nil {
+				return err
+			}
+			areaCode, err := cfg.selectArea(args, *locationFlag)
+			if err != nil {
+				return err
+			}
+			cacheTTL = max(cacheTTL, statusMinCacheTTL)
+			if isFlagSet(fs, "max-age") {
+				cacheTTL = *maxAgeFlag
+			}
+
+			if os.Getenv(statusRefreshEnv) != "" {
+				_, err := loadStatusWeather(context.Background(), areaCode)
+				return err
+			}
+			var w weatherResult
+			if format.cacheOnly {
+				w, err = cachedStatusWeather(areaCode)
+			} else {
+				w, err = loadStatusWeather(context.Background(), areaCode)
+			}
+			if err != nil {
+				// Bars show stdout, so the error goes there in their format.
+				// Prompts stay clean until the refresh has cached a forecast.
+				if text := format.error(err); text != "" {
+					fmt.Println(text)
+				}
+				if !format.cacheOnly {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				}
+				return exitStatus(checkUnknown)
+			}
+			fmt.Println(format.render(newStatusInfo(w, cfg.Risk, time.Now(), *hoursFlag)))
+			return nil
+		}
+	},
+	failCode: int(checkUnknown),
+}
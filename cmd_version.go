@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+var versionCommand = &command{
+	name:  "version",
+	args:  "[flags]",
+	short: "Print the version, commit, build date and Go version",
+	setup: func(fs *flag.FlagSet) func(args []string) error {
+		formatFlag := fs.String("format", "text", "Output format: text or json")
+		return func(args []string) error {
+			if *formatFlag != "text" && *formatFlag != "json" {
+				return usageError(fmt.Sprintf("unknown format %q (use text or json)", *formatFlag))
+			}
+			if len(args) > 0 {
+				return usageError("too many arguments")
+			}
+			b := buildInfo()
+			if *formatFlag == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(map[string]any{
+					"version":    b.version,
+					"commit":     b.commit,
+					"modified":   b.modified,
+					"build_date": b.date,
+					"go":         b.goVersion,
+					"os":         runtime.GOOS,
+					"arch":       runtime.GOARCH,
+				})
+			}
+			fmt.Printf("goHeadache %s\n", b.version)
+			fmt.Printf("commit:  %s\n", b.commitLabel())
+			fmt.Printf("built:   %s\n", b.builtAt())
+			fmt.Printf("go:      %s %s/%s\n", b.goVersion, runtime.GOOS, runtime.GOARCH)
+			return nil
+		}
+	},
+}
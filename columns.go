@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// parseHiddenColumns checks the names of the hide_columns setting. Time
+// cannot be hidden; delta is accepted for the Change column.
+func parseHiddenColumns(names []string) (map[string]bool, error) {
+	hidden := map[string]bool{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "delta" {
+			name = "change"
+		}
+		if _, ok := hideableColumn(name); !ok {
+			return nil, fmt.Errorf("unknown column %q in hide_columns (use %s)", name, strings.Join(hideableColumnNames(), ", "))
+		}
+		hidden[name] = true
+	}
+	return hidden, nil
+}
+
+// hideableColumn returns the column called name, unless it is Time.
+func hideableColumn(name string) (column, bool) {
+	for _, c := range tableColumns[1:] {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+func hideableColumnNames() []string {
+	names := make([]string, 0, len(tableColumns)-1)
+	for _, c := range tableColumns[1:] {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// pickableColumns are the columns the column picker lists: all but Time,
+// and Rain only with -rain.
+func (m model) pickableColumns() []column {
+	cols := tableColumns[1 : len(tableColumns)-1]
+	if m.rain != nil {
+		cols = tableColumns[1:]
+	}
+	return cols
+}
+
+// openColumnPicker shows the popup that hides and shows columns.
+func (m model) openColumnPicker() model {
+	m.columnPicker = true
+	m.columnCursor = 0
+	return m
+}
+
+// updateColumnPicker handles the keys of the column picker: up and down
+// move, space and enter hide or show the column, c and esc close it.
+func (m model) updateColumnPicker(msg tea.KeyPressMsg) (model, tea.Cmd) {
+	cols := m.pickableColumns()
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m.quit()
+	case "c", "esc":
+		m.columnPicker = false
+	case "up", "k":
+		m.columnCursor = max(m.columnCursor-1, 0)
+	case "down", "j":
+		m.columnCursor = min(m.columnCursor+1, len(cols)-1)
+	case "space", "enter":
+		name := cols[m.columnCursor].name
+		hidden := make(map[string]bool, len(m.hiddenColumns)+1)
+		for k, v := range m.hiddenColumns {
+			hidden[k] = v
+		}
+		hidden[name] = !hidden[name]
+		m.hiddenColumns = hidden
+	}
+	return m, nil
+}
+
+// columnPickerBox renders the column picker popup.
+func (m model) columnPickerBox() string {
+	text := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Text))
+	lines := []string{dayHeaderStyle.MarginTop(0).Render(tr("Columns")), ""}
+	for i, c := range m.pickableColumns() {
+		mark := "[x] "
+		if m.hiddenColumns[c.name] {
+			mark = "[ ] "
+		}
+		style := text
+		if i == m.columnCursor {
+			style = text.Reverse(true)
+		}
+		lines = append(lines, style.Render(mark+tr(c.title)))
+	}
+	lines = append(lines, "", statusStyle.Render(columnPickerKeys.line()))
+	return popupStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"golang.org/x/sync/errgroup"
+
+	"goHeadache/pkg/zutool"
+)
+
+// compareModel shows the pressure forecast of several locations side by side.
+type compareModel struct {
+	areaCodes  []string
+	data       []zutool.WeatherData
+	errs       []error
+	loading    bool
+	currentDay int
+	// viewport scrolls the hour rows under the fixed table header.
+	viewport viewport.Model
+	width    int
+	height   int
+	// cancel aborts the fetches when the user quits before they finish.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newCompareModel(areaCodes []string, dayFilter string) compareModel {
+	currentDay := 1
+	if days, err := dayIndices(dayFilter); err == nil && len(days) == 1 {
+		currentDay = days[0]
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return compareModel{
+		ctx:        ctx,
+		cancel:     cancel,
+		areaCodes:  areaCodes,
+		loading:    true,
+		currentDay: currentDay,
+		viewport:   newViewport(),
+		width:      80,
+		height:     24,
+	}
+}
+
+type compareResultMsg struct {
+	data []zutool.WeatherData
+	errs []error
+}
+
+// maxConcurrentFetches bounds the requests fetchAllCmd has in flight.
+const maxConcurrentFetches = 4
+
+// fetchAllCmd fetches every area code concurrently and reports all results
+// in a single message, keeping the order of areaCodes. A failed area does
+// not stop the others; its error is shown in its column.
+func fetchAllCmd(ctx context.Context, areaCodes []string) tea.Cmd {
+	return func() tea.Msg {
+		msg := compareResultMsg{
+			data: make([]zutool.WeatherData, len(areaCodes)),
+			errs: make([]error, len(areaCodes)),
+		}
+		var g errgroup.Group
+		g.SetLimit(maxConcurrentFetches)
+		for i, code := range areaCodes {
+			g.Go(func() error {
+				msg.data[i], msg.errs[i] = fetchWeatherData(ctx, code)
+				return nil
+			})
+		}
+		g.Wait()
+		return msg
+	}
+}
+
+func (m compareModel) Init() tea.Cmd {
+	return fetchAllCmd(m.ctx, m.areaCodes)
+}
+
+func (m compareModel) dayData(i int) []zutool.HourlyData {
+	_, data := model{weatherData: m.data[i]}.getDayData(m.currentDay)
+	return data
+}
+
+// hours returns the union of the hours present for the current day, in order.
+func (m compareModel) hours() []int {
+	seen := map[int]bool{}
+	var hours []int
+	for i := range m.data {
+		for _, entry := range m.dayData(i) {
+			h, err := strconv.Atoi(strings.TrimSpace(entry.Time))
+			if err != nil || seen[h] {
+				continue
+			}
+			seen[h] = true
+			hours = append(hours, h)
+		}
+	}
+	sort.Ints(hours)
+	return hours
+}
+
+// colWidth returns the width of a column of the table, the time column
+// included.
+func (m compareModel) colWidth() int {
+	return (m.width - appFrameWidth) / (len(m.areaCodes) + 1)
+}
+
+// tableHeader renders what stays above the hour rows: the day title and the
+// header rows with the place names and units.
+func (m compareModel) tableHeader() string {
+	dayName, _ := model{}.getDayData(m.currentDay)
+	colW := m.colWidth()
+	tableWidth := colW * (len(m.areaCodes) + 1)
+	header := renderCell(tableHeaderStyle, colW, tr("Time"))
+	unitRow := tableHeaderStyle.Width(colW).Render("")
+	for i, code := range m.areaCodes {
+		name := code
+		if m.errs[i] == nil && m.data[i].PlaceName != "" {
+			name = m.data[i].PlaceName
+		}
+		header += renderCell(tableHeaderStyle, colW, name)
+		unitRow += renderCell(tableHeaderStyle, colW, units.pressure+tr(" (level)"))
+	}
+	return dayHeaderStyle.Width(tableWidth).Render(trf("Pressure comparison - %s", tr(dayName))) + "\n" + header + "\n" + unitRow
+}
+
+// rows renders a row for every hour of the current day.
+func (m compareModel) rows() string {
+	colW := m.colWidth()
+	byHour := make([]map[int]zutool.HourlyData, len(m.data))
+	for i := range m.data {
+		byHour[i] = map[int]zutool.HourlyData{}
+		for _, entry := range m.dayData(i) {
+			if h, err := strconv.Atoi(strings.TrimSpace(entry.Time)); err == nil {
+				byHour[i][h] = entry
+			}
+		}
+	}
+	now := clockNow().Hour()
+	var rows []string
+	for _, h := range m.hours() {
+		s := cellStyle
+		if m.currentDay == 1 && h == now {
+			s = currentCellStyle
+		}
+		row := renderCell(s, colW, formatHour(h))
+		for i := range m.data {
+			cell := "-"
+			if m.errs[i] != nil {
+				cell = tr("error")
+			} else if entry, ok := byHour[i][h]; ok {
+				_, _, _, pressure := displayHourlyData(entry)
+				cell = fmt.Sprintf("%s (%s)", pressure, entry.PressureLevel)
+			}
+			row += renderCell(s, colW, cell)
+		}
+		rows = append(rows, row)
+	}
+	return strings.Join(rows, "\n")
+}
+
+// footer renders what stays below the hour rows: the errors of the areas
+// that failed and the key hints.
+func (m compareModel) footer() string {
+	tableWidth := m.colWidth() * (len(m.areaCodes) + 1)
+	var b strings.Builder
+	for i, err := range m.errs {
+		if err != nil {
+			b.WriteString(errorStyle.Render(fmt.Sprintf("%s: %s", m.areaCodes[i], trError(err))) + "\n")
+		}
+	}
+	b.WriteString(footerStyle.Width(tableWidth).Render(m.keys().footer(tableWidth, false)))
+	return b.String()
+}
+
+// sync sizes the viewport to the space the header and footer leave and
+// fills it with the rows of the current day.
+func (m *compareModel) sync() {
+	if m.loading {
+		return
+	}
+	// The app frame's border takes 2 lines and the scroll indicator with
+	// its blank line another 2, whether it is shown or not.
+	height := m.height - 4 - lipgloss.Height(m.tableHeader()) - lipgloss.Height(m.footer())
+	m.viewport.SetWidth(m.width - appFrameWidth)
+	m.viewport.SetHeight(max(height, 3))
+	m.viewport.SetContent(m.rows())
+}
+
+func (m compareModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.sync()
+	case compareResultMsg:
+		m.data = msg.data
+		m.errs = msg.errs
+		m.loading = false
+		m.sync()
+	case tea.MouseWheelMsg:
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			m.cancel()
+			return m, tea.Quit
+		case "left", "h":
+			if m.currentDay > 0 {
+				m.currentDay--
+				m.sync()
+				m.viewport.GotoTop()
+			}
+		case "right", "l":
+			if m.currentDay < 3 {
+				m.currentDay++
+				m.sync()
+				m.viewport.GotoTop()
+			}
+		case "home":
+			m.viewport.GotoTop()
+		case "end":
+			m.viewport.GotoBottom()
+		default:
+			var cmd tea.Cmd
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+func (m compareModel) View() tea.View {
+	if m.loading {
+		return newView(loadingStyle.Render(trf("Loading weather data for %d locations...\nPlease wait", len(m.areaCodes))))
+	}
+
+	var b strings.Builder
+	if indicator := m.indicator(); indicator != "" {
+		b.WriteString(indicator + "\n\n")
+	}
+	b.WriteString(m.tableHeader() + "\n" + m.viewport.View() + "\n" + m.footer())
+	return newView(b.String())
+}
+
+// indicator tells where the rows are scrolled to, if they do not all fit.
+func (m compareModel) indicator() string {
+	var parts []string
+	if !m.viewport.AtTop() {
+		parts = append(parts, tr("↑ More above"))
+	}
+	if !m.viewport.AtBottom() {
+		parts = append(parts, tr("↓ More below"))
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"goHeadache/internal/areas"
+)
+
+// Config holds user settings read from the config file.
+type Config struct {
+	// Area is the default area code used when none is given on the command line.
+	Area string `toml:"area"`
+	// Day is the default -day filter.
+	Day string `toml:"day"`
+	// Locations are named area codes that can be selected with -location
+	// and switched between in the TUI with alt and the number keys.
+	Locations []Location `toml:"locations"`
+	// Icons selects weather icons: none, emoji, nerd or ascii.
+	Icons string `toml:"icons"`
+	// Theme selects the color theme: auto, light, dark, high-contrast or
+	// the name of a theme defined under [themes].
+	Theme string `toml:"theme"`
+	// Themes are user-defined color themes by name.
+	Themes map[string]Theme `toml:"themes,omitempty"`
+	// Palette replaces the severity colors of the theme with a preset for
+	// color vision deficiencies: default, deuteranopia, protanopia or
+	// tritanopia.
+	Palette string `toml:"palette"`
+	// Source is where forecasts come from: zutool, jma, owm or open-meteo.
+	Source string `toml:"source"`
+	// OWMAPIKey is the OpenWeatherMap API key for -source owm, overriding
+	// OWM_API_KEY.
+	OWMAPIKey string `toml:"owm_api_key"`
+	// Lang is the language of the UI: auto, en, ja or zh.
+	Lang string `toml:"lang"`
+	// Units are the units temperatures and pressures are shown in, such as
+	// "F,inHg"; see parseUnits.
+	Units string `toml:"units"`
+	// Clock is the clock of the times shown: 24h or 12h.
+	Clock string `toml:"clock"`
+	// Hours are the hours of the day the table shows, such as "7-23"; empty
+	// shows all of them.
+	Hours string `toml:"hours"`
+	// HideColumns are the table columns left out, such as "weather".
+	HideColumns []string `toml:"hide_columns"`
+	// Rain adds the chance of precipitation from Open-Meteo to the table.
+	Rain bool `toml:"rain"`
+	// CacheTTL is how long a fetched forecast is reused, as a duration such
+	// as "10m". "0" always asks the API.
+	CacheTTL string `toml:"cache_ttl"`
+	// Proxy is the proxy for API requests, overriding HTTPS_PROXY.
+	Proxy string `toml:"proxy"`
+	// CAFile is a PEM bundle of extra CA certificates to trust, for proxies
+	// that intercept TLS.
+	CAFile string `toml:"ca_file"`
+	// RateLimit is how many requests per second are sent to zutool at
+	// most, in bursts of up to RateBurst; 0 is the default of the client.
+	RateLimit float64 `toml:"rate_limit"`
+	RateBurst int     `toml:"rate_burst"`
+	// Risk holds the weights of the headache risk score.
+	Risk RiskWeights `toml:"risk"`
+	// ScreenAlert is how the forecast screen signals when the next hours
+	// turn severe: none, bell, flash or both.
+	ScreenAlert string `toml:"screen_alert"`
+	// Notify sets when desktop notifications about pressure warnings fire.
+	Notify NotifyConfig `toml:"notify"`
+	// Rules are the conditions that raise daemon alerts. Without any, the
+	// [notify] thresholds are used.
+	Rules []RuleConfig `toml:"rules,omitempty"`
+	// Webhooks are URLs that daemon alerts are posted to.
+	Webhooks []WebhookConfig `toml:"webhooks,omitempty"`
+	// Hooks are commands run for daemon alerts.
+	Hooks []HookConfig `toml:"hooks,omitempty"`
+	// Daemon configures goHeadache daemon.
+	Daemon DaemonConfig `toml:"daemon"`
+	// MQTT is a broker the daemon publishes the forecast to.
+	MQTT MQTTConfig `toml:"mqtt"`
+	// History records every fetched forecast.
+	History HistoryConfig `toml:"history"`
+}
+
+// Location is a saved, named area code.
+type Location struct {
+	Name string `toml:"name"`
+	Area string `toml:"area"`
+}
+
+// findLocation returns the saved location with the given name, ignoring case.
+func (c Config) findLocation(name string) (Location, bool) {
+	for _, loc := range c.Locations {
+		if strings.EqualFold(loc.Name, name) {
+			return loc, true
+		}
+	}
+	return Location{}, false
+}
+
+// selectArea returns the area code of the one-area commands: the argument,
+// else the saved location named location, else area, else the first saved
+// location.
+func (c Config) selectArea(args []string, location string) (string, error) {
+	areaCode := c.Area
+	if areaCode == "" && len(c.Locations) > 0 {
+		areaCode = c.Locations[0].Area
+	}
+	switch {
+	case len(args) == 1 && location != "":
+		return "", usageError("use either an area code or -location")
+	case len(args) == 1:
+		areaCode = args[0]
+	case location != "":
+		loc, ok := c.findLocation(location)
+		if !ok {
+			return "", fmt.Errorf("no saved location named %q in the config file", location)
+		}
+		areaCode = loc.Area
+	}
+	if areaCode == "" {
+		return "", usageError("area code is required (pass it as an argument or set area in the config file)")
+	}
+	return areas.Normalize(areaCode)
+}
+
+// watchedAreas returns the area codes the background commands watch: args,
+// else the areas of [daemon], else every saved location, else area.
+func (c Config) watchedAreas(args []string) []string {
+	switch {
+	case len(args) > 0:
+		return args
+	case len(c.Daemon.Areas) > 0:
+		return c.Daemon.Areas
+	}
+	var codes []string
+	for _, loc := range c.Locations {
+		codes = append(codes, loc.Area)
+	}
+	if len(codes) == 0 && c.Area != "" {
+		codes = append(codes, c.Area)
+	}
+	return codes
+}
+
+// owmAPIKey returns the OpenWeatherMap API key, from the config file or
+// else OWM_API_KEY.
+func (c Config) owmAPIKey() string {
+	if c.OWMAPIKey != "" {
+		return c.OWMAPIKey
+	}
+	return os.Getenv("OWM_API_KEY")
+}
+
+const defaultConfigTemplate = `# goHeadache configuration
+
+# Default area code used when none is given on the command line.
+# Find yours at https://geoshape.ex.nii.ac.jp/ka/resource/
+area = ""
+
+# Default day filter: yesterday, today, tomorrow, dayafter, or "" for all days.
+day = ""
+
+# Weather icons in the table: none, emoji, nerd (needs a Nerd Font) or ascii.
+icons = "none"
+
+# Color theme: auto (light or dark from the terminal background), light,
+# dark, high-contrast, or the name of a theme defined below.
+theme = "auto"
+
+# Colors of levels, risk scores and pressure drops: default (those of the
+# theme, green to red), or deuteranopia, protanopia or tritanopia for color
+# vision deficiencies.
+palette = "default"
+
+# Language of the screens: en, ja (日本語), zh (中文), or auto to follow
+# LANG. Command-line output for scripts stays in English.
+lang = "en"
+
+# Units shown for temperatures and pressures: C or F, and hPa, inHg or
+# mmHg, such as "F,inHg"; metric and imperial select both. Only what is shown
+# converts: thresholds stay in hPa, and CSV, JSON, metrics and MQTT in °C
+# and hPa.
+units = "metric"
+
+# Clock of the times shown: 24h ("14:00") or 12h ("2 PM"). CSV stays on the
+# 24-hour clock.
+clock = "24h"
+
+# Hours of the day the table shows, such as "7-23" to leave out the night so
+# a whole day fits on the screen; "22-6" wraps past midnight. n shows or
+# hides the rest. Empty shows every hour.
+hours = ""
+
+# Table columns to leave out: weather, temp, pressure, change, level, risk or
+# rain. c shows or hides them while the forecast is open.
+hide_columns = []
+
+# How the forecast screen signals when the next 6 hours turn severe (level 4
+# or a fall of 1 hPa within an hour), for a screen in a background pane:
+# none, bell (the terminal bell), flash (the header) or both. It signals once
+# until they are no longer severe.
+screen_alert = "none"
+
+# Where forecasts come from: zutool (default), jma for the Japan
+# Meteorological Agency's observed pressure and daily forecast, or owm for
+# OpenWeatherMap. open-meteo needs -lat and -lon, so it is only useful as a
+# flag.
+source = "zutool"
+
+# Add a Rain column with the chance of precipitation of each hour, from
+# Open-Meteo, since rain often comes with the pressure drops that trigger
+# headaches. Same as -rain.
+rain = false
+
+# OpenWeatherMap API key for source = "owm". When empty the OWM_API_KEY
+# environment variable is used.
+owm_api_key = ""
+
+# How long a fetched forecast is reused before asking the API again, so
+# restarts and switching locations are instant. "0" always asks the API. The
+# last forecast of every area is kept either way for -offline and for when
+# the API cannot be reached.
+cache_ttl = "10m"
+
+# Proxy for API requests, e.g. "http://proxy.example.com:8080". When empty
+# the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables are used.
+proxy = ""
+
+# PEM file of additional CA certificates to trust, for corporate proxies
+# that re-sign TLS traffic.
+ca_file = ""
+
+# How many requests per second are sent to zutool at most, by every command
+# and the TUI's refreshes alike, and how many may go at once after a pause,
+# so that a short -watch or a long batch list cannot flood the API.
+rate_limit = 5
+rate_burst = 10
+
+# Saved locations. Select one with -location <name>, or press alt+1 to alt+9
+# in the forecast view to switch between them. When area is empty the first
+# saved location is used as the default.
+# [[locations]]
+# name = "home"
+# area = "13101"
+#
+# [[locations]]
+# name = "office"
+# area = "13104"
+
+# Weights of the 0-100 headache risk score. Only their ratio matters.
+[risk]
+level_weight = 0.5
+pressure_change_weight = 0.35
+temperature_swing_weight = 0.15
+# Use the personal risk model instead of the weights once one is trained
+# with "goHeadache model train".
+personal = false
+
+# Desktop notifications about pressure warnings (notify-send on Linux,
+# terminal-notifier or osascript on macOS, a toast on Windows). They are sent
+# when an hour within the next "hours" reaches "level" or the pressure falls
+# by "drop" hPa or more within an hour; 0 disables either check. Enable them
+# here or with -notify; with -watch each warning is sent once.
+[notify]
+enabled = false
+level = 3
+drop = 1.0
+hours = 6
+
+# goHeadache daemon checks the forecast every "interval" and sends an alert
+# when a rule below holds, or without rules when the [notify] thresholds
+# above are crossed, once per warning. It watches "areas", or else the saved
+# locations, or else area.
+[daemon]
+interval = "30m"
+areas = []
+desktop = true
+# Names of the webhooks below to post alerts to; empty posts to all of them.
+webhooks = []
+# Names of the hooks below to run for alerts; empty runs all of them.
+hooks = []
+# Hold alerts back during this daily span, e.g. "23:00-07:00", and send one
+# digest per area when it is over. Rules can have their own quiet_hours.
+quiet_hours = ""
+# Send a summary of the day's pressure for every area at this time, such as
+# "07:30": the worst drop, the pressure range and the highest level and risk
+# score. Empty sends none.
+digest_at = ""
+
+# Alert rules for goHeadache daemon. "when" is "<metric> <op> <number>
+# [within <hours>h]" (6h by default), and several can be joined with "or".
+# Metrics: pressure_level (0-4), pressure (hPa), pressure_change (hPa since
+# the previous hour), pressure_drop_rate (hPa lost since the previous hour),
+# pressure_drop_hpa (the largest fall within the window), temp (°C) and
+# risk (0-100). severity is info, warning or severe. Alerts of a rule are
+# held back during its quiet_hours like those of [daemon].
+# [[rules]]
+# name = "big-drop"
+# when = "pressure_drop_hpa >= 5 within 6h"
+# severity = "severe"
+#
+# [[rules]]
+# name = "level"
+# when = "pressure_level >= 3"
+# quiet_hours = "23:00-07:00"
+
+# Webhooks that daemon alerts are posted to. format is slack, discord or
+# json (the alert's fields plus "text"). template is a Go template of the
+# message using .Place, .AreaCode, .Rule, .Severity, .Time, .Date, .Until,
+# .Level, .LevelName, .Drop, .Pressure, .Title and .Message.
+# digest_template is used for digests, whose .Message lists several alerts.
+# Try them with goHeadache daemon -dry-run.
+# [[webhooks]]
+# name = "slack"
+# url = "https://hooks.slack.com/services/..."
+# format = "slack"
+# template = "Pressure dropping {{printf \"%.1f\" .Drop}} hPa at {{.Time}} in {{.Place}}"
+
+# Commands run for every daemon alert, without a shell. The alert is passed
+# in GOHEADACHE_AREA_CODE, GOHEADACHE_PLACE, GOHEADACHE_RULE,
+# GOHEADACHE_SEVERITY, GOHEADACHE_TIME, GOHEADACHE_UNTIL, GOHEADACHE_LEVEL, GOHEADACHE_LEVEL_NAME, GOHEADACHE_DROP,
+# GOHEADACHE_PRESSURE, GOHEADACHE_TITLE and GOHEADACHE_MESSAGE, and as JSON
+# on stdin. A command still running after timeout is killed.
+# [[hooks]]
+# name = "phone"
+# command = ["sh", "-c", "kdeconnect-cli --ping-msg \"$GOHEADACHE_TITLE: $GOHEADACHE_MESSAGE\" -n phone"]
+# timeout = "30s"
+
+# Every forecast fetched is recorded in the history, by default in
+# goHeadache/history in the user data directory (~/.local/share on Linux),
+# for trends, accuracy tracking and the diary. Records older than retention
+# ("0" keeps everything) are removed. With observed the measured values of
+# the day before each forecast are recorded too.
+[history]
+enabled = true
+retention = "180d"
+observed = true
+path = ""
+
+# goHeadache daemon also publishes the forecast of every area to this MQTT
+# broker on each check, as JSON on <topic>/<area code>/state: the current
+# pressure, pressure_level, temperature, pressure_change and risk, and the
+# highest level and risk and the largest drop within the next "hours". With
+# discovery the areas show up in Home Assistant as devices with sensors.
+# Brokers can be tcp://host:1883 or mqtts://host:8883.
+[mqtt]
+broker = ""
+username = ""
+password = ""
+topic = "goheadache"
+retain = true
+hours = 6
+discovery = false
+discovery_prefix = "homeassistant"
+
+# Custom themes. Colors are hex ("#268BD2") or ANSI numbers ("12"); anything
+# left out is taken from the base preset (default dark).
+# [themes.solarized]
+# base = "dark"
+# border = "#268BD2"
+# title = "#002B36"
+# title_bg = "#2AA198"
+# header = "#002B36"
+# header_bg = "#268BD2"
+# text = "#93A1A1"
+# muted = "#657B83"
+# loading = "#2AA198"
+# error = "#DC322F"
+# error_border = "#DC322F"
+# highlight = "#002B36"
+# highlight_bg = "#B58900"
+# badge_text = "#FDF6E3"
+# levels = ["#859900", "#859900", "#B58900", "#CB4B16", "#DC322F"]
+# risk = ["#859900", "#B58900", "#CB4B16", "#DC322F"]
+# drop_warn = "#CB4B16"
+# drop_severe = "#DC322F"
+`
+
+// configPath returns the config file location. GOHEADACHE_CONFIG overrides
+// the default of <user config dir>/goHeadache/config.toml.
+func configPath() (string, error) {
+	if p := os.Getenv("GOHEADACHE_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating config directory: %v", err)
+	}
+	return filepath.Join(dir, "goHeadache", "config.toml"), nil
+}
+
+// defaultConfig returns the settings used for anything the config file
+// does not set.
+func defaultConfig() Config {
+	return Config{CacheTTL: defaultCacheTTL.String(), Risk: defaultRiskWeights, Notify: defaultNotifyConfig, Daemon: defaultDaemonConfig, MQTT: defaultMQTTConfig, History: defaultHistoryConfig}
+}
+
+// loadConfig reads the config file. A missing file yields defaultConfig.
+func loadConfig() (Config, error) {
+	cfg := defaultConfig()
+	path, err := configPath()
+	if err != nil {
+		return cfg, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, cfg.Risk.loadModel()
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("error reading config: %v", err)
+	}
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("error parsing config %s: %v", path, err)
+	}
+	return cfg, cfg.Risk.loadModel()
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// crashRecord is the first panic of a program, kept for its crash report.
+type crashRecord struct {
+	once  sync.Once
+	value any
+	stack []byte
+}
+
+// record keeps r and the stack it was raised from, unless a panic was
+// already recorded.
+func (c *crashRecord) record(r any, stack []byte) {
+	c.once.Do(func() { c.value, c.stack = r, stack })
+}
+
+// crashMsg brings the panic of a command back to the program.
+type crashMsg struct {
+	value any
+	stack []byte
+}
+
+// crashGuard runs model, turning its panics and those of its commands into
+// a normal quit so the terminal is restored, and recording the first in
+// crash. quit ends the program when View panics, which cannot return a
+// command.
+type crashGuard struct {
+	model tea.Model
+	crash *crashRecord
+	quit  func()
+}
+
+func (g crashGuard) Init() (cmd tea.Cmd) {
+	defer func() {
+		if r := recover(); r != nil {
+			g.crash.record(r, debug.Stack())
+			cmd = tea.Quit
+		}
+	}()
+	return g.guard(g.model.Init())
+}
+
+func (g crashGuard) Update(msg tea.Msg) (m tea.Model, cmd tea.Cmd) {
+	if c, ok := msg.(crashMsg); ok {
+		g.crash.record(c.value, c.stack)
+		return g, tea.Quit
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			g.crash.record(r, debug.Stack())
+			m, cmd = g, tea.Quit
+		}
+	}()
+	g.model, cmd = g.model.Update(msg)
+	return g, g.guard(cmd)
+}
+
+func (g crashGuard) View() (v tea.View) {
+	defer func() {
+		if r := recover(); r != nil {
+			g.crash.record(r, debug.Stack())
+			go g.quit()
+			v = tea.NewView("")
+		}
+	}()
+	return g.model.View()
+}
+
+// guard makes the panics of cmd, and of the commands of the batch it
+// returns, come back as a crashMsg.
+func (g crashGuard) guard(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() (msg tea.Msg) {
+		defer func() {
+			if r := recover(); r != nil {
+				msg = crashMsg{r, debug.Stack()}
+			}
+		}()
+		msg = cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			guarded := make(tea.BatchMsg, len(batch))
+			for i, c := range batch {
+				guarded[i] = g.guard(c)
+			}
+			return guarded
+		}
+		return msg
+	}
+}
+
+// runProgram runs the TUI of m. When it panics the terminal is restored
+// first, then a crash report is written and its path returned in the error
+// instead of a stack dump over the screen.
+func runProgram(m tea.Model) error {
+	crash := &crashRecord{}
+	var p *tea.Program
+	p = tea.NewProgram(crashGuard{model: m, crash: crash, quit: func() { p.Quit() }}, tea.WithoutCatchPanics())
+	_, err := p.Run()
+	if crash.value != nil {
+		return crashError(crash.value, crash.stack)
+	}
+	if err != nil {
+		return fmt.Errorf("error running program: %v", err)
+	}
+	return nil
+}
+
+// crashError writes the crash report of the panic r and returns the error
+// telling where it is.
+func crashError(r any, stack []byte) error {
+	path, err := writeCrashReport(r, stack)
+	if err != nil {
+		return fmt.Errorf("goHeadache crashed: %v (the crash report could not be written: %v)", r, err)
+	}
+	return fmt.Errorf("goHeadache crashed: %v\nA crash report was written to %s; please attach it to a bug report at https://github.com/satoi8080/goHeadache/issues", r, path)
+}
+
+// writeCrashReport writes what a bug report about the panic r needs to
+// <state dir>/goHeadache/crash-<time>-<n>.txt: the panic and its stack, the
+// version, the config file with its secrets redacted and the last API
+// response.
+func writeCrashReport(r any, stack []byte) (string, error) {
+	dir, err := userStateDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "goHeadache"), 0o755); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	f, err := os.CreateTemp(filepath.Join(dir, "goHeadache"), "crash-"+now.Format("20060102-150405")+"-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	build := buildInfo()
+	fmt.Fprintf(&b, "goHeadache %s (commit %s, built %s, %s, %s/%s) crashed at %s\n", build.version, build.commitLabel(), build.builtAt(), build.goVersion, runtime.GOOS, runtime.GOARCH, now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "args: %q\n\npanic: %v\n\n%s\n", os.Args[1:], r, stack)
+	b.WriteString("config:\n")
+	if cfg, err := loadConfig(); err != nil {
+		fmt.Fprintf(&b, "(could not be read: %v)\n", err)
+	} else if out, err := toml.Marshal(cfg.redacted()); err == nil {
+		b.Write(out)
+	}
+	b.WriteString("\nlast API response:\n")
+	if url, body := lastResponse(); url != "" {
+		fmt.Fprintf(&b, "%s\n%s\n", url, body)
+	} else {
+		b.WriteString("(none)\n")
+	}
+	_, err = f.WriteString(b.String())
+	return f.Name(), err
+}
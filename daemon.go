@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DaemonConfig is the [daemon] section of the config file.
+type DaemonConfig struct {
+	// Interval is how often the forecast is checked, as a duration such as
+	// "30m".
+	Interval string `toml:"interval"`
+	// Areas are the area codes to watch. When empty the daemon watches the
+	// saved locations, or else area.
+	Areas []string `toml:"areas"`
+	// QuietHours is a daily span such as "23:00-07:00" during which alerts
+	// are held back, to be sent as one digest per area afterwards.
+	QuietHours string `toml:"quiet_hours"`
+	// DigestAt is the time of day, such as "07:30", of a summary of the
+	// day's pressure sent for every area. Empty sends none.
+	DigestAt string `toml:"digest_at"`
+	// Desktop sends alerts as desktop notifications.
+	Desktop bool `toml:"desktop"`
+	// Webhooks are the names of the [[webhooks]] alerts are posted to; empty
+	// posts to all of them.
+	Webhooks []string `toml:"webhooks"`
+	// Hooks are the names of the [[hooks]] run for alerts; empty runs all
+	// of them.
+	Hooks []string `toml:"hooks"`
+}
+
+var defaultDaemonConfig = DaemonConfig{Interval: "30m", Desktop: true}
+
+// minDaemonInterval keeps the daemon from polling the API too eagerly.
+const minDaemonInterval = time.Minute
+
+// parseDaemonInterval parses the interval setting. An empty value is the
+// default.
+func parseDaemonInterval(s string) (time.Duration, error) {
+	if s == "" {
+		s = defaultDaemonConfig.Interval
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < minDaemonInterval {
+		return 0, fmt.Errorf("invalid daemon interval %q (use a duration of at least 1m, such as \"30m\")", s)
+	}
+	return d, nil
+}
+
+// daemon checks the forecast of its areas and sends the alerts.
+type daemon struct {
+	areaCodes []string
+	interval  time.Duration
+	rules     []alertRule
+	quiet     quietHours // of the whole daemon
+	// digestAt is the time after midnight of the daily digest, if digest.
+	digestAt  time.Duration
+	digest    bool
+	risk      RiskWeights
+	notifiers []notifier
+	mqtt      *mqttPublisher // nil without a broker
+	state     alertState
+	log       *log.Logger
+	// dryRun logs what would be sent instead of sending it, and forgets it.
+	dryRun bool
+}
+
+// run polls until ctx is done, or only once with once set.
+func (d *daemon) run(ctx context.Context, once bool) error {
+	d.log.Printf("watching %d area(s) with %d rule(s) every %s", len(d.areaCodes), len(d.rules), d.interval)
+	for {
+		d.poll(ctx)
+		if once {
+			return nil
+		}
+		wait := d.interval
+		if d.digest {
+			// Wake up for the digest rather than sending it late.
+			wait = min(wait, time.Until(d.nextDigest(time.Now())))
+		}
+		select {
+		case <-ctx.Done():
+			d.log.Print("stopped")
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// poll checks every area once and saves the state.
+func (d *daemon) poll(ctx context.Context) {
+	now := time.Now()
+	for _, code := range d.areaCodes {
+		if ctx.Err() != nil {
+			return
+		}
+		w, err := loadWeather(ctx, code, true)
+		if err != nil {
+			d.log.Printf("%s: %v", code, err)
+			continue
+		}
+		if w.stale != nil {
+			d.log.Printf("%s: using the forecast from %s: %v", code, w.fetchedAt.Format("15:04"), w.stale)
+		}
+		if d.mqtt != nil {
+			d.publishMQTT(ctx, code, w, now)
+		}
+		if d.digestDue(now) {
+			d.sendDigest(ctx, code, w.data, now)
+		}
+		for _, r := range d.rules {
+			warning, ok := r.evaluate(w.data, d.risk, now)
+			if !ok {
+				continue
+			}
+			a := alert{areaCode: code, placeName: w.data.PlaceName, rule: r.name, severity: r.severity, warning: warning}
+			d.send(ctx, a, r, now)
+		}
+	}
+	d.flushQueue(ctx, now)
+	if d.dryRun {
+		return
+	}
+	if err := d.state.save(now); err != nil {
+		d.log.Printf("error saving state: %v", err)
+	}
+}
+
+// send dispatches a raised by r, unless r is in its quiet hours.
+func (d *daemon) send(ctx context.Context, a alert, r alertRule, now time.Time) {
+	_, body := a.message()
+	if d.isQuiet(r.name, now) {
+		if d.dryRun {
+			d.log.Printf("%s: rule %s would queue for after the quiet hours: %s", a.areaCode, r.name, body)
+		} else if d.state.queue(a, now) {
+			d.log.Printf("%s: rule %s queued for after the quiet hours: %s", a.areaCode, r.name, body)
+		}
+		return
+	}
+	if d.dryRun {
+		for _, n := range d.notifiers {
+			d.log.Printf("%s: rule %s would send via %s: %s", a.areaCode, r.name, n.name(), n.preview(a))
+		}
+		return
+	}
+	sent, errs := d.state.dispatch(ctx, a, d.notifiers, now)
+	for _, err := range errs {
+		d.log.Printf("%s: error sending alert of rule %s: %v", a.areaCode, r.name, err)
+	}
+	if sent {
+		d.log.Printf("%s: rule %s sent alert for %s: %s", a.areaCode, r.name, a.placeName, body)
+	}
+}
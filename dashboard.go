@@ -0,0 +1,236 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"goHeadache/internal/areas"
+)
+
+//go:embed web/*.html
+var webFS embed.FS
+
+var dashboardTemplate = template.Must(template.ParseFS(webFS, "web/dashboard.html"))
+
+// dashboardRefresh is how often the dashboard page reloads itself, for
+// screens that show it all day.
+const dashboardRefresh = 10 * time.Minute
+
+// dashboardColors are the level and risk colors of the dashboard, which
+// works on light and dark pages alike.
+var dashboardColors = themes["dark"]
+
+// Size of the pressure chart in SVG units.
+const (
+	chartWidth   = 720
+	chartHeight  = 220
+	chartPadLeft = 48
+	chartPadTop  = 12
+	chartPadBot  = 28
+)
+
+// dashboardPage is what the dashboard template renders.
+type dashboardPage struct {
+	Place     string
+	AreaCode  string
+	FetchedAt string
+	Stale     string
+	Error     string
+	Areas     []dashboardLink
+	Days      []dashboardLink
+	Chart     *dashboardChart
+	Hours     []dashboardHour
+	Refresh   int // seconds
+}
+
+type dashboardLink struct {
+	Name    string
+	URL     string
+	Current bool
+}
+
+// dashboardHour is a row of the table.
+type dashboardHour struct {
+	apiHour
+	Hour       string // "15:00"
+	LevelColor string
+	RiskColor  string
+	Now        bool
+}
+
+// dashboardChart is the SVG line of the pressure of a day.
+type dashboardChart struct {
+	Width, Height int
+	Line          string // polyline points
+	Points        []chartPoint
+	XTicks        []chartTick
+	YTicks        []chartTick
+	NowX          float64
+	HasNow        bool
+	Bottom        float64
+}
+
+type chartPoint struct {
+	X, Y  float64
+	Color string
+	Title string
+}
+
+type chartTick struct {
+	At    float64
+	Label string
+}
+
+// dashboardAreas returns the areas the dashboard links to: the saved
+// locations, else the watched areas.
+func dashboardAreas(cfg Config) []dashboardLink {
+	var links []dashboardLink
+	for _, loc := range cfg.Locations {
+		links = append(links, dashboardLink{Name: loc.Name, URL: loc.Area})
+	}
+	if len(links) > 0 {
+		return links
+	}
+	for _, code := range cfg.watchedAreas(nil) {
+		name := code
+		if a, ok := areas.Lookup(code); ok {
+			name = a.Name
+		}
+		links = append(links, dashboardLink{Name: name, URL: code})
+	}
+	return links
+}
+
+// dashboard serves the HTML page of ?area= (default the first saved
+// location) and ?day= (default today).
+func (s *apiServer) dashboard(w http.ResponseWriter, r *http.Request) {
+	page := dashboardPage{Refresh: int(dashboardRefresh / time.Second)}
+	area := r.URL.Query().Get("area")
+	day := strings.ToLower(r.URL.Query().Get("day"))
+	if day == "" {
+		day = "today"
+	}
+	links := dashboardAreas(s.cfg)
+	if area == "" && len(links) > 0 {
+		area = links[0].URL
+	}
+
+	for _, l := range links {
+		page.Areas = append(page.Areas, dashboardLink{Name: l.Name, URL: "/?" + url.Values{"area": {l.URL}, "day": {day}}.Encode(), Current: l.URL == area})
+	}
+	if area == "" {
+		page.Error = "No area to show: add ?area=<area code> to the address, or save locations in the config file."
+		renderDashboard(w, http.StatusBadRequest, page)
+		return
+	}
+	if _, err := dayIndices(day); err != nil {
+		page.Error = err.Error()
+		renderDashboard(w, http.StatusBadRequest, page)
+		return
+	}
+	for _, d := range apiDays {
+		page.Days = append(page.Days, dashboardLink{Name: dayTitle(d), URL: "/?" + url.Values{"area": {area}, "day": {d}}.Encode(), Current: d == day})
+	}
+
+	code, res, hours, status, err := s.loadHours(r.Context(), area)
+	if err != nil {
+		page.Error = err.Error()
+		renderDashboard(w, status, page)
+		return
+	}
+	page.Place, page.AreaCode = res.data.PlaceName, code
+	page.FetchedAt = res.fetchedAt.Format("2006-01-02 15:04")
+	if res.stale != nil {
+		page.Stale = res.stale.Error()
+	}
+	now := time.Now().Truncate(time.Hour)
+	for _, h := range hours {
+		if h.Day != day {
+			continue
+		}
+		row := dashboardHour{apiHour: h, Hour: h.at.Format("15:04"), Now: h.at.Equal(now)}
+		row.LevelColor = unknownLevelColor
+		if h.PressureLevel != nil && *h.PressureLevel >= 0 && *h.PressureLevel < len(dashboardColors.Levels) {
+			row.LevelColor = dashboardColors.Levels[*h.PressureLevel]
+		}
+		row.RiskColor = dashboardColors.Risk[min(max(h.Risk/25, 0), len(dashboardColors.Risk)-1)]
+		page.Hours = append(page.Hours, row)
+	}
+	page.Chart = pressureChart(page.Hours)
+	renderDashboard(w, http.StatusOK, page)
+}
+
+func renderDashboard(w http.ResponseWriter, status int, page dashboardPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_ = dashboardTemplate.Execute(w, page)
+}
+
+// dayTitle returns the tab label of a day filter value.
+func dayTitle(day string) string {
+	if day == "dayafter" {
+		return "Day after"
+	}
+	return strings.ToUpper(day[:1]) + day[1:]
+}
+
+// pressureChart returns the chart of the pressure of rows, or nil when
+// fewer than two of them have one.
+func pressureChart(rows []dashboardHour) *dashboardChart {
+	var lo, hi float64
+	n := 0
+	for _, r := range rows {
+		if r.Pressure == nil {
+			continue
+		}
+		if n == 0 || *r.Pressure < lo {
+			lo = *r.Pressure
+		}
+		if n == 0 || *r.Pressure > hi {
+			hi = *r.Pressure
+		}
+		n++
+	}
+	if n < 2 {
+		return nil
+	}
+	// Keep at least a few hPa of range so flat days do not look dramatic.
+	if hi-lo < 4 {
+		mid := (hi + lo) / 2
+		lo, hi = mid-2, mid+2
+	}
+	c := &dashboardChart{Width: chartWidth, Height: chartHeight, Bottom: chartHeight - chartPadBot}
+	plotW := float64(chartWidth - chartPadLeft - 8)
+	plotH := float64(chartHeight - chartPadTop - chartPadBot)
+	x := func(hour int) float64 { return chartPadLeft + plotW*float64(hour)/23 }
+	y := func(p float64) float64 { return chartPadTop + plotH*(hi-p)/(hi-lo) }
+
+	var line []string
+	for _, r := range rows {
+		hour := r.at.Hour()
+		if r.Now {
+			c.NowX, c.HasNow = x(hour), true
+		}
+		if r.Pressure == nil {
+			continue
+		}
+		px, py := x(hour), y(*r.Pressure)
+		line = append(line, fmt.Sprintf("%.1f,%.1f", px, py))
+		c.Points = append(c.Points, chartPoint{X: px, Y: py, Color: r.LevelColor,
+			Title: fmt.Sprintf("%s %.1f hPa, %s", r.Hour, *r.Pressure, r.LevelName)})
+	}
+	c.Line = strings.Join(line, " ")
+	for hour := 0; hour < 24; hour += 3 {
+		c.XTicks = append(c.XTicks, chartTick{At: x(hour), Label: fmt.Sprintf("%02d", hour)})
+	}
+	for i := 0; i <= 4; i++ {
+		p := lo + (hi-lo)*float64(i)/4
+		c.YTicks = append(c.YTicks, chartTick{At: y(p), Label: fmt.Sprintf("%.0f", p)})
+	}
+	return c
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"goHeadache/pkg/zutool"
+)
+
+// dayOverDayColumn shows how much the pressure of each hour differs from
+// the same hour of the day before. It is only part of the layout once d
+// turns the comparison on, and the hour that differs the most is
+// underlined.
+var dayOverDayColumn = column{name: "vsday", title: "Vs day before", short: "±1d", unit: "(hPa)", minW: 8, prefW: 15, cell: func(r tableRow, w int) string {
+	s := deltaStyle(r.style, r.vsDay, r.vsDayOK)
+	if r.vsDayMost {
+		s = s.Bold(true).Underline(true)
+	}
+	return renderCell(s, w, formatDelta(r.vsDay, r.vsDayOK))
+}}
+
+// dayPressures maps the hours of a day to their pressures.
+func (m model) dayPressures(day int) map[int]float64 {
+	_, data := m.getDayData(day)
+	values, ok := pressureValues(data)
+	pressures := map[int]float64{}
+	for i, entry := range data {
+		if h, err := strconv.Atoi(strings.TrimSpace(entry.Time)); err == nil && ok[i] {
+			pressures[h] = values[i]
+		}
+	}
+	return pressures
+}
+
+// dayOverDayChanges returns the change of the pressure of each hour of data
+// from the same hour of the day before; ok is false where either is
+// unknown, as for every hour of Yesterday. most is the hour that changed
+// the most, -1 if none is known.
+func (m model) dayOverDayChanges(data []zutool.HourlyData) (changes []float64, ok []bool, most int) {
+	values, valid := pressureValues(data)
+	days := m.viewDays(len(data))
+	before := map[int]map[int]float64{}
+	changes = make([]float64, len(data))
+	ok = make([]bool, len(data))
+	most = -1
+	for i, entry := range data {
+		day := days[i]
+		h, err := strconv.Atoi(strings.TrimSpace(entry.Time))
+		if day == 0 || err != nil || !valid[i] {
+			continue
+		}
+		if before[day] == nil {
+			before[day] = m.dayPressures(day - 1)
+		}
+		if v, found := before[day][h]; found {
+			changes[i], ok[i] = values[i]-v, true
+			if most < 0 || math.Abs(changes[i]) > math.Abs(changes[most]) {
+				most = i
+			}
+		}
+	}
+	return changes, ok, most
+}
+
+// dayOverDaySummary answers whether the day shown is better or worse than
+// the day before, by their highest pressure levels, and says which hour
+// differs the most, as in "vs Today: worse, level 3 vs 2 · most different
+// at 15:00, -2.3 hPa". It is empty for Yesterday.
+func (m model) dayOverDaySummary(data []zutool.HourlyData) string {
+	if m.currentDay == 0 {
+		return ""
+	}
+	changes, _, most := m.dayOverDayChanges(data)
+	name, before := m.getDayData(m.currentDay - 1)
+	level, levelBefore := highestLevel(data), highestLevel(before)
+	if most < 0 || level < 0 || levelBefore < 0 {
+		return ""
+	}
+	verdict := tr("similar")
+	switch {
+	case level > levelBefore:
+		verdict = tr("worse")
+	case level < levelBefore:
+		verdict = tr("better")
+	}
+	hour, _, _, _ := displayHourlyData(data[most])
+	return trf("vs %s: %s, level %d vs %d", tr(name), verdict, level, levelBefore) + " · " +
+		trf("most different at %s, %s", hour, units.formatChange(changes[most])+" "+units.pressure)
+}
+
+// highestLevel returns the highest pressure level of data, -1 if none is
+// known.
+func highestLevel(data []zutool.HourlyData) int {
+	highest := -1
+	for _, entry := range data {
+		if level, err := strconv.Atoi(strings.TrimSpace(entry.PressureLevel)); err == nil {
+			highest = max(highest, level)
+		}
+	}
+	return highest
+}
+
+// toggleDayOverDay adds or removes the comparison with the day before.
+func (m model) toggleDayOverDay() model {
+	m.dayOverDay = !m.dayOverDay
+	m.syncContent()
+	return m
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"goHeadache/pkg/zutool"
+)
+
+// daySummary sums up the hours of a day in one line, such as
+// "1003.2–1011.8 hPa · falls 8.6 hPa · worst 15:00, level 3 · mostly Sunny":
+// the range of the pressure, how far it falls from a high to a later low,
+// the first hour of the highest level and the most frequent weather. Parts
+// without data are left out.
+func daySummary(data []zutool.HourlyData) string {
+	var parts []string
+	values, ok := pressureValues(data)
+	lo, hi, high, fall, found := 0.0, 0.0, 0.0, 0.0, false
+	for i, v := range values {
+		if !ok[i] {
+			continue
+		}
+		if !found {
+			lo, hi, high, found = v, v, v, true
+		}
+		lo, hi, high = min(lo, v), max(hi, v), max(high, v)
+		fall = max(fall, high-v)
+	}
+	if found {
+		parts = append(parts, units.formatRange(lo, hi))
+		if fall >= 0.05 {
+			parts = append(parts, trf("falls %s", units.formatFall(fall)))
+		} else {
+			parts = append(parts, tr("no fall"))
+		}
+	}
+
+	worst, worstAt := -1, ""
+	weather := map[string]int{}
+	dominant := ""
+	for _, entry := range data {
+		if level, err := strconv.Atoi(strings.TrimSpace(entry.PressureLevel)); err == nil && level > worst {
+			worst, worstAt = level, entry.Time
+		}
+		code := strings.TrimSpace(entry.Weather)
+		if code == "" {
+			continue
+		}
+		weather[code]++
+		if weather[code] > weather[dominant] {
+			dominant = code
+		}
+	}
+	if worst >= 0 {
+		hour, _, _, _ := displayHourlyData(zutool.HourlyData{Time: worstAt})
+		parts = append(parts, trf("worst %s, level %d", hour, worst))
+	}
+	if dominant != "" {
+		parts = append(parts, trf("mostly %s", weatherDescription(dominant)))
+	}
+	return strings.Join(parts, " · ")
+}
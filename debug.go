@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// debugLog receives the structured logs of -debug; it discards them
+// otherwise.
+var debugLog = slog.New(slog.DiscardHandler)
+
+// debugBodyLimit is how much of each response body -debug logs and crash
+// reports keep.
+const debugBodyLimit = 4096
+
+// userStateDir returns the directory for state worth keeping but not
+// backing up, such as logs: $XDG_STATE_HOME, else ~/.local/state. macOS and
+// Windows have none, so the data directory is used.
+func userStateDir() (string, error) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return userDataDir()
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating state directory: %v", err)
+	}
+	return filepath.Join(home, ".local", "state"), nil
+}
+
+// setupDebug makes debugLog append JSON lines to
+// <state dir>/goHeadache/debug.log and returns the path.
+func setupDebug() (string, error) {
+	dir, err := userStateDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "goHeadache", "debug.log")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("error creating debug log: %v", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("error creating debug log: %v", err)
+	}
+	debugLog = slog.New(slog.NewJSONHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	b := buildInfo()
+	debugLog.Info("start", "version", b.version, "commit", b.commit, "build_date", b.date, "go", b.goVersion, "args", os.Args[1:])
+	return path, nil
+}
+
+// apiTransport keeps the last response made through next for crash
+// reports and, with -debug, logs every request and the start of the body of
+// its response, where malformed API responses show.
+type apiTransport struct{ next http.RoundTripper }
+
+// lastURL and lastBody are the last response of apiTransport.
+var (
+	lastMu   sync.Mutex
+	lastURL  string
+	lastBody []byte
+)
+
+// lastResponse returns the URL and the start of the body of the last API
+// response, "" if none came yet.
+func lastResponse() (url, body string) {
+	lastMu.Lock()
+	defer lastMu.Unlock()
+	return lastURL, string(lastBody)
+}
+
+func (t apiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		debugLog.Warn("http request failed", "method", req.Method, "url", logURL(req.URL), "duration", time.Since(start).Round(time.Millisecond).String(), "error", err)
+		return nil, err
+	}
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	attrs := []any{"method", req.Method, "url", logURL(req.URL), "status", resp.StatusCode, "duration", time.Since(start).Round(time.Millisecond).String(), "bytes", len(body)}
+	if readErr != nil {
+		attrs = append(attrs, "error", readErr)
+	}
+	kept := body[:min(len(body), debugBodyLimit)]
+	debugLog.Debug("http request", append(attrs, "body", string(kept))...)
+	lastMu.Lock()
+	lastURL, lastBody = logURL(req.URL), kept
+	lastMu.Unlock()
+	if readErr != nil {
+		return nil, readErr
+	}
+	return resp, nil
+}
+
+// logURL returns u as it is logged and kept for crash reports, which users
+// attach to public issues: without user info and query, since the query
+// holds the OpenWeatherMap key (appid) and the coordinates of the user.
+func logURL(u *url.URL) string {
+	s := u.Scheme + "://" + u.Host + u.Path
+	if u.RawQuery != "" {
+		s += "?..."
+	}
+	return s
+}
+
+// logMessage logs a message the forecast screen received by its type; key
+// presses also by their key.
+func logMessage(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		debugLog.Debug("tea message", "type", fmt.Sprintf("%T", msg), "key", msg.String())
+	case statusTickMsg:
+		// Once a second in watch mode, which would drown the rest.
+	default:
+		debugLog.Debug("tea message", "type", fmt.Sprintf("%T", msg))
+	}
+}
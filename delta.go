@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+
+	"charm.land/lipgloss/v2"
+
+	"goHeadache/pkg/zutool"
+)
+
+// Hour-over-hour pressure drops at or beyond these rates are highlighted.
+const (
+	dropWarnHPa   = -0.5
+	dropSevereHPa = -1.0
+)
+
+// pressureDeltas returns the change from the previous hour for each entry.
+// before is the pressure of the hour preceding data[0], if known. ok is
+// false where either hour lacks a pressure.
+func pressureDeltas(before *float64, data []zutool.HourlyData) (deltas []float64, ok []bool) {
+	values, valid := pressureValues(data)
+	deltas = make([]float64, len(data))
+	ok = make([]bool, len(data))
+	prev, havePrev := 0.0, before != nil
+	if havePrev {
+		prev = *before
+	}
+	for i, v := range values {
+		if valid[i] && havePrev {
+			deltas[i] = v - prev
+			ok[i] = true
+		}
+		prev, havePrev = v, valid[i]
+	}
+	return deltas, ok
+}
+
+// formatDelta renders a pressure change such as "-1.8" or "+0.3", in the
+// display unit.
+func formatDelta(delta float64, ok bool) string {
+	if !ok {
+		return "N/A"
+	}
+	return units.formatChange(delta)
+}
+
+// deltaStyle colors s when the change is a drop beyond the thresholds.
+func deltaStyle(s lipgloss.Style, delta float64, ok bool) lipgloss.Style {
+	switch {
+	case !ok:
+		return s
+	case delta <= dropSevereHPa:
+		return s.Foreground(lipgloss.Color(theme.DropSevere)).Bold(true)
+	case delta <= dropWarnHPa:
+		return s.Foreground(lipgloss.Color(theme.DropWarn))
+	}
+	return s
+}
+
+// pressureBefore returns the last known pressure of the day preceding the
+// current view, used for the first row's delta.
+func (m model) pressureBefore() *float64 {
+	day := m.currentDay
+	if m.timelineDays > 0 {
+		day = 1
+	}
+	if day == 0 {
+		return nil
+	}
+	_, prevData := m.getDayData(day - 1)
+	return lastPressure(prevData)
+}
+
+// lastPressure returns the pressure of the last hour of data that has one.
+func lastPressure(data []zutool.HourlyData) *float64 {
+	for i := len(data) - 1; i >= 0; i-- {
+		if p := strings.TrimSpace(data[i].Pressure); p != "" && p != "#" {
+			v := parseFloat(p)
+			return &v
+		}
+	}
+	return nil
+}
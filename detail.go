@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+
+	"goHeadache/pkg/zutool"
+)
+
+// selectedRow returns the values of the hour selected in the table.
+func (m model) selectedRow() (tableRow, bool) {
+	_, data, labels, _ := m.currentView()
+	shown := m.shownRows(data)
+	i := m.table.Cursor()
+	if i < 0 || i >= len(shown) {
+		return tableRow{}, false
+	}
+	return m.tableRows(data, labels)[shown[i]], true
+}
+
+// detailText lists everything known about the hour of r, unabbreviated.
+func detailText(placeName, dayName string, r tableRow) string {
+	weather := tr("Unknown")
+	code := strings.TrimSpace(r.entry.Weather)
+	if wc, ok := zutool.LookupWeatherCode(code); ok && lang == "ja" {
+		weather = trf("%s (code %s)", wc.Ja, code)
+	} else if ok {
+		weather = trf("%s (%s, code %s)", weatherDescription(code), wc.Ja, code)
+	} else if code != "" {
+		weather = trf("code %s", code)
+	}
+	temp := r.temp
+	if temp != "N/A" {
+		temp += " " + units.tempSymbol()
+	}
+	pressure := r.pressure
+	if pressure != "N/A" {
+		pressure += " " + units.pressure
+	}
+	change := formatDelta(r.delta, r.deltaOK)
+	if r.deltaOK {
+		change += " " + units.pressure + "/h"
+	}
+	level := strings.TrimSpace(r.entry.PressureLevel)
+
+	text := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Text))
+	fields := [][2]string{
+		{tr("Weather"), text.Render(weather)},
+		{tr("Temperature"), text.Render(temp)},
+		{tr("Pressure"), text.Render(pressure)},
+		{tr("Change"), deltaStyle(text, r.delta, r.deltaOK).Render(change) + text.Render(tr(" from the previous hour"))},
+		{tr("Level"), levelStyle(level).Render(level + " " + levelName(level))},
+		{tr("Risk"), riskBadge(r.risk) + text.Render(tr(" out of 100"))},
+	}
+	if r.rain != "" {
+		fields = append(fields, [2]string{tr("Rain"), text.Render(trf("%s chance of precipitation", r.rain))})
+	}
+	labelW := 0
+	for _, f := range fields {
+		labelW = max(labelW, lipgloss.Width(f[0]))
+	}
+	lines := []string{dayHeaderStyle.MarginTop(0).Render(fmt.Sprintf("%s - %s %s", placeName, tr(dayName), r.hour)), ""}
+	for _, f := range fields {
+		lines = append(lines, text.Bold(true).Width(labelW+2).Render(f[0]+":")+f[1])
+	}
+	lines = append(lines, "", statusStyle.Align(lipgloss.Center).Render(detailPopupKeys.line()))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// detailBox renders the detail popup of the selected hour.
+func (m model) detailBox() (string, bool) {
+	r, ok := m.selectedRow()
+	if !ok {
+		return "", false
+	}
+	dayName, _, _, _ := m.currentView()
+	return popupStyle.Render(detailText(m.weatherData.PlaceName, dayName, r)), true
+}
+
+// overlay draws box centered over view.
+func overlay(view, box string) string {
+	x := max((lipgloss.Width(view)-lipgloss.Width(box))/2, 0)
+	y := max((lipgloss.Height(view)-lipgloss.Height(box))/2, 0)
+	return lipgloss.NewCompositor(
+		lipgloss.NewLayer(view),
+		lipgloss.NewLayer(box).X(x).Y(y).Z(1),
+	).Render()
+}
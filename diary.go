@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// diaryEntry is a headache episode in the diary.
+type diaryEntry struct {
+	Time time.Time `json:"time"`
+	// Severity is from 1 (barely noticeable) to 10 (the worst).
+	Severity int    `json:"severity"`
+	Note     string `json:"note,omitempty"`
+	// Area is the area code the episode happened in, for comparing it with
+	// the pressure recorded there.
+	Area string `json:"area,omitempty"`
+}
+
+// maxSeverity is the highest severity of a diary entry.
+const maxSeverity = 10
+
+// validSeverity reports whether a severity can be logged.
+func validSeverity(severity int) bool {
+	return severity >= 1 && severity <= maxSeverity
+}
+
+// diaryFile returns the path of the diary, goHeadache/diary.jsonl in the
+// user data directory.
+func diaryFile() (string, error) {
+	dir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goHeadache", "diary.jsonl"), nil
+}
+
+// appendDiary adds e to the diary.
+func appendDiary(e diaryEntry) error {
+	path, err := diaryFile()
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error writing the diary: %v", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error writing the diary: %v", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing the diary: %v", err)
+	}
+	return f.Close()
+}
+
+// readDiary returns the entries of the diary in the order they were
+// logged. Lines that cannot be parsed are skipped.
+func readDiary() ([]diaryEntry, error) {
+	path, err := diaryFile()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading the diary: %v", err)
+	}
+	defer f.Close()
+	var entries []diaryEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e diaryEntry
+		if json.Unmarshal(sc.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("error reading the diary: %v", err)
+	}
+	return entries, nil
+}
+
+// parseEpisodeTime parses when an episode began: "15:04" today,
+// "2006-01-02 15:04", or a duration ago such as "2h".
+func parseEpisodeTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation("15:04", s, now.Location()); err == nil {
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location()), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use 15:04, 2006-01-02 15:04 or a duration ago such as 2h)", s)
+}
+
+// parseDiaryInput parses what is typed into the diary popup of the
+// forecast screen: the severity, then an optional note, such as "7 aura".
+func parseDiaryInput(s string) (severity int, note string, err error) {
+	first, rest, _ := strings.Cut(strings.TrimSpace(s), " ")
+	severity, err = strconv.Atoi(first)
+	if err != nil || !validSeverity(severity) {
+		return 0, "", fmt.Errorf("start with a severity from 1 to %d", maxSeverity)
+	}
+	return severity, strings.TrimSpace(rest), nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"time"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// diarySavedMsg reports logging an episode from the forecast screen.
+type diarySavedMsg struct {
+	entry diaryEntry
+	err   error
+}
+
+// saveDiaryCmd appends e to the diary.
+func saveDiaryCmd(e diaryEntry) tea.Cmd {
+	return func() tea.Msg {
+		return diarySavedMsg{e, appendDiary(e)}
+	}
+}
+
+// openDiary shows the popup logging a headache episode.
+func (m model) openDiary() (model, tea.Cmd) {
+	m.diary = true
+	m.diaryNotice = ""
+	m.diaryInput = textinput.New()
+	m.diaryInput.Prompt = "> "
+	m.diaryInput.Placeholder = "7 aura"
+	m.diaryInput.CharLimit = 200
+	m.diaryInput.SetWidth(40)
+	return m, m.diaryInput.Focus()
+}
+
+// updateDiary handles the keys of the diary popup: enter logs the episode,
+// esc closes the popup. Once logged, any key closes it.
+func (m model) updateDiary(msg tea.KeyPressMsg) (model, tea.Cmd) {
+	if m.diaryNotice != "" {
+		m.diary = false
+		return m, nil
+	}
+	switch msg.String() {
+	case "ctrl+c":
+		return m.quit()
+	case "esc":
+		m.diary = false
+		return m, nil
+	case "enter":
+		severity, note, err := parseDiaryInput(m.diaryInput.Value())
+		if err != nil {
+			m.diaryErr = err
+			return m, nil
+		}
+		m.diaryErr = nil
+		return m, saveDiaryCmd(diaryEntry{Time: time.Now(), Severity: severity, Note: note, Area: m.areaCode})
+	}
+	var cmd tea.Cmd
+	m.diaryInput, cmd = m.diaryInput.Update(msg)
+	return m, cmd
+}
+
+// diarySaved shows whether the episode was logged.
+func (m model) diarySaved(msg diarySavedMsg) model {
+	if msg.err != nil {
+		m.diaryErr = msg.err
+		return m
+	}
+	m.diaryNotice = trf("Logged a headache of severity %d at %s.", msg.entry.Severity, formatClock(msg.entry.Time))
+	return m
+}
+
+// diaryBox renders the diary popup.
+func (m model) diaryBox() string {
+	text := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Text))
+	lines := []string{dayHeaderStyle.MarginTop(0).Render(tr("Log a headache")), ""}
+	if m.diaryNotice != "" {
+		lines = append(lines, text.Render(m.diaryNotice), "", statusStyle.Render(tr("Press any key to close")))
+		return popupStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+	}
+	lines = append(lines,
+		text.Render(trf("Severity from 1 to %d, then a note if you like:", maxSeverity)),
+		m.diaryInput.View(),
+	)
+	if m.diaryErr != nil {
+		lines = append(lines, errorStyle.Render(trError(m.diaryErr)))
+	}
+	lines = append(lines, "", statusStyle.Render(diaryPopupKeys.line()))
+	return popupStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"goHeadache/pkg/zutool"
+)
+
+// digestGrace is how late the daily digest is still sent, for a daemon that
+// was not running or asleep at digest_at.
+const digestGrace = 2 * time.Hour
+
+// parseDigestAt parses the digest_at setting, such as "07:30", into the time
+// after midnight. ok is false when it is empty.
+func parseDigestAt(s string) (at time.Duration, ok bool, err error) {
+	if s == "" {
+		return 0, false, nil
+	}
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid digest_at %q (use a time such as \"07:30\")", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true, nil
+}
+
+// midnight returns the start of the day of t.
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// nextDigest returns when the next daily digest is due after now.
+func (d *daemon) nextDigest(now time.Time) time.Time {
+	next := midnight(now).Add(d.digestAt)
+	if !next.After(now) {
+		next = midnight(now).AddDate(0, 0, 1).Add(d.digestAt)
+	}
+	return next
+}
+
+// digestDue reports whether today's digest should go out at now.
+func (d *daemon) digestDue(now time.Time) bool {
+	due := midnight(now).Add(d.digestAt)
+	return d.digest && !now.Before(due) && now.Sub(due) < digestGrace
+}
+
+// dailyDigest returns the summary of the day of now in w: its worst
+// pressure drop, the range of the pressure and the highest risk score.
+func dailyDigest(w zutool.WeatherData, areaCode string, weights RiskWeights, now time.Time) (alert, bool) {
+	all, data := forecastHours(w, now)
+	deltas, deltaOK := pressureDeltas(nil, data)
+	risks := riskScores(weights, data, deltas, deltaOK)
+
+	var (
+		today     []upcomingHour
+		low, high float64
+		havePress bool
+		maxRisk   = -1
+		riskAt    time.Time
+		maxLevel  = -1
+		levelAt   time.Time
+	)
+	for i, u := range all {
+		if !sameDay(u.at, now) {
+			continue
+		}
+		today = append(today, u)
+		if p, ok := hourPressure(u); ok {
+			if !havePress || p < low {
+				low = p
+			}
+			if !havePress || p > high {
+				high = p
+			}
+			havePress = true
+		}
+		if risks[i] > maxRisk {
+			maxRisk, riskAt = risks[i], u.at
+		}
+		if l, err := strconv.Atoi(strings.TrimSpace(u.entry.PressureLevel)); err == nil && l > maxLevel {
+			maxLevel, levelAt = l, u.at
+		}
+	}
+	if len(today) == 0 {
+		return alert{}, false
+	}
+
+	var lines []string
+	if from, to, drop, ok := largestDrop(today); ok && drop >= -dropWarnHPa {
+		lines = append(lines, fmt.Sprintf("Worst drop %s–%s: -%s", formatClock(from.at), formatClock(to.at), units.formatFall(drop)))
+	} else {
+		lines = append(lines, "No notable pressure drop")
+	}
+	if havePress {
+		lines = append(lines, "Pressure "+units.formatRange(low, high))
+	}
+	if maxLevel >= 0 {
+		lines = append(lines, fmt.Sprintf("Level up to %d (%s) at %s", maxLevel, levelName(strconv.Itoa(maxLevel)), formatClock(levelAt)))
+	}
+	if maxRisk >= 0 {
+		lines = append(lines, fmt.Sprintf("Risk up to %d at %s", maxRisk, formatClock(riskAt)))
+	}
+
+	severity := "info"
+	switch {
+	case maxLevel >= 4:
+		severity = "severe"
+	case maxLevel >= 3:
+		severity = "warning"
+	}
+	return alert{
+		areaCode:  areaCode,
+		placeName: w.PlaceName,
+		rule:      "daily-digest",
+		severity:  severity,
+		// The digest is about the whole day, so it goes out once a day.
+		warning: pressureWarning{at: midnight(now), level: max(maxLevel, 0)},
+		title:   "Today's pressure: " + w.PlaceName,
+		body:    strings.Join(lines, "\n"),
+	}, true
+}
+
+// sendDigest sends the daily digest of one area unless it was sent today.
+func (d *daemon) sendDigest(ctx context.Context, code string, w zutool.WeatherData, now time.Time) {
+	a, ok := dailyDigest(w, code, d.risk, now)
+	if !ok {
+		return
+	}
+	if d.dryRun {
+		for _, n := range d.notifiers {
+			d.log.Printf("%s: would send the daily digest via %s: %s", code, n.name(), n.preview(a))
+		}
+		return
+	}
+	sent, errs := d.state.dispatch(ctx, a, d.notifiers, now)
+	for _, err := range errs {
+		d.log.Printf("%s: error sending the daily digest: %v", code, err)
+	}
+	if sent {
+		d.log.Printf("%s: sent the daily digest of %s", code, a.placeName)
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dumpVersion is the version of the JSON dumps of export.
+const dumpVersion = 1
+
+// dataDump is what export writes as JSON and import reads back: the diary
+// and the history records by area code.
+type dataDump struct {
+	Version    int                        `json:"version"`
+	ExportedAt time.Time                  `json:"exported_at"`
+	Diary      []diaryEntry               `json:"diary,omitempty"`
+	History    map[string][]historyRecord `json:"history,omitempty"`
+}
+
+var (
+	diaryCSVHeader   = []string{"time", "severity", "note", "area"}
+	historyCSVHeader = []string{"area", "fetched_at", "source", "place", "issued", "kind", "time", "weather", "temp", "pressure", "pressure_level"}
+)
+
+// areas returns the area codes with a history, sorted.
+func (s *historyStore) areas() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var codes []string
+	for _, e := range entries {
+		if e.IsDir() {
+			codes = append(codes, e.Name())
+		}
+	}
+	sort.Strings(codes)
+	return codes, nil
+}
+
+// importRecords adds the records of areaCode that are not in the history
+// yet, keeping each day file sorted by the time of fetching. It returns how
+// many were added.
+func (s *historyStore) importRecords(areaCode string, records []historyRecord) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	byDay := map[time.Time][]historyRecord{}
+	for _, r := range records {
+		day := midnight(r.FetchedAt.Local())
+		byDay[day] = append(byDay[day], r)
+	}
+	if err := os.MkdirAll(s.areaDir(areaCode), 0o755); err != nil {
+		return 0, err
+	}
+	added := 0
+	for day, recs := range byDay {
+		path := s.dayFile(areaCode, day)
+		existing, err := readRecordFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return added, err
+		}
+		seen := map[string]bool{}
+		for _, r := range existing {
+			seen[r.key()] = true
+		}
+		merged := existing
+		for _, r := range recs {
+			if !seen[r.key()] {
+				seen[r.key()] = true
+				merged = append(merged, r)
+				added++
+			}
+		}
+		if len(merged) == len(existing) {
+			continue
+		}
+		sort.SliceStable(merged, func(i, j int) bool { return merged[i].FetchedAt.Before(merged[j].FetchedAt) })
+		if err := writeRecordFile(path, merged); err != nil {
+			return added, err
+		}
+	}
+	return added, nil
+}
+
+// key identifies a record for import: the same forecast fetched at the same
+// time.
+func (r historyRecord) key() string {
+	return r.FetchedAt.UTC().Format(time.RFC3339Nano) + " " + r.Source
+}
+
+// writeRecordFile replaces a day file with records, atomically like the
+// cache.
+func writeRecordFile(path string, records []historyRecord) error {
+	var buf bytes.Buffer
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".history-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// importDiary adds the entries that are not in the diary yet and returns
+// how many were added.
+func importDiary(entries []diaryEntry) (int, error) {
+	existing, err := readDiary()
+	if err != nil {
+		return 0, err
+	}
+	key := func(e diaryEntry) string {
+		return fmt.Sprintf("%s %d %s %s", e.Time.UTC().Format(time.RFC3339), e.Severity, e.Area, e.Note)
+	}
+	seen := map[string]bool{}
+	for _, e := range existing {
+		seen[key(e)] = true
+	}
+	added := 0
+	for _, e := range entries {
+		if seen[key(e)] {
+			continue
+		}
+		seen[key(e)] = true
+		if err := appendDiary(e); err != nil {
+			return added, err
+		}
+		added++
+	}
+	return added, nil
+}
+
+// writeDiaryCSV writes one row per diary entry.
+func writeDiaryCSV(w io.Writer, entries []diaryEntry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(diaryCSVHeader); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := cw.Write([]string{e.Time.Format(time.RFC3339), strconv.Itoa(e.Severity), e.Note, e.Area}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeHistoryCSV writes one row per hour of each record, its kind being
+// forecast or observed. Missing values are empty cells.
+func writeHistoryCSV(w io.Writer, history map[string][]historyRecord) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(historyCSVHeader); err != nil {
+		return err
+	}
+	codes := make([]string, 0, len(history))
+	for code := range history {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	value := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	}
+	for _, code := range codes {
+		for _, r := range history[code] {
+			for _, part := range []struct {
+				kind  string
+				hours []historyHour
+			}{{"forecast", r.Forecast}, {"observed", r.Observed}} {
+				for _, h := range part.hours {
+					level := ""
+					if h.Level != nil {
+						level = strconv.Itoa(*h.Level)
+					}
+					row := []string{code, r.FetchedAt.Format(time.RFC3339Nano), r.Source, r.Place, r.Issued, part.kind,
+						h.Time.Format(time.RFC3339), h.Weather, value(h.Temp), value(h.Pressure), level}
+					if err := cw.Write(row); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// readDump reads what export wrote: a JSON dump, or the CSV of the diary or
+// of the history.
+func readDump(r io.Reader) (dataDump, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(1)
+	if err != nil {
+		return dataDump{}, fmt.Errorf("error reading the dump: %v", err)
+	}
+	if first[0] == '{' {
+		var d dataDump
+		if err := json.NewDecoder(br).Decode(&d); err != nil {
+			return dataDump{}, fmt.Errorf("error reading the dump: %v", err)
+		}
+		if d.Version > dumpVersion {
+			return dataDump{}, fmt.Errorf("the dump is version %d, newer than this goHeadache understands (%d)", d.Version, dumpVersion)
+		}
+		return d, nil
+	}
+
+	rows, err := csv.NewReader(br).ReadAll()
+	if err != nil {
+		return dataDump{}, fmt.Errorf("error reading the CSV: %v", err)
+	}
+	if len(rows) == 0 {
+		return dataDump{}, errors.New("the CSV is empty")
+	}
+	header := strings.Join(rows[0], ",")
+	switch header {
+	case strings.Join(diaryCSVHeader, ","):
+		return readDiaryCSV(rows[1:])
+	case strings.Join(historyCSVHeader, ","):
+		return readHistoryCSV(rows[1:])
+	}
+	return dataDump{}, fmt.Errorf("unknown CSV columns %q (expected those of export -format csv)", header)
+}
+
+func readDiaryCSV(rows [][]string) (dataDump, error) {
+	var d dataDump
+	for i, row := range rows {
+		at, err := time.Parse(time.RFC3339, row[0])
+		if err != nil {
+			return d, fmt.Errorf("line %d: invalid time %q", i+2, row[0])
+		}
+		severity, err := strconv.Atoi(row[1])
+		if err != nil || !validSeverity(severity) {
+			return d, fmt.Errorf("line %d: invalid severity %q", i+2, row[1])
+		}
+		d.Diary = append(d.Diary, diaryEntry{Time: at, Severity: severity, Note: row[2], Area: row[3]})
+	}
+	return d, nil
+}
+
+func readHistoryCSV(rows [][]string) (dataDump, error) {
+	d := dataDump{History: map[string][]historyRecord{}}
+	// Rows of a record are consecutive; index finds the record of a row.
+	index := map[string]int{}
+	optional := func(s string) (*float64, error) {
+		if s == "" {
+			return nil, nil
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		return &v, err
+	}
+	for i, row := range rows {
+		code := row[0]
+		fetchedAt, err := time.Parse(time.RFC3339Nano, row[1])
+		if err != nil {
+			return d, fmt.Errorf("line %d: invalid fetched_at %q", i+2, row[1])
+		}
+		h := historyHour{Weather: row[7]}
+		if h.Time, err = time.Parse(time.RFC3339, row[6]); err != nil {
+			return d, fmt.Errorf("line %d: invalid time %q", i+2, row[6])
+		}
+		if h.Temp, err = optional(row[8]); err != nil {
+			return d, fmt.Errorf("line %d: invalid temp %q", i+2, row[8])
+		}
+		if h.Pressure, err = optional(row[9]); err != nil {
+			return d, fmt.Errorf("line %d: invalid pressure %q", i+2, row[9])
+		}
+		if row[10] != "" {
+			l, err := strconv.Atoi(row[10])
+			if err != nil {
+				return d, fmt.Errorf("line %d: invalid pressure_level %q", i+2, row[10])
+			}
+			h.Level = &l
+		}
+
+		r := historyRecord{FetchedAt: fetchedAt, Source: row[2], Place: row[3], Issued: row[4]}
+		k := code + " " + r.key()
+		n, ok := index[k]
+		if !ok {
+			n = len(d.History[code])
+			index[k] = n
+			d.History[code] = append(d.History[code], r)
+		}
+		switch row[5] {
+		case "forecast":
+			d.History[code][n].Forecast = append(d.History[code][n].Forecast, h)
+		case "observed":
+			d.History[code][n].Observed = append(d.History[code][n].Observed, h)
+		default:
+			return d, fmt.Errorf("line %d: invalid kind %q (use forecast or observed)", i+2, row[5])
+		}
+	}
+	return d, nil
+}
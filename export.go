@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"goHeadache/pkg/zutool"
+)
+
+var csvHeader = []string{"day", "time", "weather", "temp", "pressure", "pressure_level"}
+
+// dayIndices returns the day indices selected by a -day filter value.
+// An empty filter selects every day.
+func dayIndices(dayFilter string) ([]int, error) {
+	switch strings.ToLower(dayFilter) {
+	case "":
+		return []int{0, 1, 2, 3}, nil
+	case "yesterday":
+		return []int{0}, nil
+	case "today":
+		return []int{1}, nil
+	case "tomorrow":
+		return []int{2}, nil
+	case "dayafter":
+		return []int{3}, nil
+	default:
+		return nil, fmt.Errorf("invalid day %q, please use: yesterday, today, tomorrow, or dayafter", dayFilter)
+	}
+}
+
+// writeCSV writes one row per hourly entry for the selected days.
+// Missing temperature and pressure values are written as empty cells so
+// spreadsheets treat the columns as numeric.
+func writeCSV(w io.Writer, data zutool.WeatherData, dayFilter string) error {
+	days, err := dayIndices(dayFilter)
+	if err != nil {
+		return err
+	}
+
+	m := model{weatherData: data}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, day := range days {
+		dayName, dayData := m.getDayData(day)
+		for _, entry := range dayData {
+			hour, weather, temp, pressure := formatHourlyData(entry)
+			if temp == "N/A" {
+				temp = ""
+			}
+			if pressure == "N/A" {
+				pressure = ""
+			}
+			if err := cw.Write([]string{dayName, hour, weather, temp, pressure, entry.PressureLevel}); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeForecastJSON writes the whole forecast of data as zutool's getweatherstatus
+// JSON, which -fixture and -stdin read back.
+func writeForecastJSON(w io.Writer, data zutool.WeatherData) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+// exportForecast fetches the forecast for areaCode and writes it as CSV, or
+// as JSON when asJSON is set, to path, or to stdout when path is empty.
+func exportForecast(areaCode, dayFilter, path string, asJSON bool) error {
+	if _, err := dayIndices(dayFilter); err != nil {
+		return err
+	}
+
+	w, err := loadWeather(context.Background(), areaCode, true)
+	if err != nil {
+		return err
+	}
+	weatherData := w.data
+	noteSubstitute(areaCode, w)
+	if w.stale != nil && !errors.Is(w.stale, errOffline) {
+		fmt.Fprintf(os.Stderr, "Warning: update failed (%v), using the forecast cached %s ago\n", w.stale, formatAge(time.Since(w.fetchedAt)))
+	}
+
+	write := func(w io.Writer) error { return writeCSV(w, weatherData, dayFilter) }
+	if asJSON {
+		write = func(w io.Writer) error { return writeForecastJSON(w, weatherData) }
+	}
+	if path == "" {
+		return write(os.Stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %v", err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
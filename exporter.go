@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// metricFamily is a Prometheus metric with its samples, written in the text
+// exposition format.
+type metricFamily struct {
+	name, kind, help string
+	samples          []metricSample
+}
+
+type metricSample struct {
+	labels []string // name, value pairs
+	value  float64
+}
+
+// add appends a sample with the label pairs.
+func (f *metricFamily) add(value float64, labels ...string) {
+	f.samples = append(f.samples, metricSample{labels: labels, value: value})
+}
+
+// writeMetrics writes families in the text exposition format.
+func writeMetrics(w io.Writer, families []*metricFamily) error {
+	var b strings.Builder
+	for _, f := range families {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		for _, s := range f.samples {
+			b.WriteString(f.name)
+			if len(s.labels) > 0 {
+				b.WriteByte('{')
+				for i := 0; i+1 < len(s.labels); i += 2 {
+					if i > 0 {
+						b.WriteByte(',')
+					}
+					fmt.Fprintf(&b, "%s=\"%s\"", s.labels[i], escapeLabel(s.labels[i+1]))
+				}
+				b.WriteByte('}')
+			}
+			b.WriteByte(' ')
+			b.WriteString(strconv.FormatFloat(s.value, 'f', -1, 64))
+			b.WriteByte('\n')
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// exporter serves the forecasts of its areas as Prometheus metrics.
+type exporter struct {
+	areaCodes []string
+	hours     int // how far ahead the forecast metrics go
+	risk      RiskWeights
+
+	mu     sync.Mutex
+	errors map[string]int // failed fetches by area code
+	// scrapes counts the requests of /metrics.
+	scrapes atomic.Int64
+}
+
+// areaMetrics is what one area contributes to a scrape.
+type areaMetrics struct {
+	code     string
+	result   weatherResult
+	err      error
+	duration time.Duration
+}
+
+// collect fetches every area, through the cache, for one scrape.
+func (e *exporter) collect(ctx context.Context) []areaMetrics {
+	out := make([]areaMetrics, len(e.areaCodes))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrentFetches)
+	for i, code := range e.areaCodes {
+		g.Go(func() error {
+			start := time.Now()
+			w, err := loadWeather(ctx, code, true)
+			out[i] = areaMetrics{code: code, result: w, err: err, duration: time.Since(start)}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, a := range out {
+		if a.err != nil {
+			e.errors[a.code]++
+		}
+	}
+	return out
+}
+
+// families turns the areas of a scrape into metric families.
+func (e *exporter) families(areasMetrics []areaMetrics, now time.Time) []*metricFamily {
+	pressure := &metricFamily{name: "goheadache_pressure_hpa", kind: "gauge", help: "Pressure of the current hour in hPa."}
+	level := &metricFamily{name: "goheadache_pressure_level", kind: "gauge", help: "zutool pressure level (0-4) of the current hour."}
+	temp := &metricFamily{name: "goheadache_temperature_celsius", kind: "gauge", help: "Temperature of the current hour in degrees Celsius."}
+	change := &metricFamily{name: "goheadache_pressure_change_hpa", kind: "gauge", help: "Pressure change from the previous hour in hPa."}
+	risk := &metricFamily{name: "goheadache_risk_score", kind: "gauge", help: "Headache risk score (0-100) of the current hour."}
+	fPressure := &metricFamily{name: "goheadache_forecast_pressure_hpa", kind: "gauge", help: "Forecast pressure in hPa by hours ahead."}
+	fLevel := &metricFamily{name: "goheadache_forecast_pressure_level", kind: "gauge", help: "Forecast zutool pressure level (0-4) by hours ahead."}
+	fTemp := &metricFamily{name: "goheadache_forecast_temperature_celsius", kind: "gauge", help: "Forecast temperature in degrees Celsius by hours ahead."}
+	fRisk := &metricFamily{name: "goheadache_forecast_risk_score", kind: "gauge", help: "Forecast headache risk score (0-100) by hours ahead."}
+	up := &metricFamily{name: "goheadache_fetch_success", kind: "gauge", help: "Whether the forecast of the area could be loaded (1) or not (0)."}
+	stale := &metricFamily{name: "goheadache_forecast_stale", kind: "gauge", help: "Whether the forecast is an old cached one because the source failed (1) or fresh (0)."}
+	fetched := &metricFamily{name: "goheadache_fetch_timestamp_seconds", kind: "gauge", help: "Unix time the forecast was fetched from the source."}
+	duration := &metricFamily{name: "goheadache_fetch_duration_seconds", kind: "gauge", help: "How long loading the forecast took in this scrape."}
+	errorsTotal := &metricFamily{name: "goheadache_fetch_errors_total", kind: "counter", help: "Failed forecast loads since the exporter started."}
+	scrapes := &metricFamily{name: "goheadache_scrapes_total", kind: "counter", help: "Scrapes of /metrics since the exporter started."}
+
+	current := now.Truncate(time.Hour)
+	for _, a := range areasMetrics {
+		// These two are labeled with the area code only, which a failed
+		// load still has, so that a failing area flips its series to 0
+		// instead of starting another.
+		base := []string{"area_code", a.code}
+		up.add(boolMetric(a.err == nil), base...)
+		duration.add(a.duration.Seconds(), base...)
+		if a.err != nil {
+			continue
+		}
+		labels := append(base, "place", a.result.data.PlaceName)
+		stale.add(boolMetric(a.result.stale != nil), labels...)
+		fetched.add(float64(a.result.fetchedAt.Unix()), labels...)
+
+		all, data := forecastHours(a.result.data, now)
+		deltas, deltaOK := pressureDeltas(nil, data)
+		risks := riskScores(e.risk, data, deltas, deltaOK)
+		for i, u := range all {
+			ahead := int(u.at.Sub(current) / time.Hour)
+			if ahead < 0 || ahead > e.hours {
+				continue
+			}
+			p, pOK := hourPressure(u)
+			l, lErr := strconv.Atoi(strings.TrimSpace(u.entry.PressureLevel))
+			t, tErr := strconv.ParseFloat(strings.TrimSpace(u.entry.Temp), 64)
+			if ahead == 0 {
+				if pOK {
+					pressure.add(p, labels...)
+				}
+				if lErr == nil {
+					level.add(float64(l), labels...)
+				}
+				if tErr == nil {
+					temp.add(t, labels...)
+				}
+				if u.deltaOK {
+					change.add(u.delta, labels...)
+				}
+				risk.add(float64(risks[i]), labels...)
+			}
+			hourLabels := append(append([]string(nil), labels...), "hours_ahead", strconv.Itoa(ahead))
+			if pOK {
+				fPressure.add(p, hourLabels...)
+			}
+			if lErr == nil {
+				fLevel.add(float64(l), hourLabels...)
+			}
+			if tErr == nil {
+				fTemp.add(t, hourLabels...)
+			}
+			fRisk.add(float64(risks[i]), hourLabels...)
+		}
+	}
+
+	e.mu.Lock()
+	codes := make([]string, 0, len(e.errors))
+	for code := range e.errors {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		errorsTotal.add(float64(e.errors[code]), "area_code", code)
+	}
+	e.mu.Unlock()
+	scrapes.add(float64(e.scrapes.Load()))
+
+	return []*metricFamily{pressure, level, temp, change, risk, fPressure, fLevel, fTemp, fRisk,
+		up, stale, fetched, duration, errorsTotal, scrapes}
+}
+
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ServeHTTP serves /metrics, and a page linking to it on /.
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/metrics":
+		e.scrapes.Add(1)
+		families := e.families(e.collect(r.Context()), time.Now())
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = writeMetrics(w, families)
+	case "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><title>goHeadache exporter</title></head><body><h1>goHeadache exporter</h1><p><a href="/metrics">Metrics</a></p></body></html>`)
+	default:
+		http.NotFound(w, r)
+	}
+}
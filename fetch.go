@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+
+	"goHeadache/internal/source"
+	"goHeadache/pkg/zutool"
+)
+
+// forecastData is everything the forecast screen shows for an area.
+type forecastData struct {
+	weather weatherResult
+	// pain is the prefecture's headache reports, nil when they could not
+	// be fetched; they are only a supplement to the forecast.
+	pain *zutool.PainStatus
+	// rain is the chance of precipitation from Open-Meteo, nil unless
+	// fetchRain is set and it could be fetched.
+	rain *source.Precipitation
+}
+
+// fetchRain adds Open-Meteo's chance of precipitation to forecasts (-rain).
+var fetchRain bool
+
+// fetchForecast fetches the data of the forecast screen for areaCode. The
+// requests run concurrently, so the wait is that of the slowest one; the
+// first error cancels the rest.
+func fetchForecast(ctx context.Context, areaCode string, useCache bool) (forecastData, error) {
+	var f forecastData
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		f.weather, err = loadWeather(ctx, areaCode, useCache)
+		return err
+	})
+	if usesZutool() && !offlineMode && fixtureData == nil && len(areaCode) >= 2 {
+		// The first two digits of an area code are its prefecture, the same
+		// as PrefecturesID in the forecast, so this need not wait for it.
+		g.Go(func() error {
+			if pain, err := apiClient.GetPainStatus(ctx, areaCode[:2]); err == nil {
+				f.pain = &pain
+			}
+			return nil
+		})
+	}
+	if fetchRain && !offlineMode && fixtureData == nil {
+		g.Go(func() error {
+			loc, err := locationOf(areaCode)
+			if err != nil || !loc.HasCoords {
+				return nil
+			}
+			if rain, err := (source.OpenMeteo{Client: openMeteoClient}).Precipitation(ctx, loc); err == nil {
+				f.rain = &rain
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return forecastData{}, err
+	}
+	return f, nil
+}
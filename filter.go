@@ -0,0 +1,133 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"goHeadache/pkg/zutool"
+)
+
+// levelFilters are the pressure levels f cycles the table through: every
+// hour, then only those of level 2 and above, then 3 and above.
+var levelFilters = []int{0, 2, 3}
+
+// tableSort is the order of the table rows, cycled through with s.
+type tableSort int
+
+const (
+	sortByTime tableSort = iota
+	// sortByPressure puts the lowest pressure first, to find the trough.
+	sortByPressure
+	// sortByRisk puts the highest risk score first.
+	sortByRisk
+)
+
+// column returns the title of the column the table is sorted by and the
+// arrow marking it, or "" for the time order.
+func (s tableSort) column() (title, arrow string) {
+	switch s {
+	case sortByPressure:
+		return "Pressure", "▲"
+	case sortByRisk:
+		return "Risk", "▼"
+	}
+	return "", ""
+}
+
+// shownRows returns the indices of the hours of data that the table shows,
+// in the order of the sort: all of them, less those outside the hour range
+// when the night is hidden and those below the level filter. Hours without
+// a valid time are kept by the hour range, and hours without a level are
+// left out by the filter.
+func (m model) shownRows(data []zutool.HourlyData) []int {
+	shown := make([]int, 0, len(data))
+	for i, entry := range data {
+		if h, err := strconv.Atoi(strings.TrimSpace(entry.Time)); m.hideNight && err == nil && !m.hours.contains(h) {
+			continue
+		}
+		if level, err := strconv.Atoi(strings.TrimSpace(entry.PressureLevel)); m.minLevel > 0 && (err != nil || level < m.minLevel) {
+			continue
+		}
+		shown = append(shown, i)
+	}
+	m.sortRows(data, shown)
+	return shown
+}
+
+// sortRows sorts the indices of data shown by the sort of the table. Hours
+// without a pressure come last, and equal hours stay in time order.
+func (m model) sortRows(data []zutool.HourlyData, shown []int) {
+	switch m.sortBy {
+	case sortByPressure:
+		pressure := func(i int) (float64, bool) {
+			p, err := strconv.ParseFloat(strings.TrimSpace(data[i].Pressure), 64)
+			return p, err == nil
+		}
+		sort.SliceStable(shown, func(a, b int) bool {
+			pa, okA := pressure(shown[a])
+			pb, okB := pressure(shown[b])
+			if okA != okB {
+				return okA
+			}
+			return pa < pb
+		})
+	case sortByRisk:
+		deltas, deltaOK := pressureDeltas(m.pressureBefore(), data)
+		risks := riskScores(m.riskWeights, data, deltas, deltaOK)
+		sort.SliceStable(shown, func(a, b int) bool { return risks[shown[a]] > risks[shown[b]] })
+	}
+}
+
+// rowOf returns the table row of the hour at index i of data, or when it
+// is hidden, of the next hour shown.
+func (m model) rowOf(data []zutool.HourlyData, i int) int {
+	shown := m.shownRows(data)
+	row, next := -1, len(data)
+	for r, j := range shown {
+		if j >= i && j < next {
+			row, next = r, j
+		}
+	}
+	if row < 0 {
+		return max(len(shown)-1, 0)
+	}
+	return row
+}
+
+// refilter applies change to which hours the table shows, keeping the
+// selected hour selected, or the next one shown when it is now hidden.
+func (m model) refilter(change func(*model)) model {
+	_, data, _, _ := m.currentView()
+	selected := 0
+	if shown := m.shownRows(data); m.table.Cursor() >= 0 && m.table.Cursor() < len(shown) {
+		selected = shown[m.table.Cursor()]
+	}
+	change(&m)
+	m.syncContent()
+	m.selectRow(m.rowOf(data, selected))
+	return m
+}
+
+// toggleNight hides or shows the hours outside the hour range.
+func (m model) toggleNight() model {
+	return m.refilter(func(m *model) { m.hideNight = !m.hideNight })
+}
+
+// cycleLevelFilter moves on to the next of levelFilters.
+func (m model) cycleLevelFilter() model {
+	return m.refilter(func(m *model) {
+		next := 0
+		for i, level := range levelFilters {
+			if level == m.minLevel {
+				next = levelFilters[(i+1)%len(levelFilters)]
+			}
+		}
+		m.minLevel = next
+	})
+}
+
+// cycleSort moves on to the next order of the table.
+func (m model) cycleSort() model {
+	return m.refilter(func(m *model) { m.sortBy = (m.sortBy + 1) % (sortByRisk + 1) })
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"goHeadache/pkg/zutool"
+)
+
+// fixtureData is the forecast read by -fixture, shown for every area
+// instead of one fetched or cached; nil without -fixture.
+var fixtureData *zutool.WeatherData
+
+// loadFixture reads a forecast saved as zutool's getweatherstatus JSON,
+// from stdin when path is "-".
+func loadFixture(path string) (zutool.WeatherData, error) {
+	var raw []byte
+	var err error
+	if path == "-" {
+		path = "on stdin"
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return zutool.WeatherData{}, fmt.Errorf("error reading fixture: %v", err)
+	}
+	var data zutool.WeatherData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return zutool.WeatherData{}, fmt.Errorf("error parsing fixture %s: %v", path, err)
+	}
+	if len(data.Yesterday)+len(data.Today)+len(data.Tomorrow)+len(data.DayAfterTom) == 0 {
+		return zutool.WeatherData{}, fmt.Errorf("fixture %s has no hourly forecast", path)
+	}
+	return data, nil
+}
+
+// parseNow parses the time of -now, such as "2026-10-14T15:00" in local
+// time or an RFC 3339 time.
+func parseNow(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use YYYY-MM-DDTHH:MM, such as 2026-10-14T15:00)", s)
+}
+
+// setupFixture loads the forecast of -fixture, when set, and fixes the
+// clock at -now, or else at the hour the fixture was issued, so the same
+// files give the same screens.
+func setupFixture(path, now string) error {
+	var at time.Time
+	if now != "" {
+		t, err := parseNow(now)
+		if err != nil {
+			return usageError(err.Error())
+		}
+		at = t
+	}
+	if path != "" {
+		data, err := loadFixture(path)
+		if err != nil {
+			return err
+		}
+		fixtureData = &data
+		if issued, ok := issuedAt(data); ok && at.IsZero() {
+			at = issued
+		}
+	}
+	if !at.IsZero() {
+		clockNow = func() time.Time { return at }
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+
+	"goHeadache/pkg/zutool"
+)
+
+// barBlocks are the partial block characters used for the top cell of a
+// graph column, indexed by eighths of a cell.
+var barBlocks = []rune{' ', '▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+
+// graphAxisWidth is the width of the pressure labels left of the graph.
+const graphAxisWidth = 8
+
+// pressureValues returns the parsed pressures of data; ok is false for hours
+// without a value.
+func pressureValues(data []zutool.HourlyData) (values []float64, ok []bool) {
+	values = make([]float64, len(data))
+	ok = make([]bool, len(data))
+	for i, entry := range data {
+		p := strings.TrimSpace(entry.Pressure)
+		if p == "" || p == "#" {
+			continue
+		}
+		values[i] = parseFloat(p)
+		ok[i] = true
+	}
+	return values, ok
+}
+
+// renderPressureGraph draws the pressure of each hour as a block-character
+// column chart rows lines high and width cells wide, coloring every column
+// by its pressure level. The column of highlight is marked below the axis.
+func renderPressureGraph(data []zutool.HourlyData, width, rows, highlight int) string {
+	values, ok := pressureValues(data)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, v := range values {
+		if ok[i] {
+			lo = math.Min(lo, v)
+			hi = math.Max(hi, v)
+		}
+	}
+	if math.IsInf(lo, 1) {
+		return cellStyle.Render(tr("No pressure data"))
+	}
+	if hi-lo < 1 {
+		hi, lo = hi+0.5, lo-0.5
+	}
+	// Keep the lowest hour visible as a short bar instead of an empty column.
+	base := lo - (hi-lo)*0.15
+
+	colW := max((width-graphAxisWidth-1)/max(len(data), 1), 1)
+	barW := max(colW-1, 1)
+
+	var lines []string
+	for r := rows - 1; r >= 0; r-- {
+		var label string
+		switch r {
+		case rows - 1:
+			label = units.formatPressure(hi)
+		case 0:
+			label = units.formatPressure(lo)
+		}
+		var line strings.Builder
+		line.WriteString(fmt.Sprintf("%*s ┤", graphAxisWidth-2, label))
+		for i, v := range values {
+			cell := strings.Repeat(" ", barW)
+			if ok[i] {
+				eighths := int(math.Round((v - base) / (hi - base) * float64(rows*8)))
+				fill := min(max(eighths-r*8, 0), 8)
+				cell = levelStyle(data[i].PressureLevel).Render(strings.Repeat(string(barBlocks[fill]), barW))
+			}
+			line.WriteString(cell + strings.Repeat(" ", colW-barW))
+		}
+		lines = append(lines, line.String())
+	}
+
+	axis := strings.Repeat(" ", graphAxisWidth-1) + "└" + strings.Repeat("─", colW*len(data))
+	var labels strings.Builder
+	labels.WriteString(strings.Repeat(" ", graphAxisWidth))
+	col := 0
+	for i, entry := range data {
+		hour, _, _, _ := formatHourlyData(entry)
+		pos := i * colW
+		isHighlight := i == highlight
+		// Hour labels are two cells wide; skip the ones that would collide
+		// with the highlighted hour.
+		nearHighlight := highlight >= 0 && !isHighlight && abs(pos-highlight*colW) < 3
+		if (i%3 != 0 && !isHighlight) || nearHighlight || pos < col {
+			continue
+		}
+		labels.WriteString(strings.Repeat(" ", pos-col))
+		if isHighlight {
+			labels.WriteString(currentCellStyle.Padding(0).Render(hour[:2]))
+		} else {
+			labels.WriteString(hour[:2])
+		}
+		col = pos + 2
+	}
+	lines = append(lines, axis, labels.String())
+	return strings.Join(lines, "\n")
+}
+
+// pressureSparkline summarizes the day's pressure as one block character per
+// hour, scaled between the day's minimum and maximum, surrounded by the range
+// when it fits in width.
+func pressureSparkline(data []zutool.HourlyData, width int) string {
+	values, ok := pressureValues(data)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, v := range values {
+		if ok[i] {
+			lo = math.Min(lo, v)
+			hi = math.Max(hi, v)
+		}
+	}
+	if math.IsInf(lo, 1) {
+		return ""
+	}
+
+	var b strings.Builder
+	for i, v := range values {
+		if !ok[i] {
+			b.WriteRune(' ')
+			continue
+		}
+		level := 8
+		if hi > lo {
+			level = 1 + int(math.Round((v-lo)/(hi-lo)*7))
+		}
+		b.WriteString(levelStyle(data[i].PressureLevel).Render(string(barBlocks[level])))
+	}
+	line := fmt.Sprintf("%s %s %s %s", units.formatPressure(lo), b.String(), units.formatPressure(hi), units.pressure)
+	if lipgloss.Width(line) > width {
+		return ansi.Truncate(b.String(), width, "")
+	}
+	return line
+}
+
+// graphHeadersAndContent returns the day header and the pressure graph for
+// the day, sized to fill the space the table would use.
+func (m model) graphHeadersAndContent(dayName string, data []zutool.HourlyData, highlightRow int) (string, string) {
+	if len(data) == 0 {
+		return "", ""
+	}
+	tableWidth := m.contentWidth()
+	headers := dayHeaderStyle.Width(tableWidth).Render(ansi.Truncate(fmt.Sprintf("%s - %s", m.weatherData.PlaceName, tr(dayName)), tableWidth-4, "…"))
+	// Leave room below the bars for the axis and hour labels.
+	visibleHeight := contentHeight(m, lipgloss.Height(headers), math.MaxInt32)
+	return headers, renderPressureGraph(data, tableWidth, max(visibleHeight-2, 2), highlightRow)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
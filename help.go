@@ -0,0 +1,44 @@
+package main
+
+import "charm.land/lipgloss/v2"
+
+// helpBox renders the help screen: the key bindings, the area shown and
+// where the data comes from.
+func (m model) helpBox() string {
+	text := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Text))
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+
+	keys := m.screenKeys().helpRows()
+	keyW := 0
+	for _, k := range keys {
+		keyW = max(keyW, lipgloss.Width(k[0]))
+	}
+	rows := make([]string, len(keys))
+	for i, k := range keys {
+		rows[i] = text.Bold(true).Width(keyW+2).Render(k[0]) + text.Render(k[1])
+	}
+	table := lipgloss.JoinVertical(lipgloss.Left, rows...)
+	// The rest of the box takes 11 lines; use two columns when one would not
+	// fit on the screen and there is room for them.
+	if half := (len(rows) + 1) / 2; len(rows)+11 > m.height && m.width >= 100 {
+		left := lipgloss.JoinVertical(lipgloss.Left, rows[:half]...)
+		right := lipgloss.JoinVertical(lipgloss.Left, rows[half:]...)
+		table = lipgloss.JoinHorizontal(lipgloss.Top, left, "    ", right)
+	}
+
+	area := m.areaCode
+	if m.weatherData.PlaceName != "" {
+		area += " (" + m.weatherData.PlaceName + ")"
+	}
+	lines := []string{
+		dayHeaderStyle.MarginTop(0).Render("goHeadache " + appVersion()),
+		"",
+		table,
+		"",
+		text.Render(tr("Area: ") + area),
+		text.Render(tr("Data: ") + weatherSource.Credit()),
+		"",
+		muted.Render(helpPopupKeys.line()),
+	}
+	return popupStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"goHeadache/pkg/zutool"
+)
+
+// HistoryConfig is the [history] section of the config file: where every
+// fetched forecast is recorded for trends, accuracy and the diary.
+type HistoryConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Retention is how long records are kept, such as "180d"; "0" keeps
+	// them forever.
+	Retention string `toml:"retention"`
+	// Observed also records the values of the yesterday of each forecast,
+	// which are what was measured rather than forecast.
+	Observed bool `toml:"observed"`
+	// Path is the history directory, by default goHeadache/history in the
+	// user data directory.
+	Path string `toml:"path"`
+}
+
+var defaultHistoryConfig = HistoryConfig{Enabled: true, Retention: "180d", Observed: true}
+
+// history records fetched forecasts; nil records nothing.
+var history *historyStore
+
+// historyStore keeps the forecasts of every area as JSON lines, one file per
+// area and day of fetching: <dir>/<area code>/2006-01-02.jsonl.
+type historyStore struct {
+	dir       string
+	retention time.Duration // zero keeps everything
+	observed  bool
+	// mu serializes the writes of the goroutines of serve, daemon, watch
+	// and batch, so that two of them cannot both find a forecast missing
+	// and record it twice.
+	mu sync.Mutex
+}
+
+// historyRecord is a forecast as fetched.
+type historyRecord struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	// Issued is the time the source gives for the forecast, used to skip
+	// recording the same forecast twice.
+	Issued string `json:"issued,omitempty"`
+	Source string `json:"source"`
+	Place  string `json:"place"`
+	// Forecast are the hours from the day of fetching on, Observed those
+	// of the day before.
+	Forecast []historyHour `json:"forecast"`
+	Observed []historyHour `json:"observed,omitempty"`
+}
+
+// historyHour is an hour of a record. Values the source lacked are null.
+type historyHour struct {
+	Time     time.Time `json:"time"`
+	Weather  string    `json:"weather,omitempty"`
+	Temp     *float64  `json:"temp"`
+	Pressure *float64  `json:"pressure"`
+	Level    *int      `json:"level"`
+}
+
+// hourlyData converts h back into an hour of a forecast, with missing
+// values as the API sends them.
+func (h historyHour) hourlyData() zutool.HourlyData {
+	entry := zutool.HourlyData{Time: strconv.Itoa(h.Time.Hour()), Weather: h.Weather, Temp: "#", Pressure: "#"}
+	if h.Temp != nil {
+		entry.Temp = strconv.FormatFloat(*h.Temp, 'f', -1, 64)
+	}
+	if h.Pressure != nil {
+		entry.Pressure = strconv.FormatFloat(*h.Pressure, 'f', -1, 64)
+	}
+	if h.Level != nil {
+		entry.PressureLevel = strconv.Itoa(*h.Level)
+	}
+	return entry
+}
+
+// setupHistory opens the history of cfg, or disables it.
+func setupHistory(cfg HistoryConfig) error {
+	history = nil
+	if !cfg.Enabled {
+		return nil
+	}
+	retention, err := parseDays(cfg.Retention)
+	if err != nil {
+		return fmt.Errorf("invalid retention %q under [history] (use a number of days such as \"180d\", or \"0\" to keep everything)", cfg.Retention)
+	}
+	dir := cfg.Path
+	if dir == "" {
+		data, err := userDataDir()
+		if err != nil {
+			return err
+		}
+		dir = filepath.Join(data, "goHeadache", "history")
+	}
+	history = &historyStore{dir: dir, retention: retention, observed: cfg.Observed}
+	return nil
+}
+
+// parseDays parses a duration that may also be a number of days, such as
+// "30d", or of weeks, such as "2w". "0" and "" are zero.
+func parseDays(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			days, err := strconv.Atoi(n)
+			if err != nil || days < 0 {
+				return 0, fmt.Errorf("invalid number of days %q", s)
+			}
+			return time.Duration(days) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// userDataDir returns the directory for user data that should outlive the
+// cache: $XDG_DATA_HOME, else ~/.local/share, Application Support on macOS
+// and %LocalAppData% on Windows.
+func userDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+		return "", errors.New("error locating data directory: %LocalAppData% is not set")
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error locating data directory: %v", err)
+		}
+		return filepath.Join(home, "Library", "Application Support"), nil
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating data directory: %v", err)
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// newHistoryRecord returns the record of data fetched at fetchedAt.
+func newHistoryRecord(data zutool.WeatherData, source string, fetchedAt time.Time, observed bool) historyRecord {
+	r := historyRecord{FetchedAt: fetchedAt, Issued: data.DateTime, Source: source, Place: data.PlaceName}
+	all, _ := forecastHours(data, fetchedAt)
+	for _, u := range all {
+		h := historyHour{Time: u.at, Weather: strings.TrimSpace(u.entry.Weather)}
+		if t, err := strconv.ParseFloat(strings.TrimSpace(u.entry.Temp), 64); err == nil {
+			h.Temp = &t
+		}
+		if p, ok := hourPressure(u); ok {
+			h.Pressure = &p
+		}
+		if l, err := strconv.Atoi(strings.TrimSpace(u.entry.PressureLevel)); err == nil {
+			h.Level = &l
+		}
+		switch {
+		case !u.at.Before(midnight(fetchedAt)):
+			r.Forecast = append(r.Forecast, h)
+		case observed:
+			r.Observed = append(r.Observed, h)
+		}
+	}
+	return r
+}
+
+func (s *historyStore) areaDir(areaCode string) string {
+	return filepath.Join(s.dir, filepath.Base(areaCode))
+}
+
+// dayFile returns the file of the records of areaCode fetched on day.
+func (s *historyStore) dayFile(areaCode string, day time.Time) string {
+	return filepath.Join(s.areaDir(areaCode), day.Format(time.DateOnly)+".jsonl")
+}
+
+// record appends the forecast of areaCode fetched at fetchedAt, unless it
+// is the same forecast as the last one recorded. A new day's file prunes
+// what is past the retention. It is safe for concurrent use.
+func (s *historyStore) record(areaCode string, data zutool.WeatherData, source string, fetchedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir := s.areaDir(areaCode)
+	path := s.dayFile(areaCode, fetchedAt)
+	existing, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if err := s.prune(areaCode, fetchedAt); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case data.DateTime != "":
+		lines := bytes.Split(bytes.TrimSpace(existing), []byte("\n"))
+		var last historyRecord
+		if json.Unmarshal(lines[len(lines)-1], &last) == nil && last.Issued == data.DateTime && last.Source == source {
+			return nil
+		}
+	}
+	line, err := json.Marshal(newHistoryRecord(data, source, fetchedAt, s.observed))
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// prune removes the files of areaCode older than the retention.
+func (s *historyStore) prune(areaCode string, now time.Time) error {
+	if s.retention == 0 {
+		return nil
+	}
+	days, err := s.days(areaCode)
+	if err != nil {
+		return err
+	}
+	cutoff := midnight(now.Add(-s.retention))
+	for _, day := range days {
+		if day.Before(cutoff) {
+			if err := os.Remove(s.dayFile(areaCode, day)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// days returns the days with records of areaCode, oldest first.
+func (s *historyStore) days(areaCode string) ([]time.Time, error) {
+	entries, err := os.ReadDir(s.areaDir(areaCode))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var days []time.Time
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".jsonl")
+		if !ok {
+			continue
+		}
+		if day, err := time.ParseInLocation(time.DateOnly, name, time.Local); err == nil {
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days, nil
+}
+
+// records returns the records of areaCode fetched from from until to,
+// oldest first.
+func (s *historyStore) records(areaCode string, from, to time.Time) ([]historyRecord, error) {
+	days, err := s.days(areaCode)
+	if err != nil {
+		return nil, err
+	}
+	var out []historyRecord
+	for _, day := range days {
+		if day.Before(midnight(from)) || day.After(to) {
+			continue
+		}
+		recs, err := readRecordFile(s.dayFile(areaCode, day))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range recs {
+			if !r.FetchedAt.Before(from) && !r.FetchedAt.After(to) {
+				out = append(out, r)
+			}
+		}
+	}
+	return out, nil
+}
+
+// readRecordFile reads the records of a day file. Lines that cannot be
+// parsed, such as one cut short by a crash, are skipped.
+func readRecordFile(path string) ([]historyRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []historyRecord
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var r historyRecord
+		if json.Unmarshal(sc.Bytes(), &r) == nil {
+			out = append(out, r)
+		}
+	}
+	return out, sc.Err()
+}
+
+// recordHistory records a fetched forecast when history is enabled. Like
+// the cache it only adds value, so failing to write it is not an error.
+func recordHistory(areaCode string, data zutool.WeatherData, fetchedAt time.Time) {
+	if history == nil {
+		return
+	}
+	_ = history.record(areaCode, data, weatherSource.Name(), fetchedAt)
+}
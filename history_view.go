@@ -0,0 +1,265 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+
+	"goHeadache/pkg/zutool"
+)
+
+// historyDay is a past day of the history: the measured hours where they
+// were recorded, else the last forecast for them.
+type historyDay struct {
+	day      time.Time
+	place    string
+	hours    []historyHour
+	observed int // how many of hours were measured
+}
+
+// recordedHour is an hour of the history and whether it was measured.
+type recordedHour struct {
+	historyHour
+	observed bool
+	place    string
+}
+
+// recordedHours merges the hours of records by time, in UTC so that equal
+// times are equal keys. The newest value of each hour wins, and a measured
+// one always beats a forecast.
+func recordedHours(records []historyRecord) map[time.Time]recordedHour {
+	hours := map[time.Time]recordedHour{}
+	add := func(place string, h historyHour, observed bool) {
+		at := h.Time.UTC()
+		if old, ok := hours[at]; ok && old.observed && !observed {
+			return
+		}
+		hours[at] = recordedHour{h, observed, place}
+	}
+	for _, r := range records {
+		for _, h := range r.Forecast {
+			add(r.Place, h, false)
+		}
+		for _, h := range r.Observed {
+			add(r.Place, h, true)
+		}
+	}
+	return hours
+}
+
+// pastHistoryDays returns the days before today that records cover, oldest
+// first, with the hours of recordedHours.
+func pastHistoryDays(records []historyRecord, today time.Time) []historyDay {
+	days := map[time.Time]*historyDay{}
+	for _, h := range recordedHours(records) {
+		day := midnight(h.Time)
+		if !day.Before(today) {
+			continue
+		}
+		d := days[day]
+		if d == nil {
+			d = &historyDay{day: day}
+			days[day] = d
+		}
+		d.place = h.place
+		d.hours = append(d.hours, h.historyHour)
+		if h.observed {
+			d.observed++
+		}
+	}
+	out := make([]historyDay, 0, len(days))
+	for _, d := range days {
+		sort.Slice(d.hours, func(i, j int) bool { return d.hours[i].Time.Before(d.hours[j].Time) })
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].day.Before(out[j].day) })
+	return out
+}
+
+// hourlyData converts the hours of d for the graph and sparkline.
+func (d historyDay) hourlyData() []zutool.HourlyData {
+	data := make([]zutool.HourlyData, len(d.hours))
+	for i, h := range d.hours {
+		data[i] = h.hourlyData()
+	}
+	return data
+}
+
+// source says whether the values of d were measured or forecast.
+func (d historyDay) source() string {
+	switch d.observed {
+	case 0:
+		return tr("as forecast")
+	case len(d.hours):
+		return tr("measured")
+	}
+	return tr("partly measured")
+}
+
+// pressureRange returns the lowest and highest pressure of d.
+func (d historyDay) pressureRange() (lo, hi float64, ok bool) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, h := range d.hours {
+		if h.Pressure != nil {
+			lo, hi = math.Min(lo, *h.Pressure), math.Max(hi, *h.Pressure)
+		}
+	}
+	return lo, hi, !math.IsInf(lo, 1)
+}
+
+// largestDrop returns the largest fall of the pressure within an hour of
+// d, and the hour it fell to; drop is zero when it never fell.
+func (d historyDay) largestDrop() (drop float64, at time.Time) {
+	for i := 1; i < len(d.hours); i++ {
+		if fall, ok := hourlyFall(d.hours[i-1], d.hours[i]); ok && fall > drop {
+			drop, at = fall, d.hours[i].Time
+		}
+	}
+	return drop, at
+}
+
+// hourlyFall returns how much the pressure fell from prev to h, an hour
+// later.
+func hourlyFall(prev, h historyHour) (float64, bool) {
+	if prev.Pressure == nil || h.Pressure == nil || !prev.Time.Add(time.Hour).Equal(h.Time) {
+		return 0, false
+	}
+	return *prev.Pressure - *h.Pressure, true
+}
+
+// summary gives the range of the pressure and its largest fall within an
+// hour, such as "1003.2–1011.8 hPa · -1.4 hPa/h at 14:00".
+func (d historyDay) summary() string {
+	lo, hi, ok := d.pressureRange()
+	if !ok {
+		return tr("no pressure data")
+	}
+	s := units.formatRange(lo, hi)
+	if drop, at := d.largestDrop(); drop > 0 {
+		s += trf(" · %s at %s", units.formatDropRate(drop), formatClock(at))
+	}
+	return s
+}
+
+// historyLoadedMsg carries the past days of areaCode read from the history.
+type historyLoadedMsg struct {
+	areaCode string
+	days     []historyDay
+	err      error
+}
+
+// errHistoryDisabled is shown by the history view when [history] is off.
+var errHistoryDisabled = errors.New("the history is disabled (set enabled = true under [history] in the config file)")
+
+// loadHistoryCmd reads the recorded days of areaCode.
+func loadHistoryCmd(areaCode string) tea.Cmd {
+	return func() tea.Msg {
+		if history == nil {
+			return historyLoadedMsg{areaCode: areaCode, err: errHistoryDisabled}
+		}
+		records, err := history.records(areaCode, time.Time{}, time.Now())
+		if err != nil {
+			return historyLoadedMsg{areaCode: areaCode, err: fmt.Errorf("error reading the history: %v", err)}
+		}
+		return historyLoadedMsg{areaCode: areaCode, days: pastHistoryDays(records, midnight(time.Now()))}
+	}
+}
+
+// openHistory switches to the history view and loads it for the current
+// area.
+func (m model) openHistory() (model, tea.Cmd) {
+	m.historyMode = true
+	m.historyDays, m.historyErr = nil, nil
+	m.historyLoading = true
+	m.viewport.GotoTop()
+	return m, loadHistoryCmd(m.areaCode)
+}
+
+// historyLoaded shows the newest recorded day once the history is read.
+func (m model) historyLoaded(msg historyLoadedMsg) model {
+	if !m.historyMode || msg.areaCode != m.areaCode {
+		return m
+	}
+	m.historyLoading = false
+	m.historyDays, m.historyErr = msg.days, msg.err
+	m.historyIndex = len(m.historyDays) - 1
+	return m
+}
+
+// updateHistory handles the keys of the history view: left and right page
+// through the days, home and end jump to the oldest and newest, and gg and G
+// scroll to the top and bottom.
+func (m model) updateHistory(msg tea.KeyPressMsg) (model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m.quit()
+	case "H", "esc":
+		m.historyMode = false
+		m.viewport.GotoTop()
+	case "?":
+		m.help = true
+	case "left", "h":
+		if m.historyIndex > 0 {
+			m.historyIndex--
+		}
+	case "right", "l":
+		if m.historyIndex < len(m.historyDays)-1 {
+			m.historyIndex++
+		}
+	case "home":
+		m.historyIndex = 0
+	case "end":
+		m.historyIndex = len(m.historyDays) - 1
+	case "g":
+		return m.startSequence("g")
+	case "G":
+		m.viewport.GotoBottom()
+	case "alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9":
+		return withHistory(m.switchLocation(int(msg.String()[len("alt+")] - '1')))
+	case ":", "/":
+		return m.openPrompt()
+	default:
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// withHistory follows a switch to another area in the history view, fetch
+// being the fetch of its forecast, by loading its history too.
+func withHistory(m model, fetch tea.Cmd) (model, tea.Cmd) {
+	if fetch == nil {
+		return m, nil
+	}
+	m, load := m.openHistory()
+	return m, tea.Batch(fetch, load)
+}
+
+// historyBody renders the header and pressure graph of the history day
+// shown.
+func (m model) historyBody() (string, string) {
+	width := m.contentWidth()
+	switch {
+	case m.historyLoading:
+		return "", loadingStyle.Render(tr("Reading the history..."))
+	case m.historyErr != nil:
+		return "", errorStyle.Render(ansi.Wrap(trError(m.historyErr), width, ""))
+	case len(m.historyDays) == 0:
+		return "", cellStyle.Render(ansi.Wrap(tr("No past days are recorded for this area yet. Forecasts are recorded as they are fetched; come back tomorrow."), width, ""))
+	}
+	d := m.historyDays[m.historyIndex]
+	title := fmt.Sprintf("%s - %s (%s)", d.place, d.day.Format(tr("Mon 2 Jan 2006")), d.source())
+	header := dayHeaderStyle.Width(width).Render(ansi.Truncate(title, width-4, "…"))
+	position := trf("Day %d of %d · %s", m.historyIndex+1, len(m.historyDays), d.summary())
+	header += "\n" + sparklineStyle.Width(width).Render(ansi.Truncate(position, width, "…"))
+	// Leave room below the bars for the axis and hour labels.
+	visibleHeight := contentHeight(m, lipgloss.Height(header), math.MaxInt32)
+	return header, renderPressureGraph(d.hourlyData(), width, max(visibleHeight-2, 2), -1)
+}
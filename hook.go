@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// HookConfig is a [[hooks]] entry of the config file: a command run for
+// every alert.
+type HookConfig struct {
+	// Name identifies the hook in log messages.
+	Name string `toml:"name"`
+	// Command is the program and its arguments. It is not run through a
+	// shell; use ["sh", "-c", "..."] for one.
+	Command []string `toml:"command"`
+	// Timeout kills the command after this duration, such as "30s". It
+	// defaults to defaultHookTimeout.
+	Timeout string `toml:"timeout"`
+}
+
+const defaultHookTimeout = 30 * time.Second
+
+// hookNotifier runs a command for each alert, with the alert in its
+// environment and as JSON on stdin.
+type hookNotifier struct {
+	cfg     HookConfig
+	timeout time.Duration
+}
+
+// newHookNotifier checks cfg and returns its notifier.
+func newHookNotifier(cfg HookConfig) (*hookNotifier, error) {
+	if cfg.Name == "" {
+		cfg.Name = "hook"
+	}
+	if len(cfg.Command) == 0 || cfg.Command[0] == "" {
+		return nil, fmt.Errorf("hook %s: command is empty", cfg.Name)
+	}
+	timeout := defaultHookTimeout
+	if cfg.Timeout != "" {
+		d, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("hook %s: invalid timeout %q (use a duration such as \"30s\")", cfg.Name, cfg.Timeout)
+		}
+		timeout = d
+	}
+	return &hookNotifier{cfg: cfg, timeout: timeout}, nil
+}
+
+func (n *hookNotifier) name() string { return "hook " + n.cfg.Name }
+
+// alertEnv returns the environment variables describing a, such as
+// GOHEADACHE_PLACE=千代田区.
+func alertEnv(a alert) []string {
+	data := newAlertTemplateData(a)
+	return []string{
+		"GOHEADACHE_AREA_CODE=" + a.areaCode,
+		"GOHEADACHE_PLACE=" + a.placeName,
+		"GOHEADACHE_RULE=" + a.rule,
+		"GOHEADACHE_SEVERITY=" + a.severity,
+		"GOHEADACHE_TIME=" + a.warning.at.Format(time.RFC3339),
+		"GOHEADACHE_UNTIL=" + formatTime(a.warning.until),
+		fmt.Sprintf("GOHEADACHE_LEVEL=%d", data.Level),
+		"GOHEADACHE_LEVEL_NAME=" + data.LevelName,
+		fmt.Sprintf("GOHEADACHE_DROP=%.1f", data.Drop),
+		"GOHEADACHE_PRESSURE=" + data.Pressure,
+		"GOHEADACHE_TITLE=" + data.Title,
+		"GOHEADACHE_MESSAGE=" + data.Message,
+	}
+}
+
+func (n *hookNotifier) preview(a alert) string {
+	stdin, err := alertJSON(a, "")
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("run %q with %s on stdin", n.cfg.Command, stdin)
+}
+
+// send runs the command and waits for it. A non-zero exit is an error that
+// includes the end of its output.
+func (n *hookNotifier) send(ctx context.Context, a alert) error {
+	stdin, err := alertJSON(a, "")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, n.cfg.Command[0], n.cfg.Command[1:]...)
+	cmd.Env = append(os.Environ(), alertEnv(a)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", n.timeout)
+	}
+	if err != nil {
+		if msg := lastLine(string(out)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// lastLine returns the last non-empty line of s, which is usually where a
+// command says why it failed.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
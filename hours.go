@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// hourRange is a range of hours of the day, from and to included. It wraps
+// past midnight when from is after to, as in 22-6.
+type hourRange struct{ from, to int }
+
+// wakingHours is the range n hides the night with when no other is set.
+var wakingHours = hourRange{7, 23}
+
+// parseHourRange parses a range of hours such as "7-23".
+func parseHourRange(s string) (hourRange, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if ok {
+		r := hourRange{-1, -1}
+		var errFrom, errTo error
+		r.from, errFrom = strconv.Atoi(strings.TrimSpace(from))
+		r.to, errTo = strconv.Atoi(strings.TrimSpace(to))
+		if errFrom == nil && errTo == nil && r.from >= 0 && r.from <= 23 && r.to >= 0 && r.to <= 23 {
+			return r, nil
+		}
+	}
+	return hourRange{}, fmt.Errorf("invalid hour range %q (use from-to with hours 0 to 23, such as 7-23)", s)
+}
+
+// contains reports whether hour h is in r.
+func (r hourRange) contains(h int) bool {
+	if r.from <= r.to {
+		return h >= r.from && h <= r.to
+	}
+	return h >= r.from || h <= r.to
+}
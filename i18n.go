@@ -0,0 +1,168 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"goHeadache/pkg/zutool"
+)
+
+// localeFiles are the message catalogs, one per language besides English.
+// They map the English text of a message, as written in the code, to its
+// translation: [messages] the text of the UI, and [errors] error messages,
+// whose verbs such as %s also match the values of an error.
+//
+//go:embed locales/*.toml
+var localeFiles embed.FS
+
+// languages are the languages of the UI.
+var languages = []string{"en", "ja", "zh"}
+
+// lang is the language selected by setupLang.
+var lang = "en"
+
+// catalog is the message catalog of lang, empty for English.
+var catalog struct {
+	Messages map[string]string `toml:"messages"`
+	Errors   map[string]string `toml:"errors"`
+	// patterns match the [errors] messages with verbs.
+	patterns []errorPattern
+}
+
+// errorPattern matches an error message with values, such as "area code
+// %s not found", and formats its translation with them.
+type errorPattern struct {
+	re          *regexp.Regexp
+	translation string
+	length      int // of the message, for trying the longest first
+}
+
+// resolveLang returns the language selected by name; auto picks it from
+// LC_ALL, LC_MESSAGES or LANG, else English.
+func resolveLang(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == "auto" {
+		name = "en"
+		for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+			if env := os.Getenv(v); env != "" {
+				name = strings.ToLower(env)
+				break
+			}
+		}
+		for _, l := range languages {
+			if strings.HasPrefix(name, l) {
+				return l, nil
+			}
+		}
+		return "en", nil
+	}
+	for _, l := range languages {
+		if name == l {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("unknown language %q (use auto, %s)", name, strings.Join(languages, ", "))
+}
+
+var verbPattern = regexp.MustCompile(`%(\[\d+\])?[-+# 0-9.]*[a-z]`)
+
+// setupLang selects the language of the config file, overridden by
+// flagValue, and loads its catalog.
+func setupLang(cfg Config, flagValue string) error {
+	name := cfg.Lang
+	if flagValue != "" {
+		name = flagValue
+	}
+	l, err := resolveLang(name)
+	if err != nil {
+		return err
+	}
+	lang = l
+	catalog.Messages, catalog.Errors, catalog.patterns = nil, nil, nil
+	if l == "en" {
+		return nil
+	}
+	data, err := localeFiles.ReadFile("locales/" + l + ".toml")
+	if err != nil {
+		return err
+	}
+	if err := toml.Unmarshal(data, &catalog); err != nil {
+		panic("invalid embedded catalog " + l + ": " + err.Error())
+	}
+	for msg, translation := range catalog.Errors {
+		if !verbPattern.MatchString(msg) {
+			continue
+		}
+		var re strings.Builder
+		re.WriteString("^")
+		last := 0
+		for _, loc := range verbPattern.FindAllStringIndex(msg, -1) {
+			re.WriteString(regexp.QuoteMeta(msg[last:loc[0]]) + "(.+?)")
+			last = loc[1]
+		}
+		re.WriteString(regexp.QuoteMeta(msg[last:]) + "$")
+		// The values are matched as text, so they are formatted as such.
+		translation = verbPattern.ReplaceAllStringFunc(translation, func(v string) string {
+			if i := strings.Index(v, "]"); i >= 0 {
+				return v[:i+1] + "s"
+			}
+			return "%s"
+		})
+		catalog.patterns = append(catalog.patterns, errorPattern{regexp.MustCompile(re.String()), translation, len(msg)})
+	}
+	// Try the longest messages first, as they are the most specific.
+	sort.Slice(catalog.patterns, func(i, j int) bool { return catalog.patterns[i].length > catalog.patterns[j].length })
+	return nil
+}
+
+// tr translates a message of the UI, or returns it as it is.
+func tr(msg string) string {
+	if t, ok := catalog.Messages[msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// trf formats the translation of format.
+func trf(format string, args ...any) string {
+	return fmt.Sprintf(tr(format), args...)
+}
+
+// trError translates the message of err, or returns it as it is.
+func trError(err error) string {
+	return translateError(err.Error())
+}
+
+// translateError translates an error message, and the values of a message
+// with verbs so that wrapped errors are translated too.
+func translateError(msg string) string {
+	if t, ok := catalog.Errors[msg]; ok {
+		return t
+	}
+	for _, p := range catalog.patterns {
+		m := p.re.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		args := make([]any, len(m)-1)
+		for i, v := range m[1:] {
+			args[i] = translateError(v)
+		}
+		return fmt.Sprintf(p.translation, args...)
+	}
+	return msg
+}
+
+// weatherDescription describes a weather code in the language of the UI.
+func weatherDescription(code string) string {
+	if wc, ok := zutool.LookupWeatherCode(code); ok && lang == "ja" {
+		return wc.Ja
+	}
+	return tr(translateWeatherCode(code))
+}
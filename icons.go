@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"goHeadache/pkg/zutool"
+)
+
+// weatherKind groups weather codes for picking an icon.
+type weatherKind int
+
+const (
+	kindUnknown weatherKind = iota
+	kindClear
+	kindPartlyCloudy
+	kindCloudy
+	kindRain
+	kindSnow
+	kindThunder
+	kindFog
+)
+
+// iconSets maps an -icons value to the icon of each weatherKind. Emoji use
+// the emoji presentation selector so they are consistently two cells wide;
+// the nerd set needs a Nerd Font patched terminal font.
+var iconSets = map[string]map[weatherKind]string{
+	"emoji": {
+		kindUnknown:      "❔",
+		kindClear:        "☀️",
+		kindPartlyCloudy: "🌤️",
+		kindCloudy:       "☁️",
+		kindRain:         "🌧️",
+		kindSnow:         "❄️",
+		kindThunder:      "⛈️",
+		kindFog:          "🌫️",
+	},
+	"nerd": {
+		kindUnknown:      "",
+		kindClear:        "",
+		kindPartlyCloudy: "",
+		kindCloudy:       "",
+		kindRain:         "",
+		kindSnow:         "",
+		kindThunder:      "",
+		kindFog:          "",
+	},
+	"ascii": {
+		kindUnknown:      "??",
+		kindClear:        "*",
+		kindPartlyCloudy: "*~",
+		kindCloudy:       "~~",
+		kindRain:         "//",
+		kindSnow:         "**",
+		kindThunder:      "/!",
+		kindFog:          "==",
+	},
+}
+
+// validateIconSet checks an -icons value; "none" and "" disable icons.
+func validateIconSet(name string) error {
+	if name == "" || name == "none" {
+		return nil
+	}
+	if _, ok := iconSets[name]; !ok {
+		return fmt.Errorf("unknown icon set %q (use none, emoji, nerd or ascii)", name)
+	}
+	return nil
+}
+
+// classifyWeather returns the kind of a weather code from its first digit
+// and, for the mixed codes, its Japanese description.
+func classifyWeather(code string) weatherKind {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return kindUnknown
+	}
+	wc, _ := zutool.LookupWeatherCode(code)
+	switch {
+	case strings.Contains(wc.Ja, "雷"):
+		return kindThunder
+	case strings.HasPrefix(wc.Ja, "霧") || strings.HasPrefix(wc.Ja, "朝の内霧"):
+		return kindFog
+	}
+	switch code[0] {
+	case '1':
+		if strings.Contains(wc.Ja, "曇") {
+			return kindPartlyCloudy
+		}
+		return kindClear
+	case '2':
+		if strings.Contains(wc.Ja, "晴") {
+			return kindPartlyCloudy
+		}
+		return kindCloudy
+	case '3':
+		return kindRain
+	case '4':
+		return kindSnow
+	}
+	return kindUnknown
+}
+
+// weatherLabel returns the description of a weather code, prefixed with
+// its icon from the selected icon set.
+func weatherLabel(code, iconSet string) string {
+	text := weatherDescription(code)
+	icons, ok := iconSets[iconSet]
+	if !ok {
+		return text
+	}
+	if _, known := zutool.LookupWeatherCode(code); !known {
+		// translateWeatherCode already prefixed a fallback symbol.
+		text = strings.TrimSpace(code)
+	}
+	return icons[classifyWeather(code)] + " " + text
+}
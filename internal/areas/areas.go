@@ -0,0 +1,198 @@
+// Package areas is a compact offline database of area codes accepted by the
+// zutool API. It covers Tokyo's wards and cities, designated cities and their
+// wards, prefectural capitals and other major cities; codes outside the
+// dataset may still be valid.
+package areas
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed areas.csv
+var areasCSV string
+
+// Area is a municipality or ward with its five-digit JIS area code.
+type Area struct {
+	Code   string
+	Name   string
+	NameEn string
+	Lat    float64
+	Lon    float64
+}
+
+// PrefectureCode returns the two-digit prefecture part of the area code.
+func (a Area) PrefectureCode() string {
+	return a.Code[:2]
+}
+
+// FullName returns the area name prefixed with its prefecture, e.g. "東京都千代田区".
+func (a Area) FullName() string {
+	if p, ok := LookupPrefecture(a.PrefectureCode()); ok {
+		return p.Name + a.Name
+	}
+	return a.Name
+}
+
+var (
+	loadOnce sync.Once
+	all      []Area
+	byCode   map[string]Area
+)
+
+func load() {
+	records, err := csv.NewReader(strings.NewReader(areasCSV)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("areas: invalid embedded dataset: %v", err))
+	}
+	byCode = make(map[string]Area, len(records))
+	for _, rec := range records[1:] {
+		lat, _ := strconv.ParseFloat(rec[3], 64)
+		lon, _ := strconv.ParseFloat(rec[4], 64)
+		a := Area{Code: rec[0], Name: rec[1], NameEn: rec[2], Lat: lat, Lon: lon}
+		all = append(all, a)
+		byCode[a.Code] = a
+	}
+}
+
+// All returns every area in the dataset ordered by code.
+func All() []Area {
+	loadOnce.Do(load)
+	return all
+}
+
+// Lookup returns the area with the given code.
+func Lookup(code string) (Area, bool) {
+	loadOnce.Do(load)
+	a, ok := byCode[code]
+	return a, ok
+}
+
+// InPrefecture returns the areas whose code starts with the prefecture code.
+func InPrefecture(prefCode string) []Area {
+	var result []Area
+	for _, a := range All() {
+		if a.PrefectureCode() == prefCode {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// Search returns the areas whose Japanese name, English name or code
+// contains keyword, ignoring case. A prefecture name or English prefecture
+// name matches every area in that prefecture.
+func Search(keyword string) []Area {
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	if keyword == "" {
+		return nil
+	}
+	var result []Area
+	for _, a := range All() {
+		p, _ := LookupPrefecture(a.PrefectureCode())
+		if strings.Contains(a.FullName(), keyword) ||
+			strings.Contains(strings.ToLower(a.NameEn), keyword) ||
+			strings.Contains(strings.ToLower(p.NameEn), keyword) ||
+			strings.HasPrefix(a.Code, keyword) {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// Nearest returns the area of the dataset closest to lat, lon and how far
+// away it is in kilometers.
+func Nearest(lat, lon float64) (Area, float64) {
+	var best Area
+	bestDist := math.Inf(1)
+	for _, a := range All() {
+		d := a.DistanceTo(lat, lon)
+		if d < bestDist {
+			best, bestDist = a, d
+		}
+	}
+	return best, bestDist
+}
+
+// DistanceTo returns the distance in kilometers from a to lat, lon, on the
+// sphere: close enough for places within Japan.
+func (a Area) DistanceTo(lat, lon float64) float64 {
+	const earthRadius = 6371.0
+	rad := math.Pi / 180
+	dlat, dlon := (lat-a.Lat)*rad, (lon-a.Lon)*rad
+	h := math.Sin(dlat/2)*math.Sin(dlat/2) + math.Cos(a.Lat*rad)*math.Cos(lat*rad)*math.Sin(dlon/2)*math.Sin(dlon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// Normalize returns the five-digit area code of code, written as the
+// five-digit JIS code (13101), the six-digit code ending in its check digit
+// (131016) or without the leading zero lost to spreadsheets and integer
+// parsing (1101 for 01101), in full-width digits too. The code is then
+// validated as by Validate.
+func Normalize(code string) (string, error) {
+	var digits []byte
+	for _, r := range strings.TrimSpace(code) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, byte(r))
+		case r >= '０' && r <= '９':
+			digits = append(digits, byte('0'+r-'０'))
+		default:
+			return "", formatError(code)
+		}
+	}
+	switch len(digits) {
+	case 4:
+		digits = append([]byte{'0'}, digits...)
+	case 5:
+	case 6:
+		if want := checkDigit(string(digits[:5])); digits[5] != want {
+			return "", fmt.Errorf("wrong check digit in area code %s (%s%c would be right)", code, digits[:5], want)
+		}
+		digits = digits[:5]
+	default:
+		return "", formatError(code)
+	}
+	normalized := string(digits)
+	if err := Validate(normalized); err != nil {
+		return "", err
+	}
+	return normalized, nil
+}
+
+// formatError reports an area code in none of the formats Normalize takes.
+func formatError(code string) error {
+	return fmt.Errorf("invalid area code %q (use the five-digit JIS code such as 13101, the six-digit code with its check digit such as 131016, or 1101 for 01101)", code)
+}
+
+// checkDigit returns the check digit of a five-digit area code: 11 less
+// the sum of its digits weighted 6 to 2, modulo 11, keeping the last digit.
+func checkDigit(code string) byte {
+	sum := 0
+	for i := range 5 {
+		sum += int(code[i]-'0') * (6 - i)
+	}
+	return byte('0' + (11-sum%11)%10)
+}
+
+// Validate checks that code is a five-digit area code inside a known
+// prefecture. It does not require the code to be in the dataset.
+func Validate(code string) error {
+	if len(code) != 5 {
+		return fmt.Errorf("unknown area code %s", code)
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("unknown area code %s", code)
+		}
+	}
+	if _, ok := LookupPrefecture(code[:2]); !ok {
+		return fmt.Errorf("unknown area code %s", code)
+	}
+	return nil
+}
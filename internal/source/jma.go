@@ -0,0 +1,154 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"goHeadache/pkg/jma"
+	"goHeadache/pkg/zutool"
+)
+
+// JMA is the Japan Meteorological Agency. It publishes no pressure forecast,
+// so the pressure and temperature are the hourly observations of the nearest
+// staffed AMeDAS station up to now, and later hours only have the daily
+// weather forecast of the area.
+type JMA struct {
+	Client *jma.Client
+}
+
+func (JMA) Name() string { return "JMA" }
+
+func (JMA) Credit() string { return "Japan Meteorological Agency, https://www.jma.go.jp" }
+
+// Fetch returns the forecast of loc, which needs both an area code and
+// coordinates.
+func (s JMA) Fetch(ctx context.Context, loc Location) (Forecast, error) {
+	if loc.AreaCode == "" || !loc.HasCoords {
+		return Forecast{}, fmt.Errorf("%w: JMA needs an area code in the embedded area list", ErrUnsupportedLocation)
+	}
+
+	var (
+		areas    jma.Areas
+		stations map[string]jma.Station
+		latest   time.Time
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		areas, err = s.Client.GetAreas(gctx)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		stations, err = s.Client.GetStations(gctx)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		latest, err = s.Client.GetLatestTime(gctx)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return Forecast{}, err
+	}
+	office, class10, err := areas.Subdivision(loc.AreaCode)
+	if err != nil {
+		return Forecast{}, fmt.Errorf("%w: %v", zutool.ErrAreaNotFound, err)
+	}
+	station, ok := jma.NearestPressureStation(stations, loc.Lat, loc.Lon)
+	if !ok {
+		return Forecast{}, fmt.Errorf("no AMeDAS station observes pressure")
+	}
+
+	latest = latest.In(jma.JST)
+	yesterday := time.Date(latest.Year(), latest.Month(), latest.Day()-1, 0, 0, 0, 0, jma.JST)
+	reports, obs, err := s.fetchData(ctx, office, station, yesterday, latest)
+	if err != nil {
+		return Forecast{}, err
+	}
+
+	codes := dailyWeatherCodes(reports, class10)
+	w := Forecast{
+		PlaceName: areas.Class20s[loc.AreaCode+"00"].Name,
+		PlaceID:   loc.AreaCode,
+		DateTime:  latest.Format("2006-01-02 15"),
+	}
+	days := []*[]zutool.HourlyData{&w.Yesterday, &w.Today, &w.Tomorrow, &w.DayAfterTom}
+	pressures := make([]*float64, 0, 24*len(days))
+	for i := range 24 * len(days) {
+		t := yesterday.Add(time.Duration(i) * time.Hour)
+		o := obs[t]
+		pressures = append(pressures, o.Pressure)
+		*days[i/24] = append(*days[i/24], zutool.HourlyData{
+			Time:          strconv.Itoa(t.Hour()),
+			Weather:       codes[t.Format(time.DateOnly)],
+			Temp:          formatValue(o.Temp),
+			Pressure:      formatValue(o.Pressure),
+			PressureLevel: strconv.Itoa(pressureLevel(pressures, i)),
+		})
+	}
+	return w, nil
+}
+
+// fetchData fetches the office's forecast and the station's observations
+// from the start of yesterday to latest, concurrently.
+func (s JMA) fetchData(ctx context.Context, office, station string, from, latest time.Time) ([]jma.Report, map[time.Time]jma.Observation, error) {
+	var (
+		reports []jma.Report
+		mu      sync.Mutex
+		obs     = map[time.Time]jma.Observation{}
+	)
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(4)
+	g.Go(func() error {
+		var err error
+		reports, err = s.Client.GetForecast(ctx, office)
+		return err
+	})
+	for t := from; !t.After(latest); t = t.Add(3 * time.Hour) {
+		g.Go(func() error {
+			block, err := s.Client.GetObservations(ctx, station, t)
+			if err != nil {
+				// A missing block only leaves a gap in the observations.
+				return nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for at, o := range block {
+				obs[at] = o
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return reports, obs, nil
+}
+
+// dailyWeatherCodes returns the weather code of each day in the detailed
+// forecast of the class10 area, keyed by date.
+func dailyWeatherCodes(reports []jma.Report, class10 string) map[string]string {
+	codes := map[string]string{}
+	if len(reports) == 0 {
+		return codes
+	}
+	for _, ts := range reports[0].TimeSeries {
+		for _, a := range ts.Areas {
+			if a.Area.Code != class10 || len(a.WeatherCodes) == 0 {
+				continue
+			}
+			for i, code := range a.WeatherCodes {
+				if i < len(ts.TimeDefines) {
+					codes[ts.TimeDefines[i].In(jma.JST).Format(time.DateOnly)] = code
+				}
+			}
+		}
+	}
+	return codes
+}
@@ -0,0 +1,173 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"goHeadache/pkg/openmeteo"
+	"goHeadache/pkg/zutool"
+)
+
+// OpenMeteo is the Open-Meteo API. It covers the whole world; the pressure
+// levels are derived from how fast the pressure changes.
+type OpenMeteo struct {
+	Client *openmeteo.Client
+}
+
+func (OpenMeteo) Name() string { return "Open-Meteo" }
+
+func (OpenMeteo) Credit() string { return "Open-Meteo, https://open-meteo.com" }
+
+// Fetch returns the forecast at loc's coordinates.
+func (s OpenMeteo) Fetch(ctx context.Context, loc Location) (Forecast, error) {
+	if !loc.HasCoords {
+		return Forecast{}, fmt.Errorf("%w: Open-Meteo needs coordinates", ErrUnsupportedLocation)
+	}
+	f, err := s.Client.GetForecast(ctx, loc.Lat, loc.Lon)
+	if err != nil {
+		return Forecast{}, err
+	}
+	return openMeteoForecast(loc.Key(), f, time.Now())
+}
+
+// openMeteoForecast converts an Open-Meteo forecast into the zutool model,
+// splitting the hours into yesterday to the day after tomorrow by the local
+// date at now.
+func openMeteoForecast(key string, f openmeteo.Forecast, now time.Time) (Forecast, error) {
+	h := f.Hourly
+	if len(h.Time) == 0 {
+		return Forecast{}, fmt.Errorf("Open-Meteo returned no forecast for %s", key)
+	}
+	loc := time.FixedZone(f.Timezone, f.UTCOffsetSeconds)
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	w := Forecast{
+		PlaceName: FormatCoords(f.Latitude, f.Longitude),
+		PlaceID:   key,
+		DateTime:  now.Format("2006-01-02 15"),
+	}
+	days := []*[]zutool.HourlyData{&w.Yesterday, &w.Today, &w.Tomorrow, &w.DayAfterTom}
+	for i, text := range h.Time {
+		t, err := time.ParseInLocation(openmeteo.TimeLayout, text, loc)
+		if err != nil {
+			return Forecast{}, fmt.Errorf("error parsing Open-Meteo time %q: %v", text, err)
+		}
+		day := dayIndex(t, today)
+		if day < 0 || day >= len(days) {
+			continue
+		}
+		entry := zutool.HourlyData{
+			Time:          strconv.Itoa(t.Hour()),
+			Weather:       wmoWeatherCode(valueAt(h.WeatherCode, i)),
+			Temp:          formatValue(valueAt(h.Temperature, i)),
+			Pressure:      formatValue(valueAt(h.SurfacePressure, i)),
+			PressureLevel: strconv.Itoa(pressureLevel(h.SurfacePressure, i)),
+		}
+		*days[day] = append(*days[day], entry)
+	}
+	return w, nil
+}
+
+// Precipitation is the chance of precipitation in percent by day, from
+// yesterday to the day after tomorrow like the days of a Forecast, and hour.
+type Precipitation [4]map[int]int
+
+// Precipitation returns the chance of precipitation at loc's coordinates.
+func (s OpenMeteo) Precipitation(ctx context.Context, loc Location) (Precipitation, error) {
+	var p Precipitation
+	if !loc.HasCoords {
+		return p, fmt.Errorf("%w: Open-Meteo needs coordinates", ErrUnsupportedLocation)
+	}
+	f, err := s.Client.GetForecast(ctx, loc.Lat, loc.Lon)
+	if err != nil {
+		return p, err
+	}
+	tz := time.FixedZone(f.Timezone, f.UTCOffsetSeconds)
+	now := time.Now().In(tz)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, tz)
+	for i, text := range f.Hourly.Time {
+		t, err := time.ParseInLocation(openmeteo.TimeLayout, text, tz)
+		v := valueAt(f.Hourly.PrecipitationProbability, i)
+		day := dayIndex(t, today)
+		if err != nil || v == nil || day < 0 || day >= len(p) {
+			continue
+		}
+		if p[day] == nil {
+			p[day] = map[int]int{}
+		}
+		p[day][t.Hour()] = int(math.Round(*v))
+	}
+	return p, nil
+}
+
+// dayIndex returns the Forecast day of t: 0 for yesterday to 3 for the day
+// after tomorrow, counting from the midnight that starts today.
+func dayIndex(t, today time.Time) int {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, today.Location())
+	return int(midnight.Sub(today).Hours()/24) + 1
+}
+
+// valueAt returns the i-th value of s, nil when it is missing.
+func valueAt[T any](s []*T, i int) *T {
+	if i < len(s) {
+		return s[i]
+	}
+	return nil
+}
+
+// formatValue renders a temperature or pressure like zutool does, "#" when
+// it is missing.
+func formatValue(v *float64) string {
+	if v == nil {
+		return "#"
+	}
+	return strconv.FormatFloat(*v, 'f', 1, 64)
+}
+
+// pressureLevel estimates zutool's 0-4 pressure level from the change over
+// the three hours up to hour i of hourly pressures.
+func pressureLevel(pressure []*float64, i int) int {
+	if i < 3 {
+		return 0
+	}
+	cur, prev := valueAt(pressure, i), valueAt(pressure, i-3)
+	if cur == nil || prev == nil {
+		return 0
+	}
+	return levelForChange(*cur - *prev)
+}
+
+// levelForChange maps a pressure change over three hours to zutool's 0-4
+// pressure level: under 1 hPa is 0, then one level per hPa.
+func levelForChange(change float64) int {
+	return min(int(math.Abs(change)), 4)
+}
+
+// wmoWeatherCode maps a WMO weather interpretation code to the closest
+// zutool weather code.
+func wmoWeatherCode(code *int) string {
+	if code == nil {
+		return ""
+	}
+	switch c := *code; {
+	case c <= 1:
+		return "100" // clear, mainly clear
+	case c == 2:
+		return "101" // partly cloudy
+	case c == 3:
+		return "200" // overcast
+	case c == 45 || c == 48:
+		return "209" // fog
+	case c >= 51 && c <= 67, c >= 80 && c <= 82:
+		return "300" // drizzle, rain, showers
+	case c >= 71 && c <= 77, c == 85 || c == 86:
+		return "400" // snow
+	case c >= 95:
+		return "350" // thunderstorm
+	}
+	return ""
+}
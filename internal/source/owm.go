@@ -0,0 +1,100 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"goHeadache/pkg/owm"
+	"goHeadache/pkg/zutool"
+)
+
+// OWM is OpenWeatherMap's free forecast, in three-hour steps from now. It
+// has no past data, so yesterday and the hours before now are empty.
+type OWM struct {
+	Client *owm.Client
+}
+
+func (OWM) Name() string { return "OpenWeatherMap" }
+
+func (OWM) Credit() string { return "OpenWeatherMap, https://openweathermap.org" }
+
+// Fetch returns the forecast at loc's coordinates.
+func (s OWM) Fetch(ctx context.Context, loc Location) (Forecast, error) {
+	if !loc.HasCoords {
+		return Forecast{}, fmt.Errorf("%w: OpenWeatherMap needs coordinates", ErrUnsupportedLocation)
+	}
+	f, err := s.Client.GetForecast(ctx, loc.Lat, loc.Lon)
+	if err != nil {
+		return Forecast{}, err
+	}
+	return owmForecast(loc.Key(), f, time.Now())
+}
+
+// owmForecast converts an OpenWeatherMap forecast into the zutool model by
+// the local date at now.
+func owmForecast(key string, f owm.Forecast, now time.Time) (Forecast, error) {
+	if len(f.List) == 0 {
+		return Forecast{}, fmt.Errorf("OpenWeatherMap returned no forecast for %s", key)
+	}
+	loc := time.FixedZone("", f.City.Timezone)
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	w := Forecast{
+		PlaceName: f.City.Name,
+		PlaceID:   key,
+		DateTime:  now.Format("2006-01-02 15"),
+	}
+	if w.PlaceName == "" {
+		w.PlaceName = FormatCoords(f.City.Coord.Lat, f.City.Coord.Lon)
+	}
+	days := []*[]zutool.HourlyData{&w.Yesterday, &w.Today, &w.Tomorrow, &w.DayAfterTom}
+	for i, e := range f.List {
+		t := e.Time().In(loc)
+		day := dayIndex(t, today)
+		if day < 0 || day >= len(days) {
+			continue
+		}
+		level := 0
+		if i > 0 {
+			// Steps are three hours apart, the span zutool's levels use.
+			level = levelForChange(e.Main.Pressure - f.List[i-1].Main.Pressure)
+		}
+		code := ""
+		if len(e.Weather) > 0 {
+			code = owmWeatherCode(e.Weather[0].ID)
+		}
+		*days[day] = append(*days[day], zutool.HourlyData{
+			Time:          strconv.Itoa(t.Hour()),
+			Weather:       code,
+			Temp:          strconv.FormatFloat(e.Main.Temp, 'f', 1, 64),
+			Pressure:      strconv.FormatFloat(e.Main.Pressure, 'f', 1, 64),
+			PressureLevel: strconv.Itoa(level),
+		})
+	}
+	return w, nil
+}
+
+// owmWeatherCode maps an OpenWeatherMap condition code to the closest
+// zutool weather code.
+func owmWeatherCode(id int) string {
+	switch {
+	case id >= 200 && id < 300:
+		return "350" // thunderstorm
+	case id >= 300 && id < 600:
+		return "300" // drizzle, rain
+	case id >= 600 && id < 700:
+		return "400" // snow
+	case id == 701 || id == 721 || id == 741:
+		return "209" // mist, haze, fog
+	case id == 800 || id == 801:
+		return "100" // clear, few clouds
+	case id == 802:
+		return "101" // scattered clouds
+	case id == 803 || id == 804:
+		return "200" // broken, overcast clouds
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+// Package source abstracts where forecasts come from. Every source returns
+// the same Forecast, so the TUI, the cache and the exporters do not depend
+// on any one API.
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"goHeadache/pkg/zutool"
+)
+
+// Forecast is the hourly forecast of a location from yesterday to the day
+// after tomorrow. It uses the zutool model, which the rest of the app was
+// built on; sources with other data map theirs into it.
+type Forecast = zutool.WeatherData
+
+// Source fetches forecasts.
+type Source interface {
+	// Name identifies the source in messages, such as "zutool".
+	Name() string
+	// Credit names the data provider for the help screen.
+	Credit() string
+	// Fetch returns the forecast of loc. A source that needs an area code
+	// or coordinates loc does not have returns ErrUnsupportedLocation.
+	Fetch(ctx context.Context, loc Location) (Forecast, error)
+}
+
+// ErrUnsupportedLocation is returned by Fetch when the location lacks what
+// the source needs to look it up.
+var ErrUnsupportedLocation = errors.New("location not supported by this source")
+
+// Location is where a forecast is for: a zutool area code, coordinates, or
+// both.
+type Location struct {
+	AreaCode string
+	Lat, Lon float64
+	// HasCoords reports whether Lat and Lon are set, since 0, 0 is a valid
+	// position.
+	HasCoords bool
+}
+
+// AtCoords returns the location at lat, lon, checking that they are in
+// range.
+func AtCoords(lat, lon float64) (Location, error) {
+	if math.IsNaN(lat) || math.IsNaN(lon) || math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+		return Location{}, fmt.Errorf("invalid coordinates %g, %g (latitude -90 to 90, longitude -180 to 180)", lat, lon)
+	}
+	return Location{Lat: lat, Lon: lon, HasCoords: true}, nil
+}
+
+// Key identifies the location in the cache and on screen: the area code if
+// there is one, else "lat,lon".
+func (l Location) Key() string {
+	if l.AreaCode != "" || !l.HasCoords {
+		return l.AreaCode
+	}
+	return strconv.FormatFloat(l.Lat, 'f', 4, 64) + "," + strconv.FormatFloat(l.Lon, 'f', 4, 64)
+}
+
+// ParseKey turns a key made by Location.Key back into a Location.
+func ParseKey(key string) (Location, error) {
+	latText, lonText, ok := strings.Cut(key, ",")
+	if !ok {
+		return Location{AreaCode: key}, nil
+	}
+	lat, err := strconv.ParseFloat(latText, 64)
+	if err != nil {
+		return Location{}, fmt.Errorf("invalid coordinates %q", key)
+	}
+	lon, err := strconv.ParseFloat(lonText, 64)
+	if err != nil {
+		return Location{}, fmt.Errorf("invalid coordinates %q", key)
+	}
+	return AtCoords(lat, lon)
+}
+
+// FormatCoords renders coordinates as a place name, such as
+// "35.68°N 139.77°E".
+func FormatCoords(lat, lon float64) string {
+	ns, ew := "N", "E"
+	if lat < 0 {
+		ns = "S"
+	}
+	if lon < 0 {
+		ew = "W"
+	}
+	return fmt.Sprintf("%.2f°%s %.2f°%s", math.Abs(lat), ns, math.Abs(lon), ew)
+}
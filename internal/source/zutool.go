@@ -0,0 +1,26 @@
+package source
+
+import (
+	"context"
+	"fmt"
+
+	"goHeadache/pkg/zutool"
+)
+
+// Zutool is the zutool API, the default source. Its pressure levels are
+// zutool's own headache forecast.
+type Zutool struct {
+	Client *zutool.Client
+}
+
+func (Zutool) Name() string { return "zutool" }
+
+func (Zutool) Credit() string { return "zutool (頭痛ーる), https://zutool.jp" }
+
+// Fetch returns the forecast of loc's area code.
+func (s Zutool) Fetch(ctx context.Context, loc Location) (Forecast, error) {
+	if loc.AreaCode == "" {
+		return Forecast{}, fmt.Errorf("%w: zutool needs an area code", ErrUnsupportedLocation)
+	}
+	return s.Client.GetWeatherStatus(ctx, loc.AreaCode)
+}
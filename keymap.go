@@ -0,0 +1,218 @@
+package main
+
+import "strings"
+
+// keyBinding describes a key for the footer and the help screen. Every
+// screen lists the keys that do something in its current state, so the
+// hints follow what can be done.
+type keyBinding struct {
+	// hint is the footer hint, as in "r: Retry"; empty leaves the key out
+	// of the footer.
+	hint string
+	// short replaces hint in the compact footer, and wide leaves the key out
+	// of it.
+	short string
+	wide  bool
+	// keys and help are the key and what it does on the help screen; empty
+	// keys leaves the key out of it.
+	keys, help string
+}
+
+// keymap is the key bindings of a screen, in the order they are listed.
+type keymap []keyBinding
+
+// hints returns the footer hints of k in the language of the UI, those of
+// the compact footer if compact.
+func (k keymap) hints(compact bool) []string {
+	var hints []string
+	for _, b := range k {
+		h := b.hint
+		if compact {
+			if b.wide {
+				continue
+			}
+			if b.short != "" {
+				h = b.short
+			}
+		}
+		if h != "" {
+			hints = append(hints, tr(h))
+		}
+	}
+	return hints
+}
+
+// footer packs the hints of k into lines of at most width cells.
+func (k keymap) footer(width int, compact bool) string {
+	return packHints(k.hints(compact), width)
+}
+
+// line joins the hints of k on one line, for popups.
+func (k keymap) line() string {
+	return strings.Join(k.hints(false), "  ")
+}
+
+// helpRows returns the keys of k and what they do for the help screen, in
+// the language of the UI.
+func (k keymap) helpRows() [][2]string {
+	var rows [][2]string
+	for _, b := range k {
+		if b.keys != "" {
+			rows = append(rows, [2]string{tr(b.keys), tr(b.help)})
+		}
+	}
+	return rows
+}
+
+var (
+	quitKey = keyBinding{hint: "q: Quit", keys: "q, ctrl+c", help: "Quit"}
+	helpKey = keyBinding{hint: "?: Help", keys: "?", help: "Show/hide this help"}
+	gotoKey = keyBinding{hint: "/: Go to", wide: true, keys: ":, /", help: "Go to another area code or place"}
+)
+
+// screenKeys returns the keys of the forecast screen in its current state:
+// an error, loading, the history or the forecast itself.
+func (m model) screenKeys() keymap {
+	switch {
+	case m.err != nil:
+		var k keymap
+		if m.retryAt.IsZero() {
+			k = append(k, keyBinding{hint: "r: Retry", keys: "r", help: "Retry after an error"})
+		}
+		return append(append(k, m.locationKeys()...), gotoKey, helpKey, quitKey)
+	case m.loading:
+		return keymap{quitKey}
+	case m.historyMode:
+		k := keymap{
+			{hint: "←/→: Previous/next day", keys: "←/→, h/l", help: "Previous/next day"},
+			{hint: "Home/End: Oldest/newest", keys: "Home/End", help: "Oldest/newest day"},
+			{keys: "↑/↓, k/j", help: "Scroll"},
+			{keys: "gg/G", help: "Top/bottom"},
+			{keys: "ctrl+u/ctrl+d", help: "Half a page up/down"},
+			{hint: "H/Esc: Back", keys: "H, Esc", help: "Back to the forecast"},
+		}
+		return append(append(k, m.locationKeys()...), gotoKey, helpKey, quitKey)
+	}
+
+	var k keymap
+	if m.dayFilter == "" {
+		if m.timelineDays == 0 {
+			k = append(k,
+				keyBinding{hint: "←/→: Change day", short: "←/→/1-4: Day", keys: "←/→, h/l", help: "Previous/next day"},
+				keyBinding{hint: "1-4: Yesterday/Today/Tomorrow/Day after", wide: true, keys: "1-4", help: "Yesterday, Today, Tomorrow, Day After"},
+			)
+		} else {
+			k = append(k, keyBinding{hint: "1-4: Yesterday/Today/Tomorrow/Day after", short: "1-4: Day", keys: "1-4", help: "Yesterday, Today, Tomorrow, Day After"})
+		}
+		k = append(k, keyBinding{keys: "Click a tab", help: "Show that day"})
+	}
+	k = append(k, keyBinding{keys: "Click ↑/↓ above", help: "Page up/down"})
+	if m.showsTable() {
+		k = append(k,
+			keyBinding{hint: "↑/↓: Select", keys: "↑/↓, k/j", help: "Select the previous/next hour"},
+			keyBinding{keys: "Mouse wheel", help: "Select or scroll"},
+			keyBinding{keys: "PgUp/PgDn", help: "Page up/down"},
+			keyBinding{keys: "ctrl+u/ctrl+d", help: "Half a page up/down"},
+			keyBinding{keys: "Home/End, gg/G", help: "First/last hour"},
+			keyBinding{hint: "Enter: Details", keys: "Enter", help: "Details of the selected hour"},
+			keyBinding{keys: "Click a row", help: "Details of that hour"},
+		)
+		night := keyBinding{hint: "n: Hide night", wide: true, keys: "n", help: "Hide/show the hours outside the hour range"}
+		if m.hideNight {
+			night.hint = "n: Show night"
+		}
+		filter := keyBinding{hint: "f: Level ≥ 2", wide: true, keys: "f", help: "Show all hours, or only those of level ≥ 2 or ≥ 3"}
+		switch m.minLevel {
+		case 2:
+			filter.hint = "f: Level ≥ 3"
+		case 3:
+			filter.hint = "f: All hours"
+		}
+		order := keyBinding{hint: "s: Sort by pressure", wide: true, keys: "s", help: "Sort by time, lowest pressure or highest risk"}
+		switch m.sortBy {
+		case sortByPressure:
+			order.hint = "s: Sort by risk"
+		case sortByRisk:
+			order.hint = "s: Sort by time"
+		}
+		vsDay := keyBinding{hint: "d: Vs day before", wide: true, keys: "d", help: "Compare each hour with the day before"}
+		if m.dayOverDay {
+			vsDay.hint = "d: Hide comparison"
+		}
+		k = append(k, night, filter, order, vsDay, keyBinding{hint: "c: Columns", wide: true, keys: "c", help: "Hide or show columns"})
+	} else {
+		k = append(k,
+			keyBinding{hint: "↑/↓: Scroll", keys: "↑/↓, k/j", help: "Scroll"},
+			keyBinding{keys: "Mouse wheel", help: "Scroll"},
+			keyBinding{keys: "PgUp/PgDn", help: "Page up/down"},
+			keyBinding{keys: "ctrl+u/ctrl+d", help: "Half a page up/down"},
+			keyBinding{keys: "Home/End, gg/G", help: "Top/bottom"},
+		)
+	}
+	k = append(k,
+		keyBinding{hint: "g: Graph", keys: "g", help: "Toggle the pressure graph"},
+		keyBinding{hint: "t: Timeline", keys: "t", help: "Cycle the 48/72-hour timeline"},
+		keyBinding{hint: "H: History", keys: "H", help: "Browse the past days in the history"},
+		keyBinding{keys: "L", help: "Log a headache in the diary"},
+	)
+	return append(append(k, m.locationKeys()...), gotoKey, helpKey, quitKey)
+}
+
+// locationKeys is the help screen entry of the saved locations, whose
+// footer hints are on a line of their own.
+func (m model) locationKeys() keymap {
+	if len(m.locations) == 0 {
+		return nil
+	}
+	return keymap{{keys: "alt+1-9", help: "Switch saved location"}}
+}
+
+// The keys of the popups, listed at their bottom.
+var (
+	helpPopupKeys    = keymap{{hint: "?/Esc: Close"}}
+	detailPopupKeys  = keymap{{hint: "Enter/Esc: Close"}}
+	diaryPopupKeys   = keymap{{hint: "Enter: Log"}, {hint: "Esc: Cancel"}}
+	promptKeys       = keymap{{hint: "Enter: Go"}, {hint: "Esc: Cancel"}}
+	promptMatchKeys  = keymap{{hint: "↑/↓: Move"}, {hint: "Enter: Go"}, {hint: "Esc: Cancel"}}
+	columnPickerKeys = keymap{{hint: "↑/↓: Move"}, {hint: "Space: Hide/show"}, {hint: "c/Esc: Close"}}
+)
+
+// keys returns the keys of the place picker in its current state. What Esc
+// does depends on it: going back, clearing the filter or quitting.
+func (m pickerModel) keys() keymap {
+	quit := keyBinding{hint: "ctrl+c: Quit"}
+	if m.err != nil || m.loading {
+		return keymap{{hint: "Esc: Back"}, quit}
+	}
+	esc := keyBinding{hint: "Esc: Quit"}
+	switch {
+	case m.filter != "":
+		esc.hint = "Esc: Clear filter"
+	case m.stage == stageCity:
+		esc.hint = "Esc: Back"
+	}
+	k := keymap{{hint: "↑/↓: Move"}}
+	if len(m.filtered()) > 0 {
+		k = append(k, keyBinding{hint: "Enter: Select"})
+	}
+	return append(k, keyBinding{hint: "Type to filter"}, esc, quit)
+}
+
+// keys returns the keys of the pain map.
+func (m painMapModel) keys() keymap {
+	return keymap{
+		{hint: "←/→/↑/↓: Move"},
+		{hint: "Enter: Choose a place in the prefecture"},
+		{hint: "q/Esc: Quit"},
+	}
+}
+
+// keys returns the keys of the comparison.
+func (m compareModel) keys() keymap {
+	return keymap{
+		{hint: "←/→: Change day"},
+		{hint: "↑/↓/Mouse wheel: Scroll"},
+		{hint: "Home/End: Jump to top/bottom"},
+		{hint: "q: Quit"},
+	}
+}
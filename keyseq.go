@@ -0,0 +1,58 @@
+package main
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// keySequenceTimeout is how long g waits for a second g, as in gg, before
+// it toggles the graph on its own.
+const keySequenceTimeout = 400 * time.Millisecond
+
+// keySequenceMsg ends the wait for the rest of the key sequence id.
+type keySequenceMsg struct{ id int }
+
+// keySequenceCmd times out the key sequence id.
+func keySequenceCmd(id int) tea.Cmd {
+	return tea.Tick(keySequenceTimeout, func(time.Time) tea.Msg {
+		return keySequenceMsg{id}
+	})
+}
+
+// startSequence holds key back until the next key press or the timeout.
+func (m model) startSequence(key string) (model, tea.Cmd) {
+	m.pendingKey = key
+	m.keySeqID++
+	return m, keySequenceCmd(m.keySeqID)
+}
+
+// continueSequence resolves the pending key with the key pressed after it.
+// It reports whether msg completed a sequence, which leaves nothing more to
+// do with it; otherwise the pending key is applied on its own first.
+func (m model) continueSequence(msg tea.KeyPressMsg) (model, bool) {
+	pending := m.pendingKey
+	m.pendingKey = ""
+	if pending == "g" && msg.String() == "g" {
+		m.gotoTop()
+		return m, true
+	}
+	return m.applyPending(pending), false
+}
+
+// applyPending does what a pending key does when no sequence follows it.
+// Alone, g toggles the graph; the history has no graph to toggle.
+func (m model) applyPending(key string) model {
+	if key == "g" && !m.historyMode {
+		m.graphMode = !m.graphMode
+		m.viewport.GotoTop()
+	}
+	return m
+}
+
+// gotoBottom selects the last row of the table and scrolls the viewport to
+// the bottom.
+func (m *model) gotoBottom() {
+	m.selectRow(len(m.table.Rows()) - 1)
+	m.viewport.GotoBottom()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+)
+
+const unknownLevelColor = "#64748B"
+
+// levelNames are the descriptions zutool gives each pressure level.
+var levelNames = map[string]string{
+	"0": "Normal",
+	"1": "Normal",
+	"2": "Slight caution",
+	"3": "Caution",
+	"4": "Warning",
+}
+
+// levelColor returns the theme color of a pressure level, on a scale from
+// green to red.
+func levelColor(level string) string {
+	n, err := strconv.Atoi(strings.TrimSpace(level))
+	if err != nil || n < 0 || n >= len(theme.Levels) {
+		return unknownLevelColor
+	}
+	return theme.Levels[n]
+}
+
+// levelName returns the description of a pressure level, in the language
+// of the UI.
+func levelName(level string) string {
+	if name, ok := levelNames[strings.TrimSpace(level)]; ok {
+		return tr(name)
+	}
+	return tr("Unknown")
+}
+
+// levelNameWidth returns the width of the longest level description.
+func levelNameWidth() int {
+	w := 0
+	for _, name := range levelNames {
+		w = max(w, lipgloss.Width(tr(name)))
+	}
+	return w
+}
+
+// levelStyle returns the foreground style for a pressure level.
+func levelStyle(level string) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(levelColor(level)))
+}
+
+// levelCellStyle colors a table cell's background by pressure level.
+func levelCellStyle(s lipgloss.Style, level string) lipgloss.Style {
+	return s.Background(lipgloss.Color(levelColor(level))).Foreground(lipgloss.Color(theme.BadgeText)).Bold(true)
+}
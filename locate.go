@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+
+	"goHeadache/internal/areas"
+	"goHeadache/pkg/geoip"
+)
+
+var geoClient = geoip.NewClient(nil)
+
+// autoLocate guesses where the user is from their IP address and returns
+// the nearest area code of the embedded area database, once the user has
+// confirmed it on the terminal. The guess is that of the internet provider,
+// which can be a city or more away.
+func autoLocate() (string, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("-auto-locate asks to confirm the place it finds, so it needs a terminal; pass an area code instead")
+	}
+	loc, err := geoClient.Locate(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("error locating this IP address: %w", err)
+	}
+	debugLog.Debug("located", "city", loc.City, "region", loc.Region, "country", loc.CountryCode, "lat", loc.Latitude, "lon", loc.Longitude)
+	if loc.CountryCode != "JP" {
+		return "", fmt.Errorf("this IP address is in %s, outside Japan; use -source open-meteo -lat %.2f -lon %.2f for a forecast there", placeOf(loc), loc.Latitude, loc.Longitude)
+	}
+	area, km := areas.Nearest(loc.Latitude, loc.Longitude)
+	ok, err := confirmArea(os.Stdin, os.Stdout, loc, area, km)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("no place selected")
+	}
+	return area.Code, nil
+}
+
+// nearestAreaLimit is how far in kilometers the nearest area of the embedded
+// area list may be from coordinates for its forecast to stand for theirs.
+const nearestAreaLimit = 50.0
+
+// areaAt returns the area of the embedded area list nearest to lat, lon and
+// how far away it is, ok=false when none is within nearestAreaLimit, as
+// outside Japan.
+func areaAt(lat, lon float64) (a areas.Area, km float64, ok bool) {
+	a, km = areas.Nearest(lat, lon)
+	return a, km, km <= nearestAreaLimit
+}
+
+// nearestKnownArea returns the area of the embedded area list to show
+// instead of code, which has no forecast: the nearest other area when code
+// is in the list, else the area of its prefecture with the closest code,
+// since neighbouring municipalities are numbered close together.
+func nearestKnownArea(code string) (areas.Area, bool) {
+	var best areas.Area
+	bestDist := math.Inf(1)
+	if a, ok := areas.Lookup(code); ok {
+		for _, other := range areas.All() {
+			if d := other.DistanceTo(a.Lat, a.Lon); other.Code != code && d < bestDist {
+				best, bestDist = other, d
+			}
+		}
+		return best, best.Code != ""
+	}
+	n, err := strconv.Atoi(code)
+	if err != nil || len(code) < 2 {
+		return best, false
+	}
+	for _, other := range areas.InPrefecture(code[:2]) {
+		m, _ := strconv.Atoi(other.Code)
+		if d := math.Abs(float64(m - n)); d < bestDist {
+			best, bestDist = other, d
+		}
+	}
+	return best, best.Code != ""
+}
+
+// noteSubstitute says on stderr which area's forecast w is when it stands
+// in for areaCode.
+func noteSubstitute(areaCode string, w weatherResult) {
+	if w.substitute != "" {
+		fmt.Fprintf(os.Stderr, "Area code %s has no forecast; using the nearest known area, %s %s\n", areaCode, w.substitute, w.data.PlaceName)
+	}
+}
+
+// placeOf names loc as "city, region, country", leaving out what is not
+// known and a region named as its city, such as Tokyo.
+func placeOf(loc geoip.Location) string {
+	var parts []string
+	for _, p := range []string{loc.City, loc.Region, loc.CountryName} {
+		if p != "" && !slices.Contains(parts, p) {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return loc.CountryCode
+	}
+	return strings.Join(parts, ", ")
+}
+
+// confirmArea asks whether to use area, km away from loc. An empty answer
+// or y accepts it.
+func confirmArea(in io.Reader, out io.Writer, loc geoip.Location, area areas.Area, km float64) (bool, error) {
+	fmt.Fprintf(out, "This IP address is near %s.\n", placeOf(loc))
+	fmt.Fprintf(out, "The nearest area is %s %s (%s), %.0f km away.\n", area.Code, area.FullName(), area.NameEn, km)
+	fmt.Fprint(out, "Use it? [Y/n] ")
+	scanner := bufio.NewScanner(in)
+	if !scanner.Scan() {
+		return false, scanner.Err()
+	}
+	switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+	case "", "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
@@ -1,141 +1,212 @@
 package main
 
 import (
-	"encoding/json"
-	"flag"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/table"
+	"charm.land/bubbles/v2/textinput"
+	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
-)
-
-type WeatherData struct {
-	PlaceName     string       `json:"place_name"`
-	PlaceID       string       `json:"place_id"`
-	PrefecturesID string       `json:"prefectures_id"`
-	DateTime      string       `json:"dateTime"`
-	Yesterday     []HourlyData `json:"yesterday"`
-	Today         []HourlyData `json:"today"`
-	Tomorrow      []HourlyData `json:"tomorrow"`
-	DayAfterTom   []HourlyData `json:"dayaftertomorrow"`
-}
+	"github.com/charmbracelet/x/ansi"
 
-type HourlyData struct {
-	Time          string `json:"time"`
-	Weather       string `json:"weather"`
-	Temp          string `json:"temp"`
-	Pressure      string `json:"pressure"`
-	PressureLevel string `json:"pressure_level"`
-}
+	"goHeadache/internal/areas"
+	"goHeadache/internal/source"
+	"goHeadache/pkg/zutool"
+)
 
 type model struct {
-	weatherData WeatherData
+	weatherData zutool.WeatherData
 	dayFilter   string
 	areaCode    string
 	loading     bool
 	err         error
-	scrollPos   int
-	currentDay  int // 0=Yesterday, 1=Today, 2=Tomorrow, 3=DayAfterTomorrow
-	width       int
-	height      int
+	// table shows the hourly rows; viewport scrolls the other views.
+	table    table.Model
+	viewport viewport.Model
+	// tableLayoutKey identifies the columns and size the table was last
+	// built with; see syncTable.
+	tableLayoutKey string
+	currentDay     int // 0=Yesterday, 1=Today, 2=Tomorrow, 3=DayAfterTomorrow
+	locations      []Location
+	graphMode      bool
+	riskWeights    RiskWeights
+	iconSet        string // key of iconSets, or "" for text only
+	// timelineDays is 2 or 3 when Today and the following days are shown
+	// as one continuous timeline, 0 for the single-day view.
+	timelineDays int
+	// compact forces the layout with only Time, Pressure and Level that is
+	// otherwise used on narrow terminals.
+	compact bool
+	// hideNight leaves the hours outside hours out of the table.
+	hours     hourRange
+	hideNight bool
+	// minLevel leaves the hours below this pressure level out of the table,
+	// 0 for none; see levelFilters.
+	minLevel int
+	// sortBy is the order of the table rows.
+	sortBy tableSort
+	// dayOverDay adds the column comparing each hour with the same hour of
+	// the day before; see dayOverDayColumn.
+	dayOverDay bool
+	// hiddenColumns are the names of the table columns left out.
+	// columnPicker shows the popup hiding and showing them, with
+	// columnCursor on one.
+	hiddenColumns map[string]bool
+	columnPicker  bool
+	columnCursor  int
+	// detail shows the popup with the full data of the selected hour.
+	detail bool
+	// help shows the key bindings and data source over the screen.
+	help bool
+	// historyMode shows the past days recorded in the history instead of
+	// the forecast, historyDays[historyIndex] being the one on screen.
+	historyMode    bool
+	historyLoading bool
+	historyDays    []historyDay
+	historyIndex   int
+	historyErr     error
+	// diary shows the popup logging a headache episode into diaryInput;
+	// diaryNotice is set once it is logged.
+	diary       bool
+	diaryInput  textinput.Model
+	diaryNotice string
+	diaryErr    error
+	// prompt shows the prompt switching to the area code or place typed
+	// into promptInput. promptQuery is the name searched for, and
+	// promptMatches the places it matches, with promptCursor on one.
+	prompt        bool
+	promptInput   textinput.Model
+	promptQuery   string
+	promptMatches []zutool.WeatherPoint
+	promptCursor  int
+	promptErr     error
+	// notify sends a desktop notification about upcoming pressure warnings;
+	// notifiedAt is the hour last notified about, and notifyErr why the
+	// last notification failed.
+	notify     NotifyConfig
+	notifiedAt time.Time
+	notifyErr  error
+	// screenAlert is how a severe forecast is signalled: "bell", "flash",
+	// "both" or "" for not at all. alerted is set once it was, and flashing
+	// while the header is inverted.
+	screenAlert string
+	alerted     bool
+	flashing    bool
+	// watchInterval re-fetches the forecast periodically when non-zero;
+	// nextRefresh is when it does next.
+	watchInterval time.Duration
+	nextRefresh   time.Time
+	lastUpdated   time.Time
+	refreshErr    error
+	// cached is set when the forecast shown was read from the cache.
+	cached bool
+	// stale is set when the forecast shown is an old cached one; see
+	// weatherResult.
+	stale    error
+	fallback string
+	// substitute is the area code whose forecast stands in for areaCode,
+	// which has none; see useNearest.
+	substitute string
+	// pain is the prefecture's current headache reports, if available.
+	pain *zutool.PainStatus
+	// rain is the chance of precipitation by hour, shown in the Rain column
+	// when -rain is set.
+	rain *source.Precipitation
+	// retries counts the retries since the last successful fetch; retryAt
+	// is when the pending one starts, zero when none is pending.
+	retries int
+	retryAt time.Time
+	// pendingKey is the first key of a sequence such as gg, waiting for the
+	// next; keySeqID numbers the waits so only the latest times out.
+	pendingKey string
+	keySeqID   int
+	// fetchCtx is the context of the latest fetch and cancelFetch cancels
+	// it; fetchID numbers the fetches so only the latest result is used.
+	fetchCtx    context.Context
+	cancelFetch context.CancelFunc
+	fetchID     int
+	// remember is saved with the state of the screen as the session on
+	// quitting, nil to remember nothing; see sessionState.
+	remember *sessionState
+	width    int
+	height   int
 }
 
+// The styles are built from the current theme by applyTheme.
 var (
-	appStyle = lipgloss.NewStyle().
-			Padding(0, 1).
-			Border(lipgloss.DoubleBorder()).
-			BorderForeground(lipgloss.Color("#0EA5E9"))
-
-	dayHeaderStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#1E3A5F")).
-			Background(lipgloss.Color("#93C5FD")).
-			PaddingLeft(2).
-			PaddingRight(2).
-			MarginTop(1).
-			MarginBottom(0).
-			Align(lipgloss.Center)
-
-	tableHeaderStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("#0C2A4A")).
-				Background(lipgloss.Color("#60A5FA")).
-				PaddingLeft(1).
-				PaddingRight(1).
-				Align(lipgloss.Center)
-
-	cellStyle = lipgloss.NewStyle().
-			PaddingLeft(1).
-			PaddingRight(1).
-			Align(lipgloss.Center).
-			Foreground(lipgloss.Color("#1E293B"))
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#991B1B")).
-			Bold(true).
-			Padding(1, 2).
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#EF4444"))
-
-	loadingStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#0369A1")).
-			Bold(true).
-			Padding(2).
-			Align(lipgloss.Center)
-
-	currentCellStyle = lipgloss.NewStyle().
-				PaddingLeft(1).
-				PaddingRight(1).
-				Align(lipgloss.Center).
-				Background(lipgloss.Color("#FEF08A")).
-				Foreground(lipgloss.Color("#1E293B")).
-				Bold(true)
-
-	footerStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#475569")).
-			Padding(0, 0).
-			MarginTop(1).
-			Border(lipgloss.NormalBorder(), true, false, false, false).
-			BorderForeground(lipgloss.Color("#1E3A5F")).
-			Align(lipgloss.Center)
+	appStyle         lipgloss.Style
+	dayHeaderStyle   lipgloss.Style
+	tableHeaderStyle lipgloss.Style
+	cellStyle        lipgloss.Style
+	errorStyle       lipgloss.Style
+	loadingStyle     lipgloss.Style
+	currentCellStyle lipgloss.Style
+	selectedRowStyle lipgloss.Style
+	popupStyle       lipgloss.Style
+	bannerStyle      lipgloss.Style
+	nowStyle         lipgloss.Style
+	tabStyle         lipgloss.Style
+	activeTabStyle   lipgloss.Style
+	sparklineStyle   lipgloss.Style
+	statusStyle      lipgloss.Style
+	footerStyle      lipgloss.Style
 )
 
 func parseFloat(s string) float64 {
 	val, err := strconv.ParseFloat(s, 64)
 	if err != nil {
+		debugLog.Warn("unparsable number", "value", s)
 		return 0
 	}
 	return val
 }
 
 func translateWeatherCode(code string) string {
-	switch code {
-	case "100":
-		return "Sunny"
-	case "200":
-		return "Cloudy"
-	case "300":
-		return "Rainy"
-	default:
+	if wc, ok := zutool.LookupWeatherCode(code); ok {
+		return wc.En
+	}
+	if strings.TrimSpace(code) == "" {
 		return "Unknown"
 	}
+	return weatherIcon(code) + " " + strings.TrimSpace(code)
 }
 
-const numCols = 5
+// weatherIcon returns a symbol for the weather group of a code: the first
+// digit is 1 for sunny, 2 cloudy, 3 rain and 4 snow.
+func weatherIcon(code string) string {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "?"
+	}
+	switch code[0] {
+	case '1':
+		return "☀"
+	case '2':
+		return "☁"
+	case '3':
+		return "☂"
+	case '4':
+		return "❄"
+	default:
+		return "?"
+	}
+}
 
-func formatHourlyData(entry HourlyData) (string, string, string, string) {
-	temp := entry.Temp
-	if temp == "#" {
+func formatHourlyData(entry zutool.HourlyData) (string, string, string, string) {
+	temp := strings.TrimSpace(entry.Temp)
+	if temp == "#" || temp == "" {
 		temp = "N/A"
 	}
-	pressure := entry.Pressure
-	if pressure == "#" {
+	pressure := strings.TrimSpace(entry.Pressure)
+	if pressure == "#" || pressure == "" {
 		pressure = "N/A"
 	}
 
@@ -149,59 +220,63 @@ func formatHourlyData(entry HourlyData) (string, string, string, string) {
 	}
 
 	if pressure != "N/A" {
-		pressure = fmt.Sprintf("%.1f", parseFloat(strings.TrimSpace(pressure)))
+		pressure = fmt.Sprintf("%.1f", parseFloat(pressure))
 	}
 
 	return hour + ":00", translateWeatherCode(entry.Weather), temp, pressure
 }
 
-func createTableHeaders(colW int) string {
-	tableHeader := tableHeaderStyle.Width(colW).Render("Time") +
-		tableHeaderStyle.Width(colW).Render("Weather") +
-		tableHeaderStyle.Width(colW).Render("Temp") +
-		tableHeaderStyle.Width(colW).Render("Pressure") +
-		tableHeaderStyle.Width(colW).Render("Pressure Level")
-
-	tableUnits := tableHeaderStyle.Width(colW).Render("") +
-		tableHeaderStyle.Width(colW).Render("") +
-		tableHeaderStyle.Width(colW).Render("(°C)") +
-		tableHeaderStyle.Width(colW).Render("(hPa)") +
-		tableHeaderStyle.Width(colW).Render("")
-
-	return tableHeader + "\n" + tableUnits
+// displayHourlyData is formatHourlyData with the temperature and pressure
+// in the display units.
+func displayHourlyData(entry zutool.HourlyData) (string, string, string, string) {
+	hour, weather, temp, pressure := formatHourlyData(entry)
+	if h, err := strconv.Atoi(strings.TrimSpace(entry.Time)); err == nil {
+		hour = formatHour(h)
+	}
+	if temp != "N/A" {
+		temp = units.formatTemp(parseFloat(temp))
+	}
+	if pressure != "N/A" {
+		pressure = units.formatPressure(parseFloat(pressure))
+	}
+	return hour, weather, temp, pressure
 }
 
-func calculateScrollParameters(m model, numHeaders int, numContentLines int) (int, int) {
-	// Lines consumed per header:
-	//   dayHeader with MarginTop(1)+content+MarginBottom(1) = 3 lines
-	//   "\n" separator between dayHeader and tableHeaders    = 1 line
-	//   table header row + table units row                   = 2 lines
-	//   trailing "\n" written to contentBuilder              = 1 line
-	//   Total: 7 lines per header
-	headerLines := numHeaders * 7
+// contentHeight returns how many content lines fit below a header of
+// headerLines lines, never more than numContentLines.
+func contentHeight(m model, headerLines int, numContentLines int) int {
 	// Fixed overhead lines (not headers or content):
-	//   appStyle border (top+bottom) + padding (top+bottom)  = 4 lines
+	//   appStyle border (top+bottom)                         = 2 lines
 	//   scroll indicator text + blank line                   = 2 lines
-	//   "\n\n" before footer                                 = 2 lines
-	//   footer with Padding(1,0) and 2 content lines         = 4 lines
-	//   Total: 12 lines
-	extraLines := 12
+	//   footer margin and top border                         = 2 lines
+	//   Total: 6 lines, plus the footer's key hint lines
+	extraLines := 6 + strings.Count(m.footerText(), "\n") + 1
+	if !m.loading && m.err == nil {
+		// status bar
+		extraLines++
+	}
+	if m.showsTabs() {
+		// day tab bar
+		extraLines++
+	}
+	if m.stale != nil {
+		// stale data banner
+		extraLines++
+	}
+	if !m.loading && m.err == nil && m.nowBanner() != "" {
+		// now banner
+		extraLines++
+	}
 	visibleHeight := m.height - headerLines - extraLines
 	if visibleHeight < 3 {
 		visibleHeight = 3
 	}
 
-	maxScroll := numContentLines - visibleHeight
-	if maxScroll < 0 {
-		maxScroll = 0
-		visibleHeight = numContentLines
-	}
-
-	return visibleHeight, maxScroll
+	return min(visibleHeight, numContentLines)
 }
 
 // getDayData returns the day name and data for a given day index.
-func (m model) getDayData(dayIndex int) (string, []HourlyData) {
+func (m model) getDayData(dayIndex int) (string, []zutool.HourlyData) {
 	switch dayIndex {
 	case 0:
 		return "Yesterday", m.weatherData.Yesterday
@@ -217,8 +292,8 @@ func (m model) getDayData(dayIndex int) (string, []HourlyData) {
 }
 
 // findCurrentRowIndex returns the index of the latest entry whose hour <= current hour.
-func findCurrentRowIndex(data []HourlyData) int {
-	now := time.Now().Hour()
+func findCurrentRowIndex(data []zutool.HourlyData) int {
+	now := clockNow().Hour()
 	best := 0
 	for i, entry := range data {
 		h, err := strconv.Atoi(strings.TrimSpace(entry.Time))
@@ -232,203 +307,301 @@ func findCurrentRowIndex(data []HourlyData) int {
 	return best
 }
 
-func (m model) extractHeadersAndContent(dayName string, data []HourlyData, highlightRow int) (string, string) {
+// tableHeader renders the day header and pressure sparkline above the table.
+func (m model) tableHeader(dayName string, data []zutool.HourlyData, labels []string) string {
 	if len(data) == 0 {
-		return "", ""
+		return ""
 	}
-
-	// appStyle has border(1 each side) + padding(2 each side) = 6 chars total horizontal overhead
-	colW := (m.width - 6) / numCols
-	tableWidth := colW * numCols
-	headers := dayHeaderStyle.Width(tableWidth).Render(fmt.Sprintf("%s - %s", m.weatherData.PlaceName, dayName)) +
-		"\n" + createTableHeaders(colW)
-
-	rows := make([]string, len(data))
-	for i, entry := range data {
-		hour, weather, temp, pressure := formatHourlyData(entry)
-		s := cellStyle
-		if i == highlightRow {
-			s = currentCellStyle
+	_, widths := m.tableLayout(labels)
+	tableWidth := 0
+	for _, w := range widths {
+		tableWidth += w
+	}
+	title := fmt.Sprintf("%s - %s", m.weatherData.PlaceName, tr(dayName))
+	if issued, ok := issuedAt(m.weatherData); ok {
+		if m.timelineDays == 0 {
+			title += " (" + formatDate(issued.AddDate(0, 0, m.currentDay-1)) + ")"
 		}
-		rows[i] = s.Width(colW).Render(hour) +
-			s.Width(colW).Render(weather) +
-			s.Width(colW).Render(temp) +
-			s.Width(colW).Render(pressure) +
-			s.Width(colW).Render(entry.PressureLevel)
+		title += trf(" · issued %s", formatHour(issued.Hour()))
 	}
-
-	return headers, strings.Join(rows, "\n")
+	titleStyle := dayHeaderStyle
+	if m.flashing {
+		titleStyle = titleStyle.Reverse(true)
+	}
+	header := titleStyle.Width(tableWidth).Render(ansi.Truncate(title, tableWidth-4, "…"))
+	if m.pain != nil {
+		header += "\n" + sparklineStyle.Width(tableWidth).Render(ansi.Truncate(m.painSummary(), tableWidth, "…"))
+	}
+	if !m.isCompact() {
+		header += "\n" + sparklineStyle.Width(tableWidth).Render(pressureSparkline(data, tableWidth))
+		if m.timelineDays == 0 {
+			header += "\n" + sparklineStyle.Width(tableWidth).Render(ansi.Truncate(daySummary(data), tableWidth, "…"))
+			if summary := m.dayOverDaySummary(data); m.dayOverDay && summary != "" {
+				header += "\n" + sparklineStyle.Width(tableWidth).Render(ansi.Truncate(summary, tableWidth, "…"))
+			}
+		}
+	}
+	return header
 }
 
 func newView(content string) tea.View {
-	v := tea.NewView(appStyle.Render(content))
+	return screenView(appStyle.Render(content))
+}
+
+// screenView returns the full-screen view of s, which already includes the
+// app frame.
+func screenView(s string) tea.View {
+	v := tea.NewView(s)
 	v.AltScreen = true
 	v.MouseMode = tea.MouseModeCellMotion
 	return v
 }
 
-func (m model) View() tea.View {
-	if m.err != nil {
-		return newView(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+// body renders the header of the current view and, unless the view is the
+// table, the content that scrolls below it. The header is empty when there
+// is no data to show.
+func (m model) body() (string, string) {
+	if m.historyMode {
+		return m.historyBody()
 	}
-	if m.loading {
-		return newView(loadingStyle.Render("Loading weather data...\nPlease wait"))
-	}
-
-	var allHeaders []string
-	var allContent string
-
 	switch strings.ToLower(m.dayFilter) {
 	case "", "yesterday", "today", "tomorrow", "dayafter":
-		dayName, dayData := m.getDayData(m.currentDay)
-		highlightRow := -1
-		if m.currentDay == 1 {
-			highlightRow = findCurrentRowIndex(dayData)
-		}
-		if headers, content := m.extractHeadersAndContent(dayName, dayData, highlightRow); headers != "" {
-			allHeaders = append(allHeaders, headers)
-			allContent = content
+		dayName, dayData, labels, highlightRow := m.currentView()
+		if m.graphMode {
+			return m.graphHeadersAndContent(dayName, dayData, highlightRow)
 		}
+		return m.tableHeader(dayName, dayData, labels), ""
 	default:
-		allContent = errorStyle.Render("Invalid day specified. Please use: yesterday, today, tomorrow, or dayafter")
+		return "", errorStyle.Render(tr("Invalid day specified. Please use: yesterday, today, tomorrow, or dayafter"))
 	}
+}
 
-	contentLines := strings.Split(allContent, "\n")
-	visibleHeight, maxScroll := calculateScrollParameters(m, len(allHeaders), len(contentLines))
-
-	if m.scrollPos < 0 {
-		m.scrollPos = 0
-	} else if m.scrollPos > maxScroll {
-		m.scrollPos = maxScroll
+// syncContent renders the current view into the table or the viewport and
+// sizes it to the space left by the header and footer. It runs after every
+// update so their bounds always match what View shows.
+func (m *model) syncContent() {
+	if m.loading || m.err != nil {
+		return
 	}
-
-	startPos := m.scrollPos
-	if startPos >= len(contentLines) && len(contentLines) > 0 {
-		startPos = len(contentLines) - 1
+	header, content := m.body()
+	headerLines := 0
+	if header != "" {
+		headerLines = lipgloss.Height(header)
 	}
-	endIdx := startPos + visibleHeight
-	if endIdx > len(contentLines) {
-		endIdx = len(contentLines)
+	if m.showsTable() {
+		m.syncTable(headerLines)
+		return
 	}
+	m.viewport.SetWidth(m.contentWidth())
+	m.viewport.SetHeight(contentHeight(*m, headerLines, lipgloss.Height(content)))
+	m.viewport.SetContent(content)
+}
 
-	var visibleContent string
-	if len(contentLines) > 0 && contentLines[0] != "" {
-		visibleContent = strings.Join(contentLines[startPos:endIdx], "\n")
+func (m model) View() tea.View {
+	view := appStyle.Render(m.content())
+	switch {
+	case m.diary:
+		view = overlay(view, m.diaryBox())
+	case m.prompt:
+		view = overlay(view, m.promptBox())
+	case m.columnPicker:
+		view = overlay(view, m.columnPickerBox())
+	case m.help:
+		view = overlay(view, m.helpBox())
+	case m.detail && m.showsTable():
+		if box, ok := m.detailBox(); ok {
+			view = overlay(view, box)
+		}
 	}
+	return screenView(view)
+}
 
-	var indicatorParts []string
-	if m.scrollPos > 0 && maxScroll > 0 {
-		indicatorParts = append(indicatorParts, "↑ More above")
+// content renders the screen inside the app frame.
+func (m model) content() string {
+	if m.err != nil {
+		text := errorStyle.Render(trf("Error: %s", trError(m.err)))
+		if status := m.retryStatus(); status != "" {
+			text += "\n\n" + statusStyle.Render(status)
+		}
+		return text + "\n" + footerStyle.Width(m.contentWidth()).Render(m.footerText())
 	}
-	if m.scrollPos < maxScroll {
-		indicatorParts = append(indicatorParts, "↓ More below")
+	if m.loading {
+		return loadingStyle.Render(tr("Loading weather data...\nPlease wait")) + "\n" + footerStyle.Width(m.contentWidth()).Render(m.footerText())
 	}
 
+	header, _ := m.body()
+	showsTable := m.showsTable()
+	tableWidth := m.contentWidth()
+	indicator := m.indicator()
+
 	var b strings.Builder
-	if len(indicatorParts) > 0 {
-		b.WriteString(strings.Join(indicatorParts, " | ") + "\n\n")
+	if m.stale != nil {
+		b.WriteString(m.bannerView() + "\n")
+	}
+	if now := m.nowBanner(); now != "" {
+		b.WriteString(now + "\n")
+	}
+	if m.showsTabs() {
+		bar, _ := m.tabBar()
+		b.WriteString(bar + "\n")
 	}
-	for i, header := range allHeaders {
+	if indicator != "" {
+		b.WriteString(indicator + "\n\n")
+	}
+	if header != "" {
 		b.WriteString(header + "\n")
-		if i == len(allHeaders)-1 && visibleContent != "" {
-			b.WriteString(visibleContent)
-		}
-		if i < len(allHeaders)-1 {
-			b.WriteString("\n\n")
-		}
 	}
-
-	var footerText string
-	if m.dayFilter == "" {
-		footerText = "←/→: Change day ↑/↓/Mouse wheel: Scroll \n PgUp/PgDn: Scroll faster  Home/End: Jump to top/bottom  q: Quit"
-	} else {
-		footerText = "↑/↓/Mouse wheel: Scroll PgUp/PgDn: Scroll faster \n Home/End: Jump to top/bottom  q: Quit"
+	switch {
+	case showsTable && len(m.table.Rows()) == 0:
+		b.WriteString(cellStyle.Render(trf("No hours at level %d or above", m.minLevel)))
+	case showsTable:
+		b.WriteString(m.table.View())
+	default:
+		b.WriteString(m.viewport.View())
 	}
-	tableWidth := ((m.width - 6) / numCols) * numCols
-	b.WriteString("\n" + footerStyle.Width(tableWidth).Render(footerText))
 
-	return newView(b.String())
+	b.WriteString("\n" + statusStyle.Width(tableWidth).Render(ansi.Truncate(m.statusBar(clockNow()), tableWidth, "…")))
+	b.WriteString("\n" + footerStyle.Width(tableWidth).Render(m.footerText()))
+	return b.String()
 }
 
-func safeGetString(data map[string]interface{}, key string) string {
-	if value, exists := data[key]; exists {
-		return fmt.Sprintf("%v", value)
-	}
-	return ""
+var apiClient = zutool.NewClient(nil)
+
+func fetchWeatherData(ctx context.Context, areaCode string) (zutool.WeatherData, error) {
+	w, err := loadWeather(ctx, areaCode, true)
+	return w.data, err
 }
 
-func parseHourlyData(data interface{}) []HourlyData {
-	var result []HourlyData
+// weatherResult is a forecast and where it came from.
+type weatherResult struct {
+	data      zutool.WeatherData
+	fetchedAt time.Time
+	// stale is why a cached forecast past its TTL was used: errOffline, or
+	// the error of the request that failed. It is nil for fresh data.
+	stale error
+	// fallback names the source the forecast came from when the configured
+	// one failed and had nothing cached; stale is then its error.
+	fallback string
+	// cached is set when the forecast was read from the cache instead of
+	// fetched.
+	cached bool
+	// substitute is the area code whose forecast is shown with -nearest
+	// because the one asked for has none.
+	substitute string
+}
 
-	hourlyArray, ok := data.([]interface{})
+// errOffline marks forecasts read from the cache because of -offline.
+var errOffline = errors.New("offline mode")
+
+// offlineMode reads forecasts only from the cache, never from the API.
+var offlineMode bool
+
+// useNearest shows the forecast of the nearest known area when an area code
+// has none, as -nearest does; see nearestKnownArea.
+var useNearest bool
+
+// loadWeather returns the forecast of areaCode. With useCache set a cached
+// forecast younger than cacheTTL is returned without asking the API; every
+// forecast fetched is cached. When the request fails, or in offline mode,
+// the last cached forecast is returned however old it is. An area code
+// without a forecast names the nearest known area in its error, or has its
+// forecast stand in with useNearest.
+func loadWeather(ctx context.Context, areaCode string, useCache bool) (weatherResult, error) {
+	w, err := loadAreaWeather(ctx, areaCode, useCache)
+	if !errors.Is(err, zutool.ErrAreaNotFound) {
+		return w, err
+	}
+	a, ok := nearestKnownArea(areaCode)
 	if !ok {
-		return result
+		return weatherResult{}, areaNotFoundError(fmt.Sprintf("area code %s not found — try `goHeadache search <city>`", areaCode))
 	}
-
-	for _, item := range hourlyArray {
-		hourlyMap, ok := item.(map[string]interface{})
-		if !ok {
-			continue
-		}
-		result = append(result, HourlyData{
-			Time:          safeGetString(hourlyMap, "time"),
-			Weather:       safeGetString(hourlyMap, "weather"),
-			Temp:          safeGetString(hourlyMap, "temp"),
-			Pressure:      safeGetString(hourlyMap, "pressure"),
-			PressureLevel: safeGetString(hourlyMap, "pressure_level"),
-		})
+	if !useNearest {
+		return weatherResult{}, areaNotFoundError(fmt.Sprintf("area code %s not found — the nearest known area is %s %s (pass -nearest to show it), or try `goHeadache search <city>`", areaCode, a.Code, a.FullName()))
 	}
-
-	return result
+	w, subErr := loadAreaWeather(ctx, a.Code, useCache)
+	if subErr != nil {
+		debugLog.Warn("nearest area failed", "area", areaCode, "nearest", a.Code, "error", subErr)
+		return weatherResult{}, areaNotFoundError(fmt.Sprintf("area code %s not found, nor the nearest known area %s %s — try `goHeadache search <city>`", areaCode, a.Code, a.FullName()))
+	}
+	w.substitute = a.Code
+	return w, nil
 }
 
-func fetchWeatherData(areaCode string) (WeatherData, error) {
-	url := fmt.Sprintf("https://zutool.jp/api/getweatherstatus/%s", areaCode)
+// areaNotFoundError is the error of loadWeather for an area code without a
+// forecast, saying what to try instead. It is zutool.ErrAreaNotFound to
+// errors.Is, while its message stays the one the catalogs translate.
+type areaNotFoundError string
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return WeatherData{}, fmt.Errorf("error making GET request: %v", err)
+func (e areaNotFoundError) Error() string { return string(e) }
+
+func (e areaNotFoundError) Unwrap() error { return zutool.ErrAreaNotFound }
+
+// loadAreaWeather is loadWeather without standing in for area codes without
+// a forecast, whose error is zutool.ErrAreaNotFound.
+func loadAreaWeather(ctx context.Context, areaCode string, useCache bool) (weatherResult, error) {
+	if fixtureData != nil {
+		return weatherResult{data: *fixtureData, fetchedAt: clockNow()}, nil
 	}
-	defer func() {
-		if cerr := resp.Body.Close(); cerr != nil {
-			fmt.Printf("Error closing response body: %v\n", cerr)
+	if offlineMode {
+		c, err := readCache(areaCode)
+		if err != nil {
+			debugLog.Warn("no cached forecast offline", "area", areaCode, "error", err)
+			return weatherResult{}, fmt.Errorf("no cached forecast for area code %s (run once without -offline to fetch one)", areaCode)
 		}
-	}()
-
-	body, err := io.ReadAll(resp.Body)
+		return weatherResult{c.Data, c.FetchedAt, errOffline, "", true, ""}, nil
+	}
+	if useCache && cacheTTL > 0 {
+		c, err := readCache(areaCode)
+		if age := clockNow().Sub(c.FetchedAt); err == nil && age < cacheTTL {
+			debugLog.Debug("cache hit", "area", areaCode, "age", age)
+			return weatherResult{c.Data, c.FetchedAt, nil, "", true, ""}, nil
+		}
+		debugLog.Debug("cache miss", "area", areaCode, "error", err)
+	}
+	loc, err := locationOf(areaCode)
 	if err != nil {
-		return WeatherData{}, fmt.Errorf("error reading response body: %v", err)
+		return weatherResult{}, err
 	}
-
-	var rawData map[string]interface{}
-	if err := json.Unmarshal(body, &rawData); err != nil {
-		return WeatherData{}, fmt.Errorf("error parsing JSON: %v", err)
+	data, err := weatherSource.Fetch(ctx, loc)
+	if errors.Is(err, zutool.ErrAreaNotFound) {
+		return weatherResult{}, err
 	}
-
-	weatherData := WeatherData{
-		PlaceName:     safeGetString(rawData, "place_name"),
-		PlaceID:       safeGetString(rawData, "place_id"),
-		PrefecturesID: safeGetString(rawData, "prefectures_id"),
-		DateTime:      safeGetString(rawData, "dateTime"),
+	if err != nil {
+		debugLog.Warn("fetch failed", "area", areaCode, "source", weatherSource.Name(), "error", err)
+		if c, cacheErr := readCache(areaCode); cacheErr == nil && ctx.Err() == nil {
+			return weatherResult{c.Data, c.FetchedAt, err, "", true, ""}, nil
+		}
+		if fallback, w, ok := fallbackForecast(ctx, loc); ok {
+			return weatherResult{w, clockNow(), err, fallback, false, ""}, nil
+		}
+		return weatherResult{}, err
 	}
+	// Forecasts are fetched at the time the screens take as now, so that
+	// their age shows the same with -now. The history keeps the real time.
+	fetchedAt := clockNow()
+	// The cache only saves requests; failing to write it is not an error.
+	_ = writeCache(areaCode, data, fetchedAt)
+	recordHistory(areaCode, data, time.Now())
+	return weatherResult{data, fetchedAt, nil, "", false, ""}, nil
+}
 
-	if yesterday, exists := rawData["yesterday"]; exists {
-		weatherData.Yesterday = parseHourlyData(yesterday)
+// fallbackForecast fetches the Open-Meteo forecast at the coordinates of
+// an area from the embedded area list, for when zutool is down. It returns
+// the name of the source used. The result is not cached, so zutool's
+// forecast replaces it once it is up.
+func fallbackForecast(ctx context.Context, loc source.Location) (string, zutool.WeatherData, bool) {
+	if !usesZutool() || !loc.HasCoords || ctx.Err() != nil {
+		return "", zutool.WeatherData{}, false
 	}
-	if today, exists := rawData["today"]; exists {
-		weatherData.Today = parseHourlyData(today)
-	}
-	if tomorrow, exists := rawData["tomorrow"]; exists {
-		weatherData.Tomorrow = parseHourlyData(tomorrow)
-	} else if tomorrow, exists := rawData["tommorow"]; exists {
-		// Handle the misspelled version from the API
-		weatherData.Tomorrow = parseHourlyData(tomorrow)
+	src := source.OpenMeteo{Client: openMeteoClient}
+	w, err := src.Fetch(ctx, source.Location{Lat: loc.Lat, Lon: loc.Lon, HasCoords: true})
+	if err != nil {
+		return "", zutool.WeatherData{}, false
 	}
-	if dayAfterTom, exists := rawData["dayaftertomorrow"]; exists {
-		weatherData.DayAfterTom = parseHourlyData(dayAfterTom)
+	if a, ok := areas.Lookup(loc.AreaCode); ok {
+		w.PlaceName = a.FullName()
 	}
-
-	return weatherData, nil
+	return src.Name(), w, true
 }
 
 func initialModel(areaCode, dayFilter string) model {
@@ -444,146 +617,443 @@ func initialModel(areaCode, dayFilter string) model {
 		currentDay = 3
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
 	return model{
-		dayFilter:  dayFilter,
-		areaCode:   areaCode,
-		loading:    true,
-		currentDay: currentDay,
-		width:      80,
-		height:     24,
+		dayFilter:   dayFilter,
+		areaCode:    areaCode,
+		loading:     true,
+		currentDay:  currentDay,
+		table:       newTable(),
+		hours:       wakingHours,
+		viewport:    newViewport(),
+		width:       80,
+		height:      24,
+		fetchCtx:    ctx,
+		cancelFetch: cancel,
 	}
 }
 
+// newViewport returns the viewport of the views other than the table. Left
+// and right change the day instead of scrolling sideways, and space, f, b, d
+// and u are left free for other commands.
+func newViewport() viewport.Model {
+	vp := viewport.New()
+	vp.KeyMap = viewport.KeyMap{
+		PageDown:     key.NewBinding(key.WithKeys("pgdown")),
+		PageUp:       key.NewBinding(key.WithKeys("pgup")),
+		HalfPageDown: key.NewBinding(key.WithKeys("ctrl+d")),
+		HalfPageUp:   key.NewBinding(key.WithKeys("ctrl+u")),
+		Up:           key.NewBinding(key.WithKeys("up", "k")),
+		Down:         key.NewBinding(key.WithKeys("down", "j")),
+		Left:         key.NewBinding(key.WithDisabled()),
+		Right:        key.NewBinding(key.WithDisabled()),
+	}
+	vp.MouseWheelDelta = 1
+	return vp
+}
+
 // Init starts the model with a command to fetch weather data.
 func (m model) Init() tea.Cmd {
-	return fetchWeatherCmd(m.areaCode)
+	fetch := fetchWeatherCmd(m.fetchCtx, m.fetchID, m.areaCode, true)
+	if m.watchInterval > 0 {
+		return tea.Batch(fetch, refreshTickCmd(m.watchInterval), statusTickCmd())
+	}
+	return fetch
+}
+
+type refreshTickMsg struct{}
+
+// refreshTickCmd schedules the next watch-mode refresh.
+func refreshTickCmd(interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return refreshTickMsg{}
+	})
 }
 
-func fetchWeatherCmd(areaCode string) tea.Cmd {
+// fetchWeatherCmd fetches the forecast of areaCode, from the cache when
+// useCache is set and it is fresh. id tags the result so that a response to
+// a superseded fetch can be told apart and dropped.
+func fetchWeatherCmd(ctx context.Context, id int, areaCode string, useCache bool) tea.Cmd {
 	return func() tea.Msg {
-		weatherData, err := fetchWeatherData(areaCode)
+		f, err := fetchForecast(ctx, areaCode, useCache)
 		if err != nil {
-			return fetchErrorMsg{err}
+			return fetchErrorMsg{id, err}
 		}
-		return fetchSuccessMsg{weatherData}
+		return fetchSuccessMsg{id, f}
 	}
 }
 
 type fetchSuccessMsg struct {
-	weatherData WeatherData
+	id int
+	forecastData
 }
 
 type fetchErrorMsg struct {
+	id  int
 	err error
 }
 
-func (m model) maxScroll() int {
-	_, dayData := m.getDayData(m.currentDay)
-	_, maxPos := calculateScrollParameters(m, 1, len(dayData))
-	return maxPos
+// fetch cancels the fetch in flight, if any, and starts a new one that may
+// be answered from the cache.
+func (m *model) fetch() tea.Cmd {
+	return m.startFetch(true)
+}
+
+// refresh is fetch bypassing the cache, for watch mode.
+func (m *model) refresh() tea.Cmd {
+	return m.startFetch(false)
+}
+
+func (m *model) startFetch(useCache bool) tea.Cmd {
+	m.cancelFetch()
+	m.fetchCtx, m.cancelFetch = context.WithCancel(context.Background())
+	m.fetchID++
+	return fetchWeatherCmd(m.fetchCtx, m.fetchID, m.areaCode, useCache)
+}
+
+// quit cancels the fetch in flight, saves the session and exits.
+func (m model) quit() (model, tea.Cmd) {
+	m.cancelFetch()
+	m.saveSession()
+	return m, tea.Quit
+}
+
+// gotoTop selects the first row of the table and scrolls the viewport to
+// the top.
+func (m *model) gotoTop() {
+	m.selectRow(0)
+	m.viewport.GotoTop()
+}
+
+// indicator tells where the table or the viewport is scrolled to, if it
+// does not show everything.
+func (m model) indicator() string {
+	if m.showsTable() {
+		if len(m.table.Rows()) > m.table.Height() {
+			return trf("↑/↓ Row %d of %d", m.table.Cursor()+1, len(m.table.Rows()))
+		}
+		return ""
+	}
+	var parts []string
+	if !m.viewport.AtTop() {
+		parts = append(parts, tr("↑ More above"))
+	}
+	if !m.viewport.AtBottom() {
+		parts = append(parts, tr("↓ More below"))
+	}
+	return strings.Join(parts, " | ")
+}
+
+// bannerView renders the stale data banner.
+func (m model) bannerView() string {
+	width := m.contentWidth()
+	return bannerStyle.Width(width).Render(ansi.Truncate(m.staleBanner(), width-2, "…"))
+}
+
+// staleBanner explains why an old forecast is shown and how old it is.
+func (m model) staleBanner() string {
+	age := formatAge(clockNow().Sub(m.lastUpdated))
+	if errors.Is(m.stale, errOffline) {
+		return trf("Offline · forecast from %s ago", age)
+	}
+	if m.fallback != "" {
+		return trf("%s forecast for the area's coordinates · %s failed: %s", m.fallback, weatherSource.Name(), trError(m.stale))
+	}
+	return trf("Stale data from %s ago · update failed: %s", age, trError(m.stale))
+}
+
+// formatAge renders a duration roughly, such as "5m", "2h" or "3d".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+// switchLocation loads the saved location at index i.
+func (m model) switchLocation(i int) (model, tea.Cmd) {
+	if i >= len(m.locations) {
+		return m, nil
+	}
+	return m.switchArea(m.locations[i].Area)
+}
+
+// switchArea loads the forecast of area code, unless it is the one shown.
+func (m model) switchArea(code string) (model, tea.Cmd) {
+	if code == m.areaCode {
+		return m, nil
+	}
+	m.areaCode = code
+	m.weatherData = zutool.WeatherData{}
+	m.err = nil
+	m.retries = 0
+	m.retryAt = time.Time{}
+	m.alerted = false
+	m.loading = true
+	m.gotoTop()
+	return m, m.fetch()
+}
+
+// footerText returns the key hints, wrapped to the content width.
+func (m model) footerText() string {
+	text := m.screenKeys().footer(m.contentWidth(), m.isCompact())
+	if len(m.locations) > 0 {
+		text += "\n" + packHints(m.locationHints(), m.contentWidth())
+	}
+	return text
+}
+
+// packHints joins hints into as few lines of at most width cells as possible.
+func packHints(hints []string, width int) string {
+	var lines []string
+	line := ""
+	for _, h := range hints {
+		switch {
+		case line == "":
+			line = h
+		case lipgloss.Width(line)+2+lipgloss.Width(h) <= width:
+			line += "  " + h
+		default:
+			lines = append(lines, line)
+			line = h
+		}
+	}
+	return strings.Join(append(lines, line), "\n")
+}
+
+// locationHints lists the saved locations for the footer, marking the active one.
+func (m model) locationHints() []string {
+	parts := make([]string, 0, len(m.locations))
+	for i, loc := range m.locations {
+		if i == 9 {
+			break
+		}
+		label := fmt.Sprintf("alt+%d: %s", i+1, loc.Name)
+		if loc.Area == m.areaCode {
+			label = "[" + label + "]"
+		}
+		parts = append(parts, label)
+	}
+	return parts
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	logMessage(msg)
+	m, cmd := m.update(msg)
+	m.syncContent()
+	return m, cmd
+}
+
+func (m model) update(msg tea.Msg) (model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 		return m, nil
+	case tea.MouseClickMsg:
+		mouse := msg.Mouse()
+		if m.detail || m.help || m.diary || m.prompt || m.columnPicker || mouse.Button != tea.MouseLeft {
+			return m, nil
+		}
+		return m.click(mouse.X, mouse.Y), nil
 	case tea.MouseWheelMsg:
-		switch msg.Button {
-		case tea.MouseWheelUp:
-			if m.scrollPos > 0 {
-				m.scrollPos--
+		if m.detail || m.help || m.diary || m.prompt || m.columnPicker {
+			return m, nil
+		}
+		if m.showsTable() {
+			switch msg.Button {
+			case tea.MouseWheelUp:
+				m.table.MoveUp(1)
+			case tea.MouseWheelDown:
+				m.table.MoveDown(1)
 			}
-		case tea.MouseWheelDown:
-			m.scrollPos++
+			return m, nil
 		}
-		return m, nil
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "ctrl+c":
-			return m, tea.Quit
-		case "up", "k":
-			if m.scrollPos > 0 {
-				m.scrollPos--
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	case tea.KeyPressMsg:
+		if m.diary {
+			return m.updateDiary(msg)
+		}
+		if m.prompt {
+			return m.updatePrompt(msg)
+		}
+		if m.columnPicker {
+			return m.updateColumnPicker(msg)
+		}
+		if m.help {
+			switch msg.String() {
+			case "q", "ctrl+c":
+				return m.quit()
+			case "?", "esc":
+				m.help = false
+			}
+			return m, nil
+		}
+		if m.detail {
+			switch msg.String() {
+			case "q", "ctrl+c":
+				return m.quit()
+			case "enter", "esc":
+				m.detail = false
 			}
-		case "down", "j":
-			if m.scrollPos < m.maxScroll() {
-				m.scrollPos++
+			return m, nil
+		}
+		if m.pendingKey != "" {
+			var done bool
+			if m, done = m.continueSequence(msg); done {
+				return m, nil
 			}
+		}
+		if m.historyMode {
+			return m.updateHistory(msg)
+		}
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m.quit()
+		case "r":
+			return m.retry()
+		case "enter":
+			m.detail = m.showsTable()
+		case "?":
+			m.help = true
 		case "left", "h":
-			if m.dayFilter == "" && m.currentDay > 0 {
+			if m.dayFilter == "" && m.timelineDays == 0 && m.currentDay > 0 {
 				m.currentDay--
-				m.scrollPos = 0
+				m.gotoTop()
 			}
 		case "right", "l":
-			if m.dayFilter == "" && m.currentDay < 3 {
+			if m.dayFilter == "" && m.timelineDays == 0 && m.currentDay < 3 {
 				m.currentDay++
-				m.scrollPos = 0
+				m.gotoTop()
 			}
 		case "home":
-			m.scrollPos = 0
-		case "end":
-			m.scrollPos = m.maxScroll()
-		case "pageup":
-			m.scrollPos -= 10
-			if m.scrollPos < 0 {
-				m.scrollPos = 0
+			m.gotoTop()
+		case "end", "G":
+			m.gotoBottom()
+		case "g":
+			return m.startSequence("g")
+		case "t":
+			m = m.cycleTimeline()
+		case "n":
+			m = m.toggleNight()
+		case "f":
+			m = m.cycleLevelFilter()
+		case "s":
+			m = m.cycleSort()
+		case "c":
+			if m.showsTable() {
+				m = m.openColumnPicker()
+			}
+		case "d":
+			if m.showsTable() {
+				m = m.toggleDayOverDay()
+			}
+		case "H":
+			return m.openHistory()
+		case "L":
+			return m.openDiary()
+		case ":", "/":
+			return m.openPrompt()
+		case "1", "2", "3", "4":
+			if m.dayFilter == "" {
+				m.selectDay(int(msg.String()[0] - '1'))
+			}
+		case "alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9":
+			return m.switchLocation(int(msg.String()[len("alt+")] - '1'))
+		default:
+			var cmd tea.Cmd
+			if m.showsTable() {
+				m.table, cmd = m.table.Update(msg)
+			} else {
+				m.viewport, cmd = m.viewport.Update(msg)
 			}
-		case "pagedown":
-			m.scrollPos += 10
+			return m, cmd
 		}
 		return m, nil
 	case fetchSuccessMsg:
-		m.weatherData = msg.weatherData
+		if msg.id != m.fetchID {
+			return m, nil
+		}
+		m.weatherData = msg.weather.data
+		m.lastUpdated = msg.weather.fetchedAt
+		m.stale = msg.weather.stale
+		m.fallback = msg.weather.fallback
+		m.cached = msg.weather.cached
+		m.substitute = msg.weather.substitute
+		m.pain = msg.pain
+		m.rain = msg.rain
+		m.err = nil
+		m.refreshErr = nil
+		m.retries = 0
+		wasLoading := m.loading
 		m.loading = false
-		if m.currentDay == 1 {
-			m.scrollPos = findCurrentRowIndex(m.weatherData.Today)
+		if wasLoading && m.currentDay == 1 {
+			m.syncContent()
+			m.selectRow(m.rowOf(m.weatherData.Today, findCurrentRowIndex(m.weatherData.Today)))
+		}
+		return m, tea.Batch(m.checkWarning(), m.checkScreenAlert())
+	case keySequenceMsg:
+		if msg.id == m.keySeqID && m.pendingKey != "" {
+			m = m.applyPending(m.pendingKey)
+			m.pendingKey = ""
 		}
 		return m, nil
+	case flashEndMsg:
+		m.flashing = false
+		return m, nil
+	case historyLoadedMsg:
+		return m.historyLoaded(msg), nil
+	case diarySavedMsg:
+		return m.diarySaved(msg), nil
+	case placesFoundMsg:
+		return m.placesFound(msg)
+	case notifyResultMsg:
+		m.notifyErr = msg.err
+		return m, nil
 	case fetchErrorMsg:
+		if msg.id != m.fetchID {
+			return m, nil
+		}
+		if !m.loading && m.err == nil {
+			// A watch-mode refresh failed; keep showing the previous data.
+			m.refreshErr = msg.err
+			return m, nil
+		}
 		m.err = msg.err
 		m.loading = false
 		return m, nil
-	}
-	return m, nil
-}
-
-func main() {
-	fs := flag.NewFlagSet("goHeadache", flag.ExitOnError)
-	dayFlag := fs.String("day", "", "Filter output by day (yesterday, today, tomorrow, dayafter)")
-
-	if len(os.Args) < 2 {
-		fmt.Println("Usage:  goHeadache <area_code> [-day <day>]")
-		fmt.Println("\nOptions:")
-		fmt.Println("  -day: yesterday, today, tomorrow, or dayafter")
-		fmt.Println("\nPlease visit https://geoshape.ex.nii.ac.jp/ka/resource/ to find the appropriate area code.")
-		return
-	}
-
-	var areaCode string
-	var args []string
-	for _, arg := range os.Args[1:] {
-		if !strings.HasPrefix(arg, "-") && areaCode == "" {
-			areaCode = arg
-		} else {
-			args = append(args, arg)
+	case retryTickMsg:
+		return m.retryTick()
+	case refreshTickMsg:
+		m.nextRefresh = clockNow().Add(m.watchInterval)
+		if m.loading {
+			return m, refreshTickCmd(m.watchInterval)
 		}
+		return m, tea.Batch(m.refresh(), refreshTickCmd(m.watchInterval))
+	case statusTickMsg:
+		return m, statusTickCmd()
 	}
-
-	if err := fs.Parse(args); err != nil {
-		fmt.Printf("Error parsing flags: %v\n", err)
-		return
+	if m.diary {
+		// Let the popup's cursor blink.
+		var cmd tea.Cmd
+		m.diaryInput, cmd = m.diaryInput.Update(msg)
+		return m, cmd
 	}
-
-	if areaCode == "" {
-		fmt.Println("Error: Area code is required")
-		return
+	if m.prompt {
+		var cmd tea.Cmd
+		m.promptInput, cmd = m.promptInput.Update(msg)
+		return m, cmd
 	}
+	return m, nil
+}
 
-	p := tea.NewProgram(initialModel(areaCode, *dayFlag))
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error running program: %v\n", err)
-		os.Exit(1)
-	}
+func main() {
+	os.Exit(run(os.Args[1:]))
 }
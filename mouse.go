@@ -0,0 +1,147 @@
+package main
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/table"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// The content starts inside appStyle's top border, and after its left
+// border and padding.
+const (
+	contentTop  = 1
+	contentLeft = 2
+)
+
+// screenLayout is where the parts of the forecast screen that take clicks
+// are: the screen rows they start at, -1 for the parts not shown.
+type screenLayout struct {
+	tabs, indicator int
+	// body is the first row of the table, below its column titles, or of
+	// the viewport.
+	body int
+}
+
+// layout returns where content puts the parts of the screen.
+func (m model) layout() screenLayout {
+	l := screenLayout{tabs: -1, indicator: -1, body: -1}
+	if m.loading || m.err != nil {
+		return l
+	}
+	y := contentTop
+	if m.stale != nil {
+		y += lipgloss.Height(m.bannerView())
+	}
+	if m.nowBanner() != "" {
+		y++
+	}
+	if m.showsTabs() {
+		l.tabs = y
+		y++
+	}
+	if m.indicator() != "" {
+		// The indicator is followed by a blank line.
+		l.indicator = y
+		y += 2
+	}
+	if header, _ := m.body(); header != "" {
+		y += lipgloss.Height(header)
+	}
+	if m.showsTable() {
+		y++
+	}
+	l.body = y
+	return l
+}
+
+// click handles a left click at column x and row y of the screen: a day
+// tab shows that day, an arrow of the scroll indicator pages up or down and
+// a row of the table opens its details.
+func (m model) click(x, y int) model {
+	l := m.layout()
+	x -= contentLeft
+	switch {
+	case y == l.tabs:
+		if day := m.tabAt(x); day >= 0 {
+			m.selectDay(day)
+		}
+	case y == l.indicator:
+		m.page(indicatorUp(ansi.Strip(m.indicator()), x))
+	case l.body >= 0 && y >= l.body && m.showsTable():
+		line := y - l.body
+		row := m.firstVisibleRow() + line
+		if line >= m.table.Height() || row >= len(m.table.Rows()) {
+			break
+		}
+		if cursor := m.table.Cursor(); row < cursor {
+			m.table.MoveUp(cursor - row)
+		} else {
+			m.table.MoveDown(row - cursor)
+		}
+		m.detail = true
+	}
+	return m
+}
+
+// indicatorUp reports whether column x of the scroll indicator text is on
+// its up arrow: the last arrow at or before x, or the first after it, so
+// that "↑ More above" pages up and "↓ More below" down.
+func indicatorUp(text string, x int) bool {
+	up, found := true, false
+	col := 0
+	for _, r := range text {
+		if col > x && found {
+			break
+		}
+		if r == '↑' || r == '↓' {
+			up, found = r == '↑', true
+		}
+		col += ansi.StringWidth(string(r))
+	}
+	return up
+}
+
+// page moves the table selection or scrolls the viewport a page up or down.
+func (m *model) page(up bool) {
+	switch {
+	case m.showsTable() && up:
+		m.table.MoveUp(m.table.Height())
+	case m.showsTable():
+		m.table.MoveDown(m.table.Height())
+	case up:
+		m.viewport.PageUp()
+	default:
+		m.viewport.PageDown()
+	}
+}
+
+// firstVisibleRow returns the index of the top row the table shows. The
+// table keeps its scroll offset to itself, so it is found by matching the
+// lines of its view with the rows, starting from the highest row that would
+// still show the cursor.
+func (m model) firstVisibleRow() int {
+	lines := strings.Split(m.table.View(), "\n")[1:]
+	rows := m.table.Rows()
+	cursor := m.table.Cursor()
+	for first := max(cursor-len(lines)+1, 0); first < cursor; first++ {
+		if rowsShown(lines, rows[first:]) {
+			return first
+		}
+	}
+	return cursor
+}
+
+// rowsShown reports whether lines show rows from the first.
+func rowsShown(lines []string, rows []table.Row) bool {
+	for i, line := range lines {
+		if i == len(rows) {
+			return strings.TrimSpace(ansi.Strip(strings.Join(lines[i:], ""))) == ""
+		}
+		if strings.TrimRight(ansi.Strip(line), " ") != strings.TrimRight(ansi.Strip(strings.Join(rows[i], "")), " ") {
+			return false
+		}
+	}
+	return true
+}
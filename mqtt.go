@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"goHeadache/pkg/mqtt"
+)
+
+// MQTTConfig is the [mqtt] section of the config file: a broker the daemon
+// publishes the forecast of its areas to.
+type MQTTConfig struct {
+	// Broker is a URL such as "tcp://localhost:1883" or
+	// "mqtts://broker.example.com:8883". Empty publishes nothing.
+	Broker   string `toml:"broker"`
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+	// ClientID defaults to goheadache-<hostname>.
+	ClientID string `toml:"client_id"`
+	// Topic is the prefix of the state topics, <topic>/<area code>/state.
+	Topic string `toml:"topic"`
+	// Retain keeps the last state on the broker for new subscribers.
+	Retain bool `toml:"retain"`
+	// Hours is how far ahead the upcoming maximums look.
+	Hours int `toml:"hours"`
+	// Discovery publishes Home Assistant MQTT discovery messages, so that
+	// every area shows up as a device with sensors.
+	Discovery       bool   `toml:"discovery"`
+	DiscoveryPrefix string `toml:"discovery_prefix"`
+}
+
+var defaultMQTTConfig = MQTTConfig{Topic: "goheadache", Retain: true, Hours: 6, DiscoveryPrefix: "homeassistant"}
+
+// mqttState is the JSON published to the state topic of an area. Values the
+// forecast lacks are left out.
+type mqttState struct {
+	AreaCode       string   `json:"area_code"`
+	Place          string   `json:"place"`
+	Time           string   `json:"time"` // start of the current hour
+	Pressure       *float64 `json:"pressure,omitempty"`
+	PressureLevel  *int     `json:"pressure_level,omitempty"`
+	LevelName      string   `json:"level_name,omitempty"`
+	Temperature    *float64 `json:"temperature,omitempty"`
+	PressureChange *float64 `json:"pressure_change,omitempty"`
+	Risk           *int     `json:"risk,omitempty"`
+	// The maximums of the next Hours, and when they are reached.
+	MaxLevel   *int   `json:"max_level,omitempty"`
+	MaxLevelAt string `json:"max_level_at,omitempty"`
+	MaxRisk    *int   `json:"max_risk,omitempty"`
+	MaxRiskAt  string `json:"max_risk_at,omitempty"`
+	// MaxDrop is the largest pressure fall within the next Hours, in hPa.
+	MaxDrop   float64 `json:"max_drop"`
+	FetchedAt string  `json:"fetched_at"`
+	// Stale is set when the forecast is an old cached one because the
+	// source failed.
+	Stale bool `json:"stale"`
+}
+
+// newMQTTState returns the state of an area from its forecast.
+func newMQTTState(code string, w weatherResult, weights RiskWeights, hours int, now time.Time) mqttState {
+	s := mqttState{
+		AreaCode:  code,
+		Place:     w.data.PlaceName,
+		Time:      now.Truncate(time.Hour).Format(time.RFC3339),
+		FetchedAt: w.fetchedAt.Format(time.RFC3339),
+		Stale:     w.stale != nil,
+	}
+	all, data := forecastHours(w.data, now)
+	deltas, deltaOK := pressureDeltas(nil, data)
+	risks := riskScores(weights, data, deltas, deltaOK)
+	current := now.Truncate(time.Hour)
+	var upcoming []upcomingHour
+	for i, u := range all {
+		if !u.within(now, hours) {
+			continue
+		}
+		upcoming = append(upcoming, u)
+		level, levelErr := strconv.Atoi(strings.TrimSpace(u.entry.PressureLevel))
+		if u.at.Equal(current) {
+			if p, ok := hourPressure(u); ok {
+				s.Pressure = &p
+			}
+			if levelErr == nil {
+				s.PressureLevel = &level
+				s.LevelName = levelName(u.entry.PressureLevel)
+			}
+			if t, err := strconv.ParseFloat(strings.TrimSpace(u.entry.Temp), 64); err == nil {
+				s.Temperature = &t
+			}
+			if u.deltaOK {
+				change := roundTenth(u.delta)
+				s.PressureChange = &change
+			}
+			s.Risk = &risks[i]
+		}
+		if levelErr == nil && (s.MaxLevel == nil || level > *s.MaxLevel) {
+			s.MaxLevel, s.MaxLevelAt = &level, u.at.Format(time.RFC3339)
+		}
+		if s.MaxRisk == nil || risks[i] > *s.MaxRisk {
+			s.MaxRisk, s.MaxRiskAt = &risks[i], u.at.Format(time.RFC3339)
+		}
+	}
+	if _, _, drop, ok := largestDrop(upcoming); ok {
+		s.MaxDrop = roundTenth(drop)
+	}
+	return s
+}
+
+// roundTenth rounds v to 0.1, the precision of the forecast pressure, so
+// differences carry no float noise.
+func roundTenth(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// mqttSensor is a Home Assistant sensor made from a field of mqttState.
+type mqttSensor struct {
+	key, name   string
+	unit        string
+	deviceClass string
+	icon        string
+}
+
+var mqttSensors = []mqttSensor{
+	{key: "pressure", name: "Pressure", unit: "hPa", deviceClass: "atmospheric_pressure"},
+	{key: "pressure_level", name: "Pressure level", icon: "mdi:head-alert"},
+	{key: "pressure_change", name: "Pressure change", unit: "hPa", icon: "mdi:trending-down"},
+	{key: "temperature", name: "Temperature", unit: "°C", deviceClass: "temperature"},
+	{key: "risk", name: "Headache risk", icon: "mdi:head-alert"},
+	{key: "max_risk", name: "Upcoming headache risk", icon: "mdi:head-alert"},
+	{key: "max_drop", name: "Upcoming pressure drop", unit: "hPa", icon: "mdi:trending-down"},
+}
+
+// mqttPublisher publishes the state of the daemon's areas to a broker.
+type mqttPublisher struct {
+	cfg  MQTTConfig
+	opts mqtt.Options
+	// announced are the areas whose discovery messages have been published
+	// since the daemon started.
+	announced map[string]bool
+}
+
+// newMQTTPublisher returns the publisher of cfg, or nil when it has no
+// broker. TLS brokers are verified like API requests, with ca_file and
+// -insecure.
+func newMQTTPublisher(cfg MQTTConfig, client *http.Client) (*mqttPublisher, error) {
+	if cfg.Broker == "" {
+		return nil, nil
+	}
+	if _, _, err := mqtt.ParseBroker(cfg.Broker); err != nil {
+		return nil, fmt.Errorf("[mqtt]: %v", err)
+	}
+	if cfg.Hours < 0 || cfg.Hours > maxRuleWindow {
+		return nil, fmt.Errorf("[mqtt]: hours must be between 0 and %d", maxRuleWindow)
+	}
+	cfg.Topic = strings.Trim(cfg.Topic, "/")
+	if cfg.Topic == "" {
+		cfg.Topic = defaultMQTTConfig.Topic
+	}
+	if cfg.DiscoveryPrefix == "" {
+		cfg.DiscoveryPrefix = defaultMQTTConfig.DiscoveryPrefix
+	}
+	id := cfg.ClientID
+	if id == "" {
+		host, _ := os.Hostname()
+		id = "goheadache-" + host
+	}
+	opts := mqtt.Options{ClientID: id, Username: cfg.Username, Password: cfg.Password, KeepAlive: time.Minute}
+	if tr, ok := client.Transport.(*http.Transport); ok {
+		opts.TLSConfig = tr.TLSClientConfig
+	}
+	return &mqttPublisher{cfg: cfg, opts: opts, announced: map[string]bool{}}, nil
+}
+
+func (p *mqttPublisher) stateTopic(code string) string {
+	return p.cfg.Topic + "/" + code + "/state"
+}
+
+// mqttMessage is a topic and its payload.
+type mqttMessage struct {
+	topic   string
+	payload []byte
+	retain  bool
+}
+
+// messages returns what publishing the state of an area sends: its
+// discovery messages the first time, then the state.
+func (p *mqttPublisher) messages(s mqttState) ([]mqttMessage, error) {
+	var msgs []mqttMessage
+	if p.cfg.Discovery && !p.announced[s.AreaCode] {
+		for _, sensor := range mqttSensors {
+			payload, err := json.Marshal(p.discovery(s, sensor))
+			if err != nil {
+				return nil, err
+			}
+			topic := fmt.Sprintf("%s/sensor/goheadache_%s/%s/config", p.cfg.DiscoveryPrefix, s.AreaCode, sensor.key)
+			// Discovery messages are always retained so Home Assistant
+			// finds the sensors again after it restarts.
+			msgs = append(msgs, mqttMessage{topic: topic, payload: payload, retain: true})
+		}
+	}
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return append(msgs, mqttMessage{topic: p.stateTopic(s.AreaCode), payload: payload, retain: p.cfg.Retain}), nil
+}
+
+// discovery returns the Home Assistant discovery config of a sensor.
+func (p *mqttPublisher) discovery(s mqttState, sensor mqttSensor) map[string]any {
+	device := "goheadache_" + s.AreaCode
+	c := map[string]any{
+		"name":                  sensor.name,
+		"unique_id":             device + "_" + sensor.key,
+		"object_id":             device + "_" + sensor.key,
+		"state_topic":           p.stateTopic(s.AreaCode),
+		"value_template":        "{{ value_json." + sensor.key + " }}",
+		"json_attributes_topic": p.stateTopic(s.AreaCode),
+		"state_class":           "measurement",
+		"device": map[string]any{
+			"identifiers":  []string{device},
+			"name":         "goHeadache " + s.Place,
+			"manufacturer": "goHeadache",
+			"model":        "Pressure forecast " + s.AreaCode,
+		},
+	}
+	if sensor.unit != "" {
+		c["unit_of_measurement"] = sensor.unit
+	}
+	if sensor.deviceClass != "" {
+		c["device_class"] = sensor.deviceClass
+	}
+	if sensor.icon != "" {
+		c["icon"] = sensor.icon
+	}
+	return c
+}
+
+// publish connects to the broker and sends msgs.
+func (p *mqttPublisher) publish(ctx context.Context, msgs []mqttMessage) error {
+	c, err := mqtt.Dial(ctx, p.cfg.Broker, p.opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for _, m := range msgs {
+		if err := c.Publish(ctx, m.topic, m.payload, 1, m.retain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishMQTT publishes the state of an area, or logs it in a dry run.
+func (d *daemon) publishMQTT(ctx context.Context, code string, w weatherResult, now time.Time) {
+	s := newMQTTState(code, w, d.risk, d.mqtt.cfg.Hours, now)
+	msgs, err := d.mqtt.messages(s)
+	if err != nil {
+		d.log.Printf("%s: error encoding MQTT state: %v", code, err)
+		return
+	}
+	if d.dryRun {
+		for _, m := range msgs {
+			d.log.Printf("%s: would publish to %s: %s", code, m.topic, m.payload)
+		}
+		return
+	}
+	if err := d.mqtt.publish(ctx, msgs); err != nil {
+		d.log.Printf("%s: %v", code, err)
+		return
+	}
+	d.mqtt.announced[code] = true
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"goHeadache/pkg/zutool"
+)
+
+// notifyHTTPClient posts webhooks and holds the TLS settings of MQTT. It has
+// the proxy, ca_file and -insecure of the API clients but not their
+// apiTransport, which would put the tokens of webhook URLs into the debug
+// log and crash reports.
+var notifyHTTPClient = &http.Client{Timeout: zutool.DefaultTimeout}
+
+// networkFlags are the flags of the commands that call the API.
+type networkFlags struct {
+	apiBase  *string
+	proxy    *string
+	caFile   *string
+	insecure *bool
+	debug    *bool
+}
+
+// addNetworkFlags registers the API connection flags on fs.
+func addNetworkFlags(fs *flag.FlagSet) networkFlags {
+	return networkFlags{
+		apiBase:  fs.String("api-base", "", "Root URL of the zutool API, e.g. a mock server or caching proxy (default $GOHEADACHE_API_BASE, else https://zutool.jp/api)"),
+		proxy:    fs.String("proxy", "", "Proxy URL for API requests (default from config, else $HTTPS_PROXY/$HTTP_PROXY)"),
+		caFile:   fs.String("ca-file", "", "PEM file of extra CA certificates to trust (default from config)"),
+		insecure: fs.Bool("insecure", false, "Skip TLS certificate verification (only for intercepting proxies you trust)"),
+		debug:    fs.Bool("debug", false, "Log requests, cache use, parse warnings and screen events to debug.log in the state directory"),
+	}
+}
+
+// setup configures the API clients from the flags, falling back to cfg and the
+// environment.
+func (f networkFlags) setup(cfg Config) error {
+	if *f.debug {
+		path, err := setupDebug()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Writing debug logs to "+path)
+	}
+	proxy, caFile := cfg.Proxy, cfg.CAFile
+	if *f.proxy != "" {
+		proxy = *f.proxy
+	}
+	if *f.caFile != "" {
+		caFile = *f.caFile
+	}
+	httpClient, err := newHTTPClient(proxy, caFile, *f.insecure)
+	if err != nil {
+		return err
+	}
+	notifyHTTPClient = httpClient
+	apiHTTPClient := &http.Client{Timeout: httpClient.Timeout, Transport: apiTransport{httpClient.Transport}}
+	apiClient.HTTPClient = apiHTTPClient
+	openMeteoClient.HTTPClient = apiHTTPClient
+	jmaClient.HTTPClient = apiHTTPClient
+	owmClient.HTTPClient = apiHTTPClient
+	geoClient.HTTPClient = apiHTTPClient
+	postalClient.HTTPClient = apiHTTPClient
+	if *f.insecure {
+		fmt.Fprintln(os.Stderr, "Warning: TLS certificate verification is disabled (-insecure)")
+	}
+	if err := setupRateLimit(cfg, 0); err != nil {
+		return err
+	}
+	return setupAPIClient(*f.apiBase)
+}
+
+// setupFetching prepares the cache, the history, the API clients and the
+// source of cfg for the commands that fetch forecasts without a -source
+// flag.
+func setupFetching(cfg Config, f networkFlags) error {
+	var err error
+	if cacheTTL, err = parseCacheTTL(cfg.CacheTTL); err != nil {
+		return err
+	}
+	if err := f.setup(cfg); err != nil {
+		return err
+	}
+	if err := setupHistory(cfg.History); err != nil {
+		return err
+	}
+	owmClient.APIKey = cfg.owmAPIKey()
+	weatherSource, err = newWeatherSource(cfg.Source)
+	return err
+}
+
+// newHTTPClient returns the client for API requests and notifications. The
+// proxy defaults to the environment's; caFile adds trusted CAs to the system
+// pool.
+func newHTTPClient(proxy, caFile string, insecure bool) (*http.Client, error) {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", proxy)
+		}
+		tr.Proxy = http.ProxyURL(u)
+	}
+	if caFile != "" || insecure {
+		tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: insecure}
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA file: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", caFile)
+		}
+		tr.TLSClientConfig.RootCAs = pool
+	}
+	return &http.Client{Timeout: zutool.DefaultTimeout, Transport: tr}, nil
+}
+
+// setupRateLimit limits the requests of apiClient to rate per second, a
+// flag's value, else rate_limit of cfg, else zutool.DefaultRateLimit, in
+// bursts of rate_burst. apiClient is shared by everything a process fetches,
+// so the limit holds for all of it.
+func setupRateLimit(cfg Config, rate float64) error {
+	if cfg.RateLimit < 0 {
+		return fmt.Errorf("invalid rate_limit %v in the config file (use a number of requests per second above 0)", cfg.RateLimit)
+	}
+	if cfg.RateBurst < 0 {
+		return fmt.Errorf("invalid rate_burst %d in the config file (use a number of requests above 0)", cfg.RateBurst)
+	}
+	if rate < 0 {
+		return fmt.Errorf("invalid rate limit %v (use a number of requests per second above 0)", rate)
+	}
+	if rate == 0 {
+		rate = cfg.RateLimit
+	}
+	if rate == 0 {
+		rate = zutool.DefaultRateLimit
+	}
+	burst := cfg.RateBurst
+	if burst == 0 {
+		burst = zutool.DefaultRateBurst
+	}
+	apiClient.Limiter = zutool.NewLimiter(rate, burst)
+	return nil
+}
+
+// setupAPIClient points apiClient at base, or at GOHEADACHE_API_BASE when
+// base is empty, such as a mock server or a caching proxy, and identifies
+// the app in the User-Agent.
+func setupAPIClient(base string) error {
+	b := buildInfo()
+	apiClient.UserAgent = fmt.Sprintf("goHeadache/%s (%s; %s; +https://github.com/satoi8080/goHeadache)", b.version, b.shortCommit(), b.goVersion)
+	openMeteoClient.UserAgent = apiClient.UserAgent
+	jmaClient.UserAgent = apiClient.UserAgent
+	geoClient.UserAgent = apiClient.UserAgent
+	postalClient.UserAgent = apiClient.UserAgent
+	owmClient.UserAgent = apiClient.UserAgent
+	if base == "" {
+		base = os.Getenv("GOHEADACHE_API_BASE")
+	}
+	if base == "" {
+		return nil
+	}
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid API base URL %q (use http:// or https://)", base)
+	}
+	apiClient.BaseURL = strings.TrimRight(base, "/")
+	return nil
+}
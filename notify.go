@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"goHeadache/pkg/zutool"
+)
+
+// NotifyConfig is the [notify] section of the config file: when a pressure
+// warning fires a desktop notification.
+type NotifyConfig struct {
+	// Enabled turns notifications on in the forecast view without -notify.
+	Enabled bool `toml:"enabled"`
+	// Level warns when an upcoming hour reaches this pressure level (1-4).
+	// Zero disables the check.
+	Level int `toml:"level"`
+	// Drop warns when the pressure falls by at least this many hPa within
+	// an hour. Zero disables the check.
+	Drop float64 `toml:"drop"`
+	// Hours is how far ahead to look.
+	Hours int `toml:"hours"`
+}
+
+var defaultNotifyConfig = NotifyConfig{Level: 3, Drop: 1.0, Hours: 6}
+
+// pressureWarning is the first upcoming hour that crosses a threshold.
+type pressureWarning struct {
+	at       time.Time // start of the hour
+	level    int
+	drop     float64 // hPa lost since the previous hour, zero if none
+	pressure string
+	// until is set for warnings about a span of hours, such as a drop of
+	// several hPa; drop is then the fall from at to until, and pressure
+	// the one reached at until.
+	until time.Time
+}
+
+// upcomingHour is an hour of the forecast with its date and the pressure
+// change from the hour before.
+type upcomingHour struct {
+	at      time.Time
+	entry   zutool.HourlyData
+	delta   float64
+	deltaOK bool
+}
+
+// forecastHours returns every hour of w with its date and the pressure
+// change from the hour before, along with their entries in the same order.
+func forecastHours(w zutool.WeatherData, now time.Time) ([]upcomingHour, []zutool.HourlyData) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var all []upcomingHour
+	var data []zutool.HourlyData
+	for day, dayData := range [][]zutool.HourlyData{w.Yesterday, w.Today, w.Tomorrow, w.DayAfterTom} {
+		for _, entry := range dayData {
+			h, err := strconv.Atoi(strings.TrimSpace(entry.Time))
+			if err != nil {
+				continue
+			}
+			at := today.AddDate(0, 0, day-1).Add(time.Duration(h) * time.Hour)
+			all = append(all, upcomingHour{at: at, entry: entry})
+			data = append(data, entry)
+		}
+	}
+	deltas, ok := pressureDeltas(nil, data)
+	for i := range all {
+		all[i].delta, all[i].deltaOK = deltas[i], ok[i]
+	}
+	return all, data
+}
+
+// within reports whether u is between the current hour and hours ahead.
+func (u upcomingHour) within(now time.Time, hours int) bool {
+	start := now.Truncate(time.Hour)
+	end := start.Add(time.Duration(hours) * time.Hour)
+	return !u.at.Before(start) && !u.at.After(end)
+}
+
+// upcomingHours returns the hours of w from the current one to hours ahead.
+func upcomingHours(w zutool.WeatherData, now time.Time, hours int) []upcomingHour {
+	all, _ := forecastHours(w, now)
+	var upcoming []upcomingHour
+	for _, u := range all {
+		if u.within(now, hours) {
+			upcoming = append(upcoming, u)
+		}
+	}
+	return upcoming
+}
+
+// findWarning returns the first hour of the next cfg.Hours whose level or
+// pressure drop reaches the thresholds of cfg.
+func findWarning(w zutool.WeatherData, cfg NotifyConfig, now time.Time) (pressureWarning, bool) {
+	for _, u := range upcomingHours(w, now, cfg.Hours) {
+		level, err := strconv.Atoi(strings.TrimSpace(u.entry.PressureLevel))
+		levelHit := err == nil && cfg.Level > 0 && level >= cfg.Level
+		dropHit := u.deltaOK && cfg.Drop > 0 && -u.delta >= cfg.Drop
+		if !levelHit && !dropHit {
+			continue
+		}
+		warning := pressureWarning{at: u.at, level: level, pressure: strings.TrimSpace(u.entry.Pressure)}
+		if u.deltaOK && u.delta < 0 {
+			warning.drop = -u.delta
+		}
+		return warning, true
+	}
+	return pressureWarning{}, false
+}
+
+// message returns the notification title and body of w for placeName, such
+// as "Pressure warning: 千代田区" and "15:00: level 4 (Warning), -1.2 hPa/h".
+func (w pressureWarning) message(placeName string) (title, body string) {
+	title = "Pressure warning: " + placeName
+	if !w.until.IsZero() {
+		body = fmt.Sprintf("%s–%s: pressure dropping %s", formatClock(w.at), formatClock(w.until), units.formatFall(w.drop))
+		if !sameDay(w.at, clockNow()) {
+			body = w.at.Format("Mon ") + body
+		}
+		if w.pressure != "" && w.pressure != "#" {
+			body += fmt.Sprintf(" to %s %s", units.formatPressure(parseFloat(w.pressure)), units.pressure)
+		}
+		return title, body
+	}
+	body = fmt.Sprintf("%s: level %d (%s)", formatClock(w.at), w.level, levelName(strconv.Itoa(w.level)))
+	if !sameDay(w.at, clockNow()) {
+		body = w.at.Format("Mon ") + body
+	}
+	if w.drop > 0 {
+		body += ", " + units.formatDropRate(w.drop)
+	}
+	if w.pressure != "" && w.pressure != "#" {
+		body += fmt.Sprintf(", %s %s", units.formatPressure(parseFloat(w.pressure)), units.pressure)
+	}
+	return title, body
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// sendNotification shows a desktop notification with notify-send on Linux and
+// the BSDs, terminal-notifier or osascript on macOS, and a toast through
+// PowerShell on Windows.
+func sendNotification(title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		if path, err := exec.LookPath("terminal-notifier"); err == nil {
+			cmd = exec.Command(path, "-title", title, "-message", body, "-group", "goHeadache")
+		} else {
+			script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+			cmd = exec.Command("osascript", "-e", script)
+		}
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript(title, body))
+	default:
+		cmd = exec.Command("notify-send", "--app-name=goHeadache", title, body)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("error sending notification: %v: %s", err, msg)
+		}
+		return fmt.Errorf("error sending notification: %v", err)
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// windowsToastScript returns a PowerShell script that shows a toast. It is
+// sent as PowerShell's own app ID, since an unregistered one is not shown.
+func windowsToastScript(title, body string) string {
+	quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+	return strings.Join([]string{
+		"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null",
+		"$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)",
+		"$text = $xml.GetElementsByTagName('text')",
+		"$text.Item(0).AppendChild($xml.CreateTextNode(" + quote(title) + ")) > $null",
+		"$text.Item(1).AppendChild($xml.CreateTextNode(" + quote(body) + ")) > $null",
+		"$app = '{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\\WindowsPowerShell\\v1.0\\powershell.exe'",
+		"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($app).Show([Windows.UI.Notifications.ToastNotification]::new($xml))",
+	}, "; ")
+}
+
+type notifyResultMsg struct {
+	err error
+}
+
+// notifyCmd sends the notification of w in the background.
+func notifyCmd(w pressureWarning, placeName string) tea.Cmd {
+	return func() tea.Msg {
+		title, body := w.message(placeName)
+		return notifyResultMsg{sendNotification(title, body)}
+	}
+}
+
+// checkWarning notifies about the first warning in the forecast unless that
+// hour was already notified, so watch-mode refreshes do not repeat it.
+func (m *model) checkWarning() tea.Cmd {
+	if !m.notify.Enabled {
+		return nil
+	}
+	w, ok := findWarning(m.weatherData, m.notify, clockNow())
+	if !ok || w.at.Equal(m.notifiedAt) {
+		return nil
+	}
+	m.notifiedAt = w.at
+	return notifyCmd(w, m.weatherData.PlaceName)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+
+	"goHeadache/pkg/zutool"
+)
+
+// trendHours is how far back the trend of the now banner looks.
+const trendHours = 3
+
+// nowBanner sums up the current hour on one line at the top of the
+// forecast, as in "Now 14:00 · 1009.2 hPa ↓ -1.8 hPa/3h · level 3 · risk 62":
+// its pressure, how it changed over the last trendHours hours and its risk
+// badge. It is empty in the history and when Today has no data.
+func (m model) nowBanner() string {
+	today := m.weatherData.Today
+	if m.historyMode || len(today) == 0 {
+		return ""
+	}
+	now := findCurrentRowIndex(today)
+	entry := today[now]
+	hour, _, _, pressure := displayHourlyData(entry)
+	parts := []string{trf("Now %s", hour), pressure + " " + units.pressure}
+
+	// Look back into Yesterday for the first hours of Today.
+	hours := append(append([]zutool.HourlyData{}, m.weatherData.Yesterday...), today...)
+	values, ok := pressureValues(hours)
+	at := len(m.weatherData.Yesterday) + now
+	if from := at - trendHours; from >= 0 && ok[at] && ok[from] {
+		change := values[at] - values[from]
+		arrow := "→"
+		switch {
+		case change <= dropWarnHPa:
+			arrow = "↓"
+		case change >= -dropWarnHPa:
+			arrow = "↑"
+		}
+		parts[1] += " " + deltaStyle(lipgloss.NewStyle(), change, true).Render(fmt.Sprintf("%s %s %s/%dh", arrow, units.formatChange(change), units.pressure, trendHours))
+	}
+
+	level := strings.TrimSpace(entry.PressureLevel)
+	if level != "" {
+		parts = append(parts, levelStyle(level).Render(trf("level %s", level)))
+	}
+	deltas, deltaOK := pressureDeltas(lastPressure(m.weatherData.Yesterday), today)
+	risks := riskScores(m.riskWeights, today, deltas, deltaOK)
+	parts = append(parts, tr("risk")+riskBadge(risks[now]))
+
+	width := m.contentWidth()
+	return nowStyle.Width(width).Render(ansi.Truncate(strings.Join(parts, " · "), width, "…"))
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"charm.land/lipgloss/v2"
+
+	"goHeadache/pkg/zutool"
+)
+
+// painLabels describe the four pain report rates.
+var painLabels = [4]string{"none", "slight", "painful", "severe"}
+
+// painLevels are the pressure levels whose colors the rates are shown in,
+// from normal to warning.
+var painLevels = [4]int{0, 2, 3, 4}
+
+// painSummary renders the prefecture's headache reports for the header, such
+// as "東京都 headache reports: 52% none · 30% slight · 13% painful · 5% severe",
+// or only the share reporting any pain in the compact layout.
+func (m model) painSummary() string {
+	p := m.pain
+	text := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Text))
+	if m.isCompact() {
+		return text.Render(trf("%s: %.0f%% in pain", p.AreaName, p.PainRate()))
+	}
+	return text.Render(trf("%s headache reports: ", p.AreaName)) + painBreakdown(*p)
+}
+
+// painBreakdown renders the four rates of p, each in the color of its
+// level: "52% none · 30% slight · 13% painful · 5% severe".
+func painBreakdown(p zutool.PainStatus) string {
+	text := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Text))
+	var s string
+	for i, rate := range p.Rates {
+		if i > 0 {
+			s += text.Render(" · ")
+		}
+		rateStyle := text.Foreground(lipgloss.Color(theme.Levels[painLevels[i]])).Bold(true)
+		s += rateStyle.Render(fmt.Sprintf("%.0f%%", rate)) + text.Render(" "+tr(painLabels[i]))
+	}
+	return s
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"golang.org/x/sync/errgroup"
+
+	"goHeadache/internal/areas"
+	"goHeadache/pkg/zutool"
+)
+
+// mapTile places a prefecture on the map grid.
+type mapTile struct {
+	code     string
+	row, col int
+}
+
+// mapTiles lay the prefectures out roughly where they are, from Hokkaido in
+// the top right corner down to Okinawa in the bottom left.
+var mapTiles = []mapTile{
+	{"01", 0, 13},
+	{"02", 1, 12},
+	{"05", 2, 11}, {"03", 2, 12},
+	{"06", 3, 11}, {"04", 3, 12},
+	{"18", 4, 7}, {"17", 4, 8}, {"16", 4, 9}, {"15", 4, 10}, {"07", 4, 11},
+	{"32", 5, 3}, {"31", 5, 4}, {"28", 5, 5}, {"26", 5, 6}, {"25", 5, 7},
+	{"21", 5, 8}, {"20", 5, 9}, {"10", 5, 10}, {"09", 5, 11}, {"08", 5, 12},
+	{"41", 6, 0}, {"40", 6, 1}, {"35", 6, 2}, {"34", 6, 3}, {"33", 6, 4},
+	{"27", 6, 5}, {"29", 6, 6}, {"24", 6, 7}, {"23", 6, 8}, {"19", 6, 9},
+	{"11", 6, 10}, {"13", 6, 11}, {"12", 6, 12},
+	{"42", 7, 0}, {"43", 7, 1}, {"44", 7, 2}, {"38", 7, 3}, {"37", 7, 4},
+	{"30", 7, 5}, {"22", 7, 9}, {"14", 7, 10},
+	{"46", 8, 1}, {"45", 8, 2}, {"39", 8, 3}, {"36", 8, 4},
+	{"47", 10, 0},
+}
+
+const (
+	mapTileWidth = 4 // two full-width characters
+	mapRows      = 11
+	mapCols      = 14
+)
+
+// painMapModel shows the headache reports of every prefecture on a map of
+// Japan and opens the location picker in the one the user selects.
+type painMapModel struct {
+	pain    map[string]zutool.PainStatus
+	errs    int
+	loading bool
+	cursor  int // index into mapTiles
+	opts    forecastOptions
+	width   int
+	height  int
+	// cancel aborts the fetches when the user quits before they finish.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newPainMapModel(opts forecastOptions) painMapModel {
+	ctx, cancel := context.WithCancel(context.Background())
+	cursor := 0
+	for i, t := range mapTiles {
+		if t.code == "13" {
+			cursor = i
+		}
+	}
+	return painMapModel{
+		ctx:     ctx,
+		cancel:  cancel,
+		loading: true,
+		cursor:  cursor,
+		opts:    opts,
+		width:   80,
+		height:  24,
+	}
+}
+
+type painMapResultMsg struct {
+	pain map[string]zutool.PainStatus
+	errs int
+}
+
+// fetchAllPainCmd fetches the headache reports of every prefecture. A
+// prefecture that fails is left out of the map rather than failing it.
+func fetchAllPainCmd(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		statuses := make([]zutool.PainStatus, len(areas.Prefectures))
+		errs := make([]error, len(areas.Prefectures))
+		var g errgroup.Group
+		g.SetLimit(maxConcurrentFetches)
+		for i, p := range areas.Prefectures {
+			g.Go(func() error {
+				statuses[i], errs[i] = apiClient.GetPainStatus(ctx, p.Code)
+				return nil
+			})
+		}
+		g.Wait()
+		msg := painMapResultMsg{pain: map[string]zutool.PainStatus{}}
+		for i, p := range areas.Prefectures {
+			if errs[i] != nil {
+				msg.errs++
+				continue
+			}
+			msg.pain[p.Code] = statuses[i]
+		}
+		return msg
+	}
+}
+
+func (m painMapModel) Init() tea.Cmd {
+	return fetchAllPainCmd(m.ctx)
+}
+
+// painMapLevel maps the share of users in pain to the pressure level whose
+// color the tile is drawn in.
+func painMapLevel(rate float64) int {
+	switch {
+	case rate < 20:
+		return 0
+	case rate < 35:
+		return 2
+	case rate < 50:
+		return 3
+	}
+	return 4
+}
+
+// tileName shortens a prefecture name to the two characters that fit on a
+// tile: 東京都 becomes 東京, 京都府 京都 and 神奈川県 神奈.
+func tileName(name string) string {
+	for _, suffix := range []string{"都", "府", "県"} {
+		if s := strings.TrimSuffix(name, suffix); s != "" && s != name {
+			name = s
+			break
+		}
+	}
+	return ansi.Truncate(name, mapTileWidth, "")
+}
+
+// move selects the nearest tile in the direction (dr, dc), preferring tiles
+// in line with the current one.
+func (m *painMapModel) move(dr, dc int) {
+	cur := mapTiles[m.cursor]
+	best, bestDist := -1, 0
+	for i, t := range mapTiles {
+		r, c := t.row-cur.row, t.col-cur.col
+		along, across := r*dr+c*dc, r*dc+c*dr
+		if along <= 0 {
+			continue
+		}
+		if across < 0 {
+			across = -across
+		}
+		if dist := along + 2*across; best < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	if best >= 0 {
+		m.cursor = best
+	}
+}
+
+func (m painMapModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case painMapResultMsg:
+		m.pain = msg.pain
+		m.errs = msg.errs
+		m.loading = false
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			m.cancel()
+			return m, tea.Quit
+		case "up", "k":
+			m.move(-1, 0)
+		case "down", "j":
+			m.move(1, 0)
+		case "left", "h":
+			m.move(0, -1)
+		case "right", "l":
+			m.move(0, 1)
+		case "enter":
+			if m.loading {
+				return m, nil
+			}
+			return m.openPrefecture()
+		}
+	}
+	return m, nil
+}
+
+// openPrefecture hands over to the location picker, listing the places in
+// the selected prefecture.
+func (m painMapModel) openPrefecture() (tea.Model, tea.Cmd) {
+	pm := newPickerModel(m.opts)
+	pm.width = m.width
+	pm.height = m.height
+	code := mapTiles[m.cursor].code
+	for _, item := range pm.prefectures {
+		if item.code == code {
+			return pm.openPrefecture(item)
+		}
+	}
+	return pm, nil
+}
+
+// tile renders the map tile of t.
+func (m painMapModel) tile(i int, t mapTile) string {
+	s := lipgloss.NewStyle().Width(mapTileWidth)
+	p, ok := m.pain[t.code]
+	switch {
+	case i == m.cursor:
+		s = s.Foreground(lipgloss.Color(theme.Highlight)).Background(lipgloss.Color(theme.HighlightBg)).Bold(true)
+	case ok:
+		level := painMapLevel(p.PainRate())
+		s = s.Foreground(lipgloss.Color(theme.BadgeText)).Background(lipgloss.Color(theme.Levels[level]))
+	default:
+		s = s.Foreground(lipgloss.Color(theme.Muted))
+	}
+	pref, _ := areas.LookupPrefecture(t.code)
+	return s.Render(tileName(pref.Name))
+}
+
+func (m painMapModel) mapView() string {
+	var grid [mapRows][mapCols]string
+	for i, t := range mapTiles {
+		grid[t.row][t.col] = m.tile(i, t)
+	}
+	blank := strings.Repeat(" ", mapTileWidth)
+	lines := make([]string, mapRows)
+	for r, row := range grid {
+		cells := make([]string, mapCols)
+		for c, cell := range row {
+			if cell == "" {
+				cell = blank
+			}
+			cells[c] = cell
+		}
+		lines[r] = strings.Join(cells, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// painMapLegend explains the tile colors.
+func painMapLegend() string {
+	text := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Text))
+	swatch := func(level int, label string) string {
+		return lipgloss.NewStyle().Background(lipgloss.Color(theme.Levels[level])).Render("  ") + text.Render(" "+label)
+	}
+	return text.Render("In pain: ") + strings.Join([]string{
+		swatch(0, "<20%"), swatch(2, "20-35%"), swatch(3, "35-50%"), swatch(4, "50%+"),
+	}, "  ")
+}
+
+func (m painMapModel) View() tea.View {
+	if m.loading {
+		return newView(loadingStyle.Render(tr("Loading headache reports for all prefectures...\nPlease wait")))
+	}
+
+	width := max(m.width-appFrameWidth, 1)
+	var b strings.Builder
+	b.WriteString(dayHeaderStyle.Width(width).Render(tr("Headache reports across Japan")) + "\n\n")
+	b.WriteString(m.mapView() + "\n\n")
+	b.WriteString(painMapLegend() + "\n\n")
+
+	t := mapTiles[m.cursor]
+	pref, _ := areas.LookupPrefecture(t.code)
+	text := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Text))
+	b.WriteString(text.Bold(true).Render(fmt.Sprintf("%s (%s)", pref.Name, pref.NameEn)) + "\n")
+	if p, ok := m.pain[t.code]; ok {
+		b.WriteString(text.Render(trf("%.0f%% in pain: ", p.PainRate())) + painBreakdown(p))
+	} else {
+		b.WriteString(text.Render(tr("No reports available")))
+	}
+	if m.errs > 0 {
+		b.WriteString("\n" + errorStyle.Render(trf("Could not load %d of %d prefectures", m.errs, len(areas.Prefectures))))
+	}
+
+	b.WriteString("\n" + footerStyle.Width(width).Render(m.keys().footer(width, false)))
+	return newView(b.String())
+}
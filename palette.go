@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// severityPalette are the colors that rate the weather: of pressure levels 0
+// to 4, of risk scores, of pressure drops and of the check status in status
+// bars and prompts.
+type severityPalette struct {
+	Levels     []string
+	Risk       []string
+	DropWarn   string
+	DropSevere string
+}
+
+// palettes are the severity colors for color vision deficiencies, which
+// replace those of the theme. Rather than going from green to red, they go
+// between hues that stay apart for each deficiency and grow stronger with
+// the severity, so the order still shows where hues blur.
+var palettes = map[string]severityPalette{
+	// Blue to orange, which red-green color blindness keeps apart.
+	"deuteranopia": {
+		Levels:     []string{"#56B4E9", "#0072B2", "#E69F00", "#D55E00", "#9E3A00"},
+		Risk:       []string{"#0072B2", "#E69F00", "#D55E00", "#9E3A00"},
+		DropWarn:   "#E69F00",
+		DropSevere: "#D55E00",
+	},
+	// Like deuteranopia, ending on a wine that stays distinct from orange
+	// where red looks dark.
+	"protanopia": {
+		Levels:     []string{"#77AADD", "#4477AA", "#DDAA33", "#EE7733", "#994455"},
+		Risk:       []string{"#4477AA", "#DDAA33", "#EE7733", "#994455"},
+		DropWarn:   "#DDAA33",
+		DropSevere: "#EE7733",
+	},
+	// Teal to red and magenta, as blue and yellow blur together.
+	"tritanopia": {
+		Levels:     []string{"#44BB99", "#009988", "#EE8866", "#EE3377", "#CC3311"},
+		Risk:       []string{"#009988", "#EE8866", "#EE3377", "#CC3311"},
+		DropWarn:   "#EE8866",
+		DropSevere: "#EE3377",
+	},
+}
+
+// paletteNames returns default followed by the presets.
+func paletteNames() []string {
+	names := make([]string, 0, len(palettes))
+	for name := range palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{"default"}, names...)
+}
+
+// setupPalette selects the severity colors of the config file, overridden
+// by flagValue; default keeps those of the theme. It replaces the colors of
+// the theme applied by setupTheme, so it is called after it.
+func setupPalette(cfg Config, flagValue string) error {
+	name := cfg.Palette
+	if flagValue != "" {
+		name = flagValue
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == "default" {
+		return nil
+	}
+	p, ok := palettes[name]
+	if !ok {
+		return fmt.Errorf("unknown palette %q (use %s)", name, strings.Join(paletteNames(), ", "))
+	}
+	t := theme
+	t.Levels, t.Risk, t.DropWarn, t.DropSevere = p.Levels, p.Risk, p.DropWarn, p.DropSevere
+	applyTheme(t)
+	dashboardColors.Levels, dashboardColors.Risk = p.Levels, p.Risk
+	for status, color := range map[checkStatus]string{checkOK: p.Levels[1], checkModerate: p.DropWarn, checkSevere: p.DropSevere} {
+		statusColors[status] = color
+		promptColors[status] = trueColorSGR(color)
+	}
+	return nil
+}
+
+// trueColorSGR returns the SGR parameters of a hex foreground color such as
+// "#0072B2".
+func trueColorSGR(hex string) string {
+	v, _ := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	return fmt.Sprintf("38;2;%d;%d;%d", v>>16&0xFF, v>>8&0xFF, v&0xFF)
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"goHeadache/internal/areas"
+	"goHeadache/pkg/zutool"
+)
+
+type pickerStage int
+
+const (
+	stagePrefecture pickerStage = iota
+	stageCity
+)
+
+// pickerItem is a selectable row in the location picker.
+type pickerItem struct {
+	code  string
+	name  string
+	label string
+	keys  []string // strings matched against the filter
+}
+
+// pickerModel lets the user choose a prefecture and then a city, and turns
+// into the forecast model once a city is selected.
+type pickerModel struct {
+	stage       pickerStage
+	prefectures []pickerItem
+	cities      []pickerItem
+	prefecture  pickerItem
+	filter      string
+	cursor      int
+	loading     bool
+	err         error
+	opts        forecastOptions
+	width       int
+	height      int
+}
+
+func newPickerModel(opts forecastOptions) pickerModel {
+	items := make([]pickerItem, len(areas.Prefectures))
+	for i, p := range areas.Prefectures {
+		items[i] = pickerItem{
+			code:  p.Code,
+			name:  p.Name,
+			label: fmt.Sprintf("%s  %s (%s)", p.Code, p.Name, p.NameEn),
+			keys:  []string{p.Name, p.NameEn, p.Code},
+		}
+	}
+	return pickerModel{
+		stage:       stagePrefecture,
+		prefectures: items,
+		opts:        opts,
+		width:       80,
+		height:      24,
+	}
+}
+
+type citiesSuccessMsg struct {
+	points []zutool.WeatherPoint
+}
+
+type citiesErrorMsg struct {
+	err error
+}
+
+// fetchCitiesCmd lists the places inside a prefecture by searching for the
+// prefecture name and keeping the area codes that start with its code. If
+// the API is unreachable the embedded area list is used.
+func fetchCitiesCmd(pref pickerItem) tea.Cmd {
+	return func() tea.Msg {
+		points, err := apiClient.SearchWeatherPoints(context.Background(), pref.name)
+		if err != nil {
+			if offline := areas.InPrefecture(pref.code); len(offline) > 0 {
+				return citiesSuccessMsg{areaPoints(offline)}
+			}
+			return citiesErrorMsg{err}
+		}
+		var inPref []zutool.WeatherPoint
+		for _, p := range points {
+			if strings.HasPrefix(p.CityCode, pref.code) {
+				inPref = append(inPref, p)
+			}
+		}
+		return citiesSuccessMsg{inPref}
+	}
+}
+
+// Init implements tea.Model. The prefecture list is static, so there is
+// nothing to load up front.
+func (m pickerModel) Init() tea.Cmd {
+	return nil
+}
+
+// fuzzyScore reports how well query matches s as a case-insensitive
+// subsequence. Consecutive and leading matches score higher; -1 means no match.
+func fuzzyScore(query, s string) int {
+	if query == "" {
+		return 0
+	}
+	q := []rune(strings.ToLower(query))
+	score, qi, run := 0, 0, 0
+	for i, r := range []rune(strings.ToLower(s)) {
+		if qi < len(q) && r == q[qi] {
+			run++
+			score += run
+			if i == qi {
+				score += 2
+			}
+			qi++
+		} else {
+			run = 0
+		}
+	}
+	if qi < len(q) {
+		return -1
+	}
+	return score
+}
+
+func (m pickerModel) items() []pickerItem {
+	if m.stage == stageCity {
+		return m.cities
+	}
+	return m.prefectures
+}
+
+// filtered returns the items matching the filter, best matches first.
+func (m pickerModel) filtered() []pickerItem {
+	items := m.items()
+	if m.filter == "" {
+		return items
+	}
+	type scored struct {
+		item  pickerItem
+		score int
+	}
+	var matches []scored
+	for _, item := range items {
+		best := -1
+		for _, key := range item.keys {
+			if s := fuzzyScore(m.filter, key); s > best {
+				best = s
+			}
+		}
+		if best >= 0 {
+			matches = append(matches, scored{item, best})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	result := make([]pickerItem, len(matches))
+	for i, s := range matches {
+		result[i] = s.item
+	}
+	return result
+}
+
+func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+	case citiesSuccessMsg:
+		if !m.loading {
+			// The user went back before the list arrived.
+			return m, nil
+		}
+		m.loading = false
+		m.cities = nil
+		for _, p := range msg.points {
+			m.cities = append(m.cities, pickerItem{
+				code:  p.CityCode,
+				name:  p.Name,
+				label: fmt.Sprintf("%s  %s", p.CityCode, p.Name),
+				keys:  []string{p.Name, p.NameKana, p.CityCode},
+			})
+		}
+		sort.Slice(m.cities, func(i, j int) bool { return m.cities[i].code < m.cities[j].code })
+		if len(m.cities) == 0 {
+			m.err = fmt.Errorf("no places found in %s", m.prefecture.name)
+		}
+		return m, nil
+	case citiesErrorMsg:
+		if !m.loading {
+			return m, nil
+		}
+		m.loading = false
+		m.err = msg.err
+		return m, nil
+	case tea.PasteMsg:
+		m.filter += msg.Content
+		m.cursor = 0
+		return m, nil
+	case tea.KeyPressMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m pickerModel) handleKey(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		switch {
+		case m.err != nil || m.loading:
+			m.err = nil
+			m.loading = false
+			m.stage = stagePrefecture
+		case m.filter != "":
+			m.filter = ""
+		case m.stage == stageCity:
+			m.stage = stagePrefecture
+		default:
+			return m, tea.Quit
+		}
+		m.cursor = 0
+		return m, nil
+	}
+	if m.loading || m.err != nil {
+		return m, nil
+	}
+
+	items := m.filtered()
+	switch msg.String() {
+	case "up", "ctrl+p":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "ctrl+n":
+		if m.cursor < len(items)-1 {
+			m.cursor++
+		}
+	case "pgup":
+		m.cursor = max(m.cursor-10, 0)
+	case "pgdown":
+		m.cursor = max(min(m.cursor+10, len(items)-1), 0)
+	case "backspace":
+		if m.filter != "" {
+			_, size := utf8.DecodeLastRuneInString(m.filter)
+			m.filter = m.filter[:len(m.filter)-size]
+			m.cursor = 0
+		}
+	case "enter":
+		if len(items) == 0 {
+			return m, nil
+		}
+		selected := items[m.cursor]
+		if m.stage == stagePrefecture {
+			return m.openPrefecture(selected)
+		}
+		opts := m.opts
+		opts.areaCode = selected.code
+		fm := newForecastModel(opts)
+		fm.width = m.width
+		fm.height = m.height
+		return fm, fm.Init()
+	default:
+		if msg.Text != "" {
+			m.filter += msg.Text
+			m.cursor = 0
+		}
+	}
+	return m, nil
+}
+
+// openPrefecture moves on to choosing a place inside pref.
+func (m pickerModel) openPrefecture(pref pickerItem) (pickerModel, tea.Cmd) {
+	m.prefecture = pref
+	m.stage = stageCity
+	m.filter = ""
+	m.cursor = 0
+	m.loading = true
+	return m, fetchCitiesCmd(pref)
+}
+
+func (m pickerModel) View() tea.View {
+	if m.err != nil {
+		return newView(errorStyle.Render(trf("Error: %s", trError(m.err))) + "\n\n" + m.keys().line())
+	}
+	if m.loading {
+		return newView(loadingStyle.Render(trf("Loading places in %s...\nPlease wait", m.prefecture.name)) + "\n\n" + m.keys().line())
+	}
+
+	width := max(m.width-appFrameWidth, 1)
+	title := "Select a prefecture"
+	if m.stage == stageCity {
+		title = trf("Select a place in %s", m.prefecture.name)
+	}
+
+	var b strings.Builder
+	b.WriteString(dayHeaderStyle.Width(width).Render(title) + "\n\n")
+	b.WriteString("Filter: " + m.filter + "█\n\n")
+
+	items := m.filtered()
+	// Border, title, filter line and footer take about 12 lines.
+	visible := max(m.height-12, 3)
+	start := 0
+	if m.cursor >= visible {
+		start = m.cursor - visible + 1
+	}
+	end := min(start+visible, len(items))
+
+	if len(items) == 0 {
+		b.WriteString(cellStyle.Render(tr("No matches")))
+	}
+	for i := start; i < end; i++ {
+		s := cellStyle
+		if i == m.cursor {
+			s = currentCellStyle
+		}
+		b.WriteString(renderCell(s.Align(lipgloss.Left), width, items[i].label))
+		if i < end-1 {
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("\n" + footerStyle.Width(width).Render(m.keys().footer(width, false)))
+	return newView(b.String())
+}
@@ -0,0 +1,102 @@
+// Package geoip is a small client for the IP geolocation of ipapi.co
+// (https://ipapi.co), which guesses where the caller is from the address
+// its requests come from.
+package geoip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultBaseURL is the root of the public ipapi.co API.
+const DefaultBaseURL = "https://ipapi.co"
+
+// DefaultTimeout bounds each request made by a Client from NewClient(nil).
+const DefaultTimeout = 10 * time.Second
+
+// Client locates IP addresses with ipapi.co.
+type Client struct {
+	// HTTPClient is used for every request. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// BaseURL is the API root without a trailing slash. It defaults to DefaultBaseURL.
+	BaseURL string
+	// UserAgent is sent with every request when set.
+	UserAgent string
+}
+
+// NewClient returns a Client that sends requests with httpClient. A nil
+// httpClient uses a client with DefaultTimeout.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Client{HTTPClient: httpClient, BaseURL: DefaultBaseURL}
+}
+
+// Location is where an IP address is, roughly: usually the city of the
+// provider's point of presence rather than of the caller.
+type Location struct {
+	IP     string `json:"ip"`
+	City   string `json:"city"`
+	Region string `json:"region"`
+	// CountryCode is the ISO 3166-1 alpha-2 code of the country, such as
+	// "JP".
+	CountryCode string  `json:"country_code"`
+	CountryName string  `json:"country_name"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+}
+
+// errorBody is the body of a failed request, which ipapi.co may send with
+// status 200.
+type errorBody struct {
+	Error  bool   `json:"error"`
+	Reason string `json:"reason"`
+}
+
+// Locate returns the location of the address the request comes from.
+func (c *Client) Locate(ctx context.Context) (Location, error) {
+	var l Location
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/json/", nil)
+	if err != nil {
+		return l, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return l, fmt.Errorf("error requesting ipapi.co: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return l, fmt.Errorf("error reading ipapi.co response: %w", err)
+	}
+	var e errorBody
+	if json.Unmarshal(body, &e) == nil && e.Error {
+		return l, fmt.Errorf("ipapi.co error: %s", e.Reason)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return l, fmt.Errorf("ipapi.co error (status %d)", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, &l); err != nil {
+		return l, fmt.Errorf("error parsing ipapi.co response: %w", err)
+	}
+	if l.CountryCode == "" {
+		return l, fmt.Errorf("ipapi.co could not locate the address")
+	}
+	return l, nil
+}
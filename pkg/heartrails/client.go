@@ -0,0 +1,115 @@
+// Package heartrails is a small client for the postal code search of the
+// HeartRails Geo API (https://geoapi.heartrails.com), which finds the town
+// and coordinates of a Japanese postal code.
+package heartrails
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the root of the public HeartRails Geo API.
+const DefaultBaseURL = "https://geoapi.heartrails.com/api"
+
+// DefaultTimeout bounds each request made by a Client from NewClient(nil).
+const DefaultTimeout = 10 * time.Second
+
+// Client looks postal codes up with the HeartRails Geo API.
+type Client struct {
+	// HTTPClient is used for every request. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// BaseURL is the API root without a trailing slash. It defaults to DefaultBaseURL.
+	BaseURL string
+	// UserAgent is sent with every request when set.
+	UserAgent string
+}
+
+// NewClient returns a Client that sends requests with httpClient. A nil
+// httpClient uses a client with DefaultTimeout.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Client{HTTPClient: httpClient, BaseURL: DefaultBaseURL}
+}
+
+// Town is a town a postal code covers.
+type Town struct {
+	// Prefecture and City are names such as "東京都" and "新宿区"; the
+	// wards of designated cities come with their city, as in "札幌市中央区".
+	Prefecture string `json:"prefecture"`
+	City       string `json:"city"`
+	Town       string `json:"town"`
+	Postal     string `json:"postal"`
+	// X and Y are the longitude and latitude of the town, as text.
+	X string `json:"x"`
+	Y string `json:"y"`
+}
+
+// Coords returns the latitude and longitude of t, ok=false when they do
+// not parse.
+func (t Town) Coords() (lat, lon float64, ok bool) {
+	lat, errLat := strconv.ParseFloat(t.Y, 64)
+	lon, errLon := strconv.ParseFloat(t.X, 64)
+	return lat, lon, errLat == nil && errLon == nil
+}
+
+// SearchByPostal returns the towns of a seven-digit postal code, such as
+// "1600022". An unknown postal code has none.
+func (c *Client) SearchByPostal(ctx context.Context, postal string) ([]Town, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	q := url.Values{}
+	q.Set("method", "searchByPostal")
+	q.Set("postal", postal)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/json?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting HeartRails Geo API: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading HeartRails Geo API response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HeartRails Geo API error (status %d)", resp.StatusCode)
+	}
+	var r struct {
+		Response struct {
+			Location []Town `json:"location"`
+			Error    string `json:"error"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("error parsing HeartRails Geo API response: %w", err)
+	}
+	switch {
+	case len(r.Response.Location) > 0:
+		return r.Response.Location, nil
+	case strings.Contains(r.Response.Error, "does not exist"):
+		return nil, nil
+	case r.Response.Error != "":
+		return nil, fmt.Errorf("HeartRails Geo API error: %s", r.Response.Error)
+	}
+	return nil, nil
+}
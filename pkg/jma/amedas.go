@@ -0,0 +1,114 @@
+package jma
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// JST is the time zone of all JMA data.
+var JST = time.FixedZone("JST", 9*60*60)
+
+// Station is an AMeDAS observation station.
+type Station struct {
+	// Type "A" marks staffed weather stations, the only ones that observe
+	// pressure.
+	Type   string `json:"type"`
+	KjName string `json:"kjName"`
+	EnName string `json:"enName"`
+	// Lat and Lon are in degrees and minutes, such as [35, 41.5].
+	Lat [2]float64 `json:"lat"`
+	Lon [2]float64 `json:"lon"`
+}
+
+// Degrees returns the station's position in decimal degrees.
+func (s Station) Degrees() (lat, lon float64) {
+	return s.Lat[0] + s.Lat[1]/60, s.Lon[0] + s.Lon[1]/60
+}
+
+// GetStations returns the AMeDAS stations by station number.
+func (c *Client) GetStations(ctx context.Context) (map[string]Station, error) {
+	var s map[string]Station
+	err := c.get(ctx, "/amedas/const/amedastable.json", &s)
+	return s, err
+}
+
+// NearestPressureStation returns the number of the station observing
+// pressure closest to lat, lon.
+func NearestPressureStation(stations map[string]Station, lat, lon float64) (string, bool) {
+	best, bestDist := "", math.Inf(1)
+	for id, s := range stations {
+		if s.Type != "A" {
+			continue
+		}
+		slat, slon := s.Degrees()
+		// Good enough for picking the nearest station within Japan.
+		dlat, dlon := slat-lat, (slon-lon)*math.Cos(lat*math.Pi/180)
+		if d := dlat*dlat + dlon*dlon; d < bestDist {
+			best, bestDist = id, d
+		}
+	}
+	return best, best != ""
+}
+
+// Observation is one AMeDAS observation. A nil value was not observed or
+// failed quality control.
+type Observation struct {
+	Temp *float64
+	// Pressure is the sea-level pressure in hPa.
+	Pressure *float64
+}
+
+// GetLatestTime returns the time of the latest AMeDAS observations.
+func (c *Client) GetLatestTime(ctx context.Context) (time.Time, error) {
+	body, err := c.getRaw(ctx, "/amedas/data/latest_time.txt")
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(string(body)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing AMeDAS latest time: %w", err)
+	}
+	return t, nil
+}
+
+// GetObservations returns the observations of a station in the three hours
+// starting at the hour of at that is a multiple of three (JST), keyed by
+// observation time.
+func (c *Client) GetObservations(ctx context.Context, station string, at time.Time) (map[time.Time]Observation, error) {
+	at = at.In(JST)
+	block := time.Date(at.Year(), at.Month(), at.Day(), at.Hour()/3*3, 0, 0, 0, JST)
+	var raw map[string]map[string]json.RawMessage
+	path := fmt.Sprintf("/amedas/data/point/%s/%s.json", station, block.Format("20060102_15"))
+	if err := c.get(ctx, path, &raw); err != nil {
+		return nil, err
+	}
+	obs := make(map[time.Time]Observation, len(raw))
+	for key, elems := range raw {
+		t, err := time.ParseInLocation("20060102150405", key, JST)
+		if err != nil {
+			continue
+		}
+		obs[t] = Observation{
+			Temp:     observedValue(elems["temp"]),
+			Pressure: observedValue(elems["normalPressure"]),
+		}
+	}
+	return obs, nil
+}
+
+// observedValue decodes an element such as [1012.3, 0], a value and its
+// quality flag; only flag 0 marks a normal value.
+func observedValue(data json.RawMessage) *float64 {
+	if len(data) == 0 {
+		return nil
+	}
+	var pair []*float64
+	if err := json.Unmarshal(data, &pair); err != nil || len(pair) < 2 || pair[0] == nil || pair[1] == nil || *pair[1] != 0 {
+		return nil
+	}
+	return pair[0]
+}
@@ -0,0 +1,50 @@
+package jma
+
+import (
+	"context"
+	"fmt"
+)
+
+// Area is an entry of the JMA area table.
+type Area struct {
+	Name     string   `json:"name"`
+	EnName   string   `json:"enName"`
+	Parent   string   `json:"parent"`
+	Children []string `json:"children"`
+}
+
+// Areas is the JMA area table. Forecast offices contain primary subdivisions
+// (class10), which contain groups of municipalities (class15), which contain
+// the municipalities (class20). Municipality codes are the five-digit JIS
+// area code followed by "00".
+type Areas struct {
+	Offices  map[string]Area `json:"offices"`
+	Class10s map[string]Area `json:"class10s"`
+	Class15s map[string]Area `json:"class15s"`
+	Class20s map[string]Area `json:"class20s"`
+}
+
+// GetAreas returns the JMA area table.
+func (c *Client) GetAreas(ctx context.Context) (Areas, error) {
+	var a Areas
+	err := c.get(ctx, "/common/const/area.json", &a)
+	return a, err
+}
+
+// Subdivision returns the forecast office and the primary subdivision whose
+// forecast covers the municipality with the five-digit areaCode.
+func (a Areas) Subdivision(areaCode string) (office, class10 string, err error) {
+	c20, ok := a.Class20s[areaCode+"00"]
+	if !ok {
+		return "", "", fmt.Errorf("area code %s is not in the JMA area table", areaCode)
+	}
+	c15, ok := a.Class15s[c20.Parent]
+	if !ok {
+		return "", "", fmt.Errorf("JMA area table has no parent for %s", areaCode)
+	}
+	c10, ok := a.Class10s[c15.Parent]
+	if !ok {
+		return "", "", fmt.Errorf("JMA area table has no subdivision for %s", areaCode)
+	}
+	return c10.Parent, c15.Parent, nil
+}
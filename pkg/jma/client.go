@@ -0,0 +1,82 @@
+// Package jma is a small client for the public JSON data behind the Japan
+// Meteorological Agency's website (https://www.jma.go.jp/bosai/): the area
+// table, the office forecasts and AMeDAS observations.
+package jma
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultBaseURL is the root of the JMA data.
+const DefaultBaseURL = "https://www.jma.go.jp/bosai"
+
+// DefaultTimeout bounds each request made by a Client from NewClient(nil).
+const DefaultTimeout = 10 * time.Second
+
+// Client fetches data from the JMA website.
+type Client struct {
+	// HTTPClient is used for every request. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// BaseURL is the data root without a trailing slash. It defaults to DefaultBaseURL.
+	BaseURL string
+	// UserAgent is sent with every request when set.
+	UserAgent string
+}
+
+// NewClient returns a Client that sends requests with httpClient. A nil
+// httpClient uses a client with DefaultTimeout.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Client{HTTPClient: httpClient, BaseURL: DefaultBaseURL}
+}
+
+// get requests path below BaseURL and decodes the JSON response into v.
+func (c *Client) get(ctx context.Context, path string, v interface{}) error {
+	body, err := c.getRaw(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("error parsing JMA %s: %w", path, err)
+	}
+	return nil
+}
+
+// getRaw requests path below BaseURL and returns the response body.
+func (c *Client) getRaw(ctx context.Context, path string) ([]byte, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting JMA %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading JMA %s: %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JMA %s: unexpected status %s", path, resp.Status)
+	}
+	return body, nil
+}
@@ -0,0 +1,43 @@
+package jma
+
+import (
+	"context"
+	"time"
+)
+
+// Report is one of the forecasts published by an office: the first is the
+// detailed forecast for the next three days, the second the weekly one.
+type Report struct {
+	PublishingOffice string       `json:"publishingOffice"`
+	ReportDatetime   time.Time    `json:"reportDatetime"`
+	TimeSeries       []TimeSeries `json:"timeSeries"`
+}
+
+// TimeSeries holds values for TimeDefines in each of its areas.
+type TimeSeries struct {
+	TimeDefines []time.Time  `json:"timeDefines"`
+	Areas       []SeriesArea `json:"areas"`
+}
+
+// SeriesArea is the values of a time series for one area. Only the fields of
+// the kind of series are set; empty strings are missing values.
+type SeriesArea struct {
+	Area struct {
+		Name string `json:"name"`
+		Code string `json:"code"`
+	} `json:"area"`
+	// WeatherCodes are weather codes such as "100", the same as zutool's.
+	WeatherCodes []string `json:"weatherCodes"`
+	Weathers     []string `json:"weathers"`
+	Pops         []string `json:"pops"`
+	// Temps are in °C, for a city of the area rather than the area.
+	Temps []string `json:"temps"`
+}
+
+// GetForecast returns the forecasts of the office, such as "130000" for
+// Tokyo.
+func (c *Client) GetForecast(ctx context.Context, office string) ([]Report, error) {
+	var r []Report
+	err := c.get(ctx, "/forecast/data/forecast/"+office+".json", &r)
+	return r, err
+}
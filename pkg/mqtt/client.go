@@ -0,0 +1,280 @@
+// Package mqtt is a minimal MQTT 3.1.1 client that connects to a broker and
+// publishes messages, enough for sending sensor values to home automation.
+// It does not subscribe.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// DefaultTimeout bounds connecting and each publish when the context has no
+// deadline.
+const DefaultTimeout = 10 * time.Second
+
+// Options are the connection settings.
+type Options struct {
+	// ClientID identifies the client to the broker. It must be unique per
+	// broker; an empty one lets the broker assign one.
+	ClientID string
+	Username string
+	Password string
+	// KeepAlive is sent to the broker; the client disconnects well before
+	// it passes, so it only bounds how long a dead connection lingers.
+	KeepAlive time.Duration
+	// TLSConfig is used for ssl://, tls:// and mqtts:// brokers.
+	TLSConfig *tls.Config
+}
+
+// Client is a connection to a broker.
+type Client struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	nextID uint16
+}
+
+// Packet types of the fixed header.
+const (
+	packetConnect    = 1
+	packetConnAck    = 2
+	packetPublish    = 3
+	packetPubAck     = 4
+	packetDisconnect = 14
+)
+
+// connectErrors are the CONNACK return codes.
+var connectErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// ParseBroker returns the host:port of broker, a URL such as
+// "tcp://localhost:1883" or "mqtts://broker.example.com", and whether it uses
+// TLS. The port defaults to 1883, or 8883 for TLS.
+func ParseBroker(broker string) (addr string, useTLS bool, err error) {
+	u, err := url.Parse(broker)
+	if err != nil || u.Host == "" {
+		return "", false, fmt.Errorf("invalid MQTT broker URL %q (use tcp://host:1883 or mqtts://host:8883)", broker)
+	}
+	port := "1883"
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		useTLS, port = true, "8883"
+	default:
+		return "", false, fmt.Errorf("unsupported MQTT broker scheme %q (use tcp, mqtt, ssl, tls or mqtts)", u.Scheme)
+	}
+	if u.Port() == "" {
+		return net.JoinHostPort(u.Hostname(), port), useTLS, nil
+	}
+	return u.Host, useTLS, nil
+}
+
+// Dial connects to broker, see ParseBroker.
+func Dial(ctx context.Context, broker string, opts Options) (*Client, error) {
+	addr, useTLS, err := ParseBroker(broker)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to MQTT broker: %w", err)
+	}
+	if useTLS {
+		cfg := &tls.Config{}
+		if opts.TLSConfig != nil {
+			cfg = opts.TLSConfig.Clone()
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("error connecting to MQTT broker: %w", err)
+		}
+		conn = tlsConn
+	}
+	c := &Client{conn: conn, r: bufio.NewReader(conn)}
+	if err := c.connect(ctx, opts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, DefaultTimeout)
+}
+
+// deadline applies the deadline of ctx to the connection.
+func (c *Client) deadline(ctx context.Context) {
+	d, _ := ctx.Deadline()
+	_ = c.conn.SetDeadline(d)
+}
+
+// connect sends CONNECT and waits for CONNACK.
+func (c *Client) connect(ctx context.Context, opts Options) error {
+	c.deadline(ctx)
+	var vh []byte
+	vh = appendString(vh, "MQTT")
+	vh = append(vh, 4)  // protocol level 3.1.1
+	flags := byte(0x02) // clean session
+	if opts.Username != "" {
+		flags |= 0x80
+		if opts.Password != "" {
+			flags |= 0x40
+		}
+	}
+	vh = append(vh, flags)
+	vh = binary.BigEndian.AppendUint16(vh, uint16(opts.KeepAlive/time.Second))
+	vh = appendString(vh, opts.ClientID)
+	if opts.Username != "" {
+		vh = appendString(vh, opts.Username)
+		if opts.Password != "" {
+			vh = appendString(vh, opts.Password)
+		}
+	}
+	if err := c.write(packetConnect<<4, vh); err != nil {
+		return fmt.Errorf("error connecting to MQTT broker: %w", err)
+	}
+	kind, body, err := c.read()
+	if err != nil {
+		return fmt.Errorf("error connecting to MQTT broker: %w", err)
+	}
+	if kind != packetConnAck || len(body) != 2 {
+		return errors.New("error connecting to MQTT broker: unexpected reply")
+	}
+	if code := body[1]; code != 0 {
+		if msg, ok := connectErrors[code]; ok {
+			return fmt.Errorf("MQTT broker refused the connection: %s", msg)
+		}
+		return fmt.Errorf("MQTT broker refused the connection (code %d)", code)
+	}
+	return nil
+}
+
+// Publish sends payload to topic. With qos 1 it waits for the broker to
+// acknowledge it; qos 0 sends it and returns. retain keeps it on the broker
+// for clients that subscribe later.
+func (c *Client) Publish(ctx context.Context, topic string, payload []byte, qos byte, retain bool) error {
+	if qos > 1 {
+		return errors.New("MQTT QoS 2 is not supported")
+	}
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	c.deadline(ctx)
+	header := byte(packetPublish<<4) | qos<<1
+	if retain {
+		header |= 0x01
+	}
+	body := appendString(nil, topic)
+	var id uint16
+	if qos == 1 {
+		c.nextID++
+		if c.nextID == 0 {
+			c.nextID = 1
+		}
+		id = c.nextID
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+	body = append(body, payload...)
+	if err := c.write(header, body); err != nil {
+		return fmt.Errorf("error publishing to %s: %w", topic, err)
+	}
+	if qos == 0 {
+		return nil
+	}
+	for {
+		kind, reply, err := c.read()
+		if err != nil {
+			return fmt.Errorf("error publishing to %s: %w", topic, err)
+		}
+		if kind == packetPubAck && len(reply) == 2 && binary.BigEndian.Uint16(reply) == id {
+			return nil
+		}
+	}
+}
+
+// Close disconnects from the broker.
+func (c *Client) Close() error {
+	_ = c.conn.SetDeadline(time.Now().Add(time.Second))
+	_ = c.write(packetDisconnect<<4, nil)
+	return c.conn.Close()
+}
+
+// write sends a packet with the fixed header byte and body.
+func (c *Client) write(header byte, body []byte) error {
+	packet := []byte{header}
+	packet = appendLength(packet, len(body))
+	packet = append(packet, body...)
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+// read returns the type and body of the next packet.
+func (c *Client) read() (kind byte, body []byte, err error) {
+	header, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, mult := 0, 1
+	for i := 0; ; i++ {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n += int(b&0x7f) * mult
+		if b&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, errors.New("malformed packet length")
+		}
+		mult *= 128
+	}
+	body = make([]byte, n)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
+
+// appendLength appends the variable-length remaining length n.
+func appendLength(b []byte, n int) []byte {
+	for {
+		d := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			d |= 0x80
+		}
+		b = append(b, d)
+		if n == 0 {
+			return b
+		}
+	}
+}
+
+// appendString appends s with its two-byte length.
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
@@ -0,0 +1,123 @@
+// Package openmeteo is a small client for the hourly forecast of the
+// Open-Meteo API (https://open-meteo.com), which covers the whole world.
+package openmeteo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultBaseURL is the root of the public Open-Meteo API.
+const DefaultBaseURL = "https://api.open-meteo.com"
+
+// DefaultTimeout bounds each request made by a Client from NewClient(nil).
+const DefaultTimeout = 10 * time.Second
+
+// Client fetches forecasts from Open-Meteo.
+type Client struct {
+	// HTTPClient is used for every request. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// BaseURL is the API root without a trailing slash. It defaults to DefaultBaseURL.
+	BaseURL string
+	// UserAgent is sent with every request when set.
+	UserAgent string
+}
+
+// NewClient returns a Client that sends requests with httpClient. A nil
+// httpClient uses a client with DefaultTimeout.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Client{HTTPClient: httpClient, BaseURL: DefaultBaseURL}
+}
+
+// Forecast is the response of the forecast endpoint.
+type Forecast struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// Timezone is the IANA name of the location's time zone; the times of
+	// Hourly are local to it.
+	Timezone         string `json:"timezone"`
+	UTCOffsetSeconds int    `json:"utc_offset_seconds"`
+	Hourly           Hourly `json:"hourly"`
+}
+
+// Hourly holds one value per hour in each slice, in the order of Time. A nil
+// value is missing from the model run.
+type Hourly struct {
+	// Time is the local start of each hour, such as "2024-06-01T09:00".
+	Time []string `json:"time"`
+	// Temperature is the air temperature at 2 m in °C.
+	Temperature []*float64 `json:"temperature_2m"`
+	// SurfacePressure is the pressure at ground level in hPa.
+	SurfacePressure []*float64 `json:"surface_pressure"`
+	// WeatherCode is the WMO weather interpretation code.
+	WeatherCode []*int `json:"weather_code"`
+	// PrecipitationProbability is the chance of precipitation in percent.
+	PrecipitationProbability []*float64 `json:"precipitation_probability"`
+}
+
+// TimeLayout is the layout of Hourly.Time.
+const TimeLayout = "2006-01-02T15:04"
+
+// errorBody is the body of a failed request.
+type errorBody struct {
+	Error  bool   `json:"error"`
+	Reason string `json:"reason"`
+}
+
+// GetForecast returns the hourly forecast at lat, lon from the start of
+// yesterday to the end of the day after tomorrow, in local time.
+func (c *Client) GetForecast(ctx context.Context, lat, lon float64) (Forecast, error) {
+	var f Forecast
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	q := url.Values{}
+	q.Set("latitude", strconv.FormatFloat(lat, 'f', -1, 64))
+	q.Set("longitude", strconv.FormatFloat(lon, 'f', -1, 64))
+	q.Set("hourly", "temperature_2m,surface_pressure,weather_code,precipitation_probability")
+	q.Set("past_days", "1")
+	q.Set("forecast_days", "3")
+	q.Set("timezone", "auto")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/v1/forecast?"+q.Encode(), nil)
+	if err != nil {
+		return f, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return f, fmt.Errorf("error requesting Open-Meteo: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return f, fmt.Errorf("error reading Open-Meteo response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e errorBody
+		if json.Unmarshal(body, &e) == nil && e.Reason != "" {
+			return f, fmt.Errorf("Open-Meteo error: %s", e.Reason)
+		}
+		return f, fmt.Errorf("Open-Meteo error (status %d)", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, &f); err != nil {
+		return f, fmt.Errorf("error parsing Open-Meteo response: %w", err)
+	}
+	return f, nil
+}
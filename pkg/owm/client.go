@@ -0,0 +1,139 @@
+// Package owm is a small client for the 5 day / 3 hour forecast of the
+// OpenWeatherMap API (https://openweathermap.org/forecast5), which works with
+// free API keys.
+package owm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultBaseURL is the root of the public OpenWeatherMap API.
+const DefaultBaseURL = "https://api.openweathermap.org"
+
+// DefaultTimeout bounds each request made by a Client from NewClient(nil).
+const DefaultTimeout = 10 * time.Second
+
+// ErrNoAPIKey is returned when the Client has no API key.
+var ErrNoAPIKey = errors.New("no OpenWeatherMap API key")
+
+// Client fetches forecasts from OpenWeatherMap.
+type Client struct {
+	// HTTPClient is used for every request. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// BaseURL is the API root without a trailing slash. It defaults to DefaultBaseURL.
+	BaseURL string
+	// UserAgent is sent with every request when set.
+	UserAgent string
+	// APIKey is the key of the user's OpenWeatherMap account.
+	APIKey string
+}
+
+// NewClient returns a Client that sends requests with httpClient. A nil
+// httpClient uses a client with DefaultTimeout.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Client{HTTPClient: httpClient, BaseURL: DefaultBaseURL}
+}
+
+// Forecast is the response of the forecast endpoint.
+type Forecast struct {
+	List []Entry `json:"list"`
+	City struct {
+		Name  string `json:"name"`
+		Coord struct {
+			Lat float64 `json:"lat"`
+			Lon float64 `json:"lon"`
+		} `json:"coord"`
+		// Timezone is the offset from UTC in seconds.
+		Timezone int `json:"timezone"`
+	} `json:"city"`
+}
+
+// Entry is the forecast of one three-hour step.
+type Entry struct {
+	// Dt is the start of the step in Unix seconds.
+	Dt   int64 `json:"dt"`
+	Main struct {
+		// Temp is in °C.
+		Temp float64 `json:"temp"`
+		// Pressure is the sea-level pressure in hPa.
+		Pressure float64 `json:"pressure"`
+	} `json:"main"`
+	Weather []struct {
+		// ID is the weather condition code, such as 800 for clear sky.
+		ID          int    `json:"id"`
+		Description string `json:"description"`
+	} `json:"weather"`
+}
+
+// Time returns the start of the step.
+func (e Entry) Time() time.Time {
+	return time.Unix(e.Dt, 0)
+}
+
+// GetForecast returns the forecast at lat, lon for the next five days in
+// three-hour steps, with metric units.
+func (c *Client) GetForecast(ctx context.Context, lat, lon float64) (Forecast, error) {
+	var f Forecast
+	if c.APIKey == "" {
+		return f, ErrNoAPIKey
+	}
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	q := url.Values{}
+	q.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	q.Set("lon", strconv.FormatFloat(lon, 'f', -1, 64))
+	q.Set("units", "metric")
+	q.Set("appid", c.APIKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/data/2.5/forecast?"+q.Encode(), nil)
+	if err != nil {
+		return f, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// The URL holds the API key; keep it out of the message.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return f, fmt.Errorf("error requesting OpenWeatherMap: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return f, fmt.Errorf("error reading OpenWeatherMap response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &e) == nil && e.Message != "" {
+			return f, fmt.Errorf("OpenWeatherMap error: %s", e.Message)
+		}
+		return f, fmt.Errorf("OpenWeatherMap error (status %d)", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, &f); err != nil {
+		return f, fmt.Errorf("error parsing OpenWeatherMap response: %w", err)
+	}
+	return f, nil
+}
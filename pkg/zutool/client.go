@@ -0,0 +1,163 @@
+// Package zutool is a small client for the zutool.jp headache forecast API.
+package zutool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultBaseURL is the root of the public zutool API.
+const DefaultBaseURL = "https://zutool.jp/api"
+
+// Defaults used by NewClient.
+const (
+	// DefaultTimeout bounds each request, including reading the body.
+	DefaultTimeout = 10 * time.Second
+	// DefaultRetries is how many times a failed request is retried.
+	DefaultRetries = 2
+	// DefaultRetryBackoff is the wait before the first retry; it doubles
+	// with every further retry.
+	DefaultRetryBackoff = 500 * time.Millisecond
+	// DefaultUserAgent identifies the client to the API.
+	DefaultUserAgent = "goHeadache-zutool (+https://github.com/satoi8080/goHeadache)"
+)
+
+// Client fetches data from the zutool API.
+type Client struct {
+	// HTTPClient is used for every request. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// BaseURL is the API root without a trailing slash. It defaults to DefaultBaseURL.
+	BaseURL string
+	// Retries is how many times a request that failed with a network error,
+	// a 5xx status or 429 Too Many Requests is repeated. Zero disables retries.
+	Retries int
+	// RetryBackoff is the wait before the first retry. It doubles with every
+	// retry and is jittered so that clients do not retry in lockstep.
+	RetryBackoff time.Duration
+	// UserAgent is sent with every request. It defaults to DefaultUserAgent.
+	UserAgent string
+	// Limiter spaces out the requests, retries included, when set.
+	Limiter *Limiter
+}
+
+// NewClient returns a Client that sends requests with httpClient and
+// retries failed ones DefaultRetries times. A nil httpClient uses a client
+// with DefaultTimeout. The Client is rate-limited: it sends at most
+// DefaultRateLimit requests per second, after bursts of DefaultRateBurst,
+// waiting as needed; replace its Limiter to change that, or set it to nil
+// to send requests as fast as they come.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Client{
+		HTTPClient:   httpClient,
+		BaseURL:      DefaultBaseURL,
+		Retries:      DefaultRetries,
+		RetryBackoff: DefaultRetryBackoff,
+		Limiter:      NewLimiter(DefaultRateLimit, DefaultRateBurst),
+	}
+}
+
+// get issues a GET request for path below BaseURL and returns the response
+// body, retrying transient failures, each request waiting for the Limiter.
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	for attempt := 0; ; attempt++ {
+		if c.Limiter != nil {
+			if err := c.Limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		body, retry, err := c.getOnce(ctx, base+path)
+		if err == nil || !retry || attempt >= c.Retries {
+			return body, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+// getOnce issues a single GET request for url. retry reports whether the
+// failure is worth retrying.
+func (c *Client) getOnce(ctx context.Context, url string) (body []byte, retry bool, err error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error creating GET request: %w", err)
+	}
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, fmt.Errorf("error making GET request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	retry = resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, ctx.Err() == nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	if apiErr, ok := parseAPIError(resp.StatusCode, body); ok {
+		return nil, retry, apiErr
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		// A 404 without an error body, such as the HTML page of a proxy or
+		// a mock server, means the same as one with it.
+		return nil, false, &APIError{StatusCode: resp.StatusCode}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, retry, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return body, false, nil
+}
+
+// backoff returns the wait before retry number attempt+1: RetryBackoff
+// doubled attempt times, randomized between half and all of that.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.RetryBackoff << min(attempt, 10)
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// GetWeatherStatus returns the hourly weather and pressure forecast for a
+// JIS area code (for example "13101" for Chiyoda, Tokyo). Unknown area codes
+// yield an error matching ErrAreaNotFound. Canceling ctx aborts the request
+// and any retries.
+func (c *Client) GetWeatherStatus(ctx context.Context, areaCode string) (WeatherData, error) {
+	body, err := c.get(ctx, "/getweatherstatus/"+url.PathEscape(areaCode))
+	if err != nil {
+		return WeatherData{}, err
+	}
+
+	var weatherData WeatherData
+	if err := json.Unmarshal(body, &weatherData); err != nil {
+		return WeatherData{}, fmt.Errorf("error parsing JSON: %w", err)
+	}
+	if len(weatherData.Yesterday)+len(weatherData.Today)+len(weatherData.Tomorrow)+len(weatherData.DayAfterTom) == 0 {
+		return WeatherData{}, fmt.Errorf("%w: no forecast for area code %s", ErrAreaNotFound, areaCode)
+	}
+
+	return weatherData, nil
+}
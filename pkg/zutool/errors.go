@@ -0,0 +1,94 @@
+package zutool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrAreaNotFound is returned by GetWeatherStatus when the API has no
+// forecast for the area code: status 404 whatever the body is, or an error
+// body sent with 200 or 400.
+var ErrAreaNotFound = errors.New("area not found")
+
+// APIError is an error reported by the API in its response body, such as
+// {"errors": {"err_code": "...", "err_message": "..."}}.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	switch {
+	case e.Code != "" && e.Message != "":
+		return fmt.Sprintf("zutool API error %s: %s", e.Code, e.Message)
+	case e.Message != "":
+		return "zutool API error: " + e.Message
+	case e.Code != "":
+		return "zutool API error " + e.Code
+	}
+	return fmt.Sprintf("zutool API error (status %d)", e.StatusCode)
+}
+
+// Is reports the errors about the area code, the only input of the
+// endpoints, as ErrAreaNotFound: 404, and error bodies sent with 200 or
+// 400. Others, such as 429 once the retries run out or 401 and 403, are
+// not about the area code, so a cached forecast can still stand in.
+func (e *APIError) Is(target error) bool {
+	if target != ErrAreaNotFound {
+		return false
+	}
+	switch e.StatusCode {
+	case http.StatusOK, http.StatusBadRequest, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// parseAPIError extracts the error from a response body, if it is one. The
+// error object is accepted under "errors" or "error", as an object, a list
+// of objects or a plain message.
+func parseAPIError(status int, body []byte) (*APIError, bool) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, false
+	}
+	var payload interface{}
+	for _, key := range []string{"errors", "error"} {
+		if v, ok := raw[key]; ok && v != nil {
+			payload = v
+			break
+		}
+	}
+	if payload == nil {
+		return nil, false
+	}
+	if list, ok := payload.([]interface{}); ok {
+		if len(list) == 0 {
+			return nil, false
+		}
+		payload = list[0]
+	}
+
+	e := &APIError{StatusCode: status}
+	switch v := payload.(type) {
+	case string:
+		e.Message = v
+	case map[string]interface{}:
+		for key, value := range v {
+			key = strings.ToLower(key)
+			switch {
+			case strings.Contains(key, "code"):
+				e.Code = fmt.Sprintf("%v", value)
+			case strings.Contains(key, "message") || key == "msg":
+				e.Message = fmt.Sprintf("%v", value)
+			}
+		}
+	default:
+		e.Message = fmt.Sprintf("%v", v)
+	}
+	return e, true
+}
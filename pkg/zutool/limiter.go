@@ -0,0 +1,72 @@
+package zutool
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Defaults of the Limiter of NewClient.
+const (
+	// DefaultRateLimit is how many requests per second are sent at most.
+	DefaultRateLimit = 5.0
+	// DefaultRateBurst is how many requests may be sent at once after a
+	// pause.
+	DefaultRateBurst = 10
+)
+
+// Limiter is a token bucket that spaces out requests: it holds up to burst
+// tokens, refilled at rate per second, and every request takes one, waiting
+// for it when the bucket is empty. It is safe for concurrent use, so one
+// Limiter shared by the clients of a process bounds the requests of all of
+// them.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a Limiter of rate requests per second in bursts of up
+// to burst, which starts full. A burst below 1 is 1. A rate of 0 or less,
+// or one that is not a number, does not limit: Wait returns at once.
+func NewLimiter(rate float64, burst int) *Limiter {
+	if !(rate > 0) {
+		rate = math.Inf(1)
+	}
+	b := float64(max(burst, 1))
+	return &Limiter{rate: rate, burst: b, tokens: b, last: time.Now()}
+}
+
+// Wait blocks until a request may be sent, or returns the error of ctx
+// when it is done first.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if math.IsInf(l.rate, 1) {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	// The token is taken now, even if the bucket is empty, so that the
+	// requests waiting are sent in turn.
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
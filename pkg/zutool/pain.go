@@ -0,0 +1,76 @@
+package zutool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// PainStatus is the share of zutool users in a prefecture reporting each
+// degree of headache, from the getpainstatus endpoint.
+type PainStatus struct {
+	AreaName  string
+	TimeStart string
+	TimeEnd   string
+	// Rates are the percentages reporting no pain, slight pain, pain and
+	// severe pain.
+	Rates [4]float64
+}
+
+// PainRate returns the percentage of users reporting any pain.
+func (p PainStatus) PainRate() float64 {
+	return p.Rates[1] + p.Rates[2] + p.Rates[3]
+}
+
+// UnmarshalJSON decodes the "painnoterate_status" object of the response.
+func (p *PainStatus) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		AreaName  flexString `json:"area_name"`
+		TimeStart flexString `json:"time_start"`
+		TimeEnd   flexString `json:"time_end"`
+		Rate0     flexString `json:"rate_0"`
+		Rate1     flexString `json:"rate_1"`
+		Rate2     flexString `json:"rate_2"`
+		Rate3     flexString `json:"rate_3"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*p = PainStatus{
+		AreaName:  string(raw.AreaName),
+		TimeStart: string(raw.TimeStart),
+		TimeEnd:   string(raw.TimeEnd),
+	}
+	for i, r := range []flexString{raw.Rate0, raw.Rate1, raw.Rate2, raw.Rate3} {
+		if r == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(string(r), 64)
+		if err != nil {
+			return fmt.Errorf("rate_%d: %w", i, err)
+		}
+		p.Rates[i] = v
+	}
+	return nil
+}
+
+// GetPainStatus returns the current headache reports for a two-digit
+// prefecture code such as "13" for Tokyo.
+func (c *Client) GetPainStatus(ctx context.Context, prefectureCode string) (PainStatus, error) {
+	body, err := c.get(ctx, "/getpainstatus/"+url.PathEscape(prefectureCode))
+	if err != nil {
+		return PainStatus{}, err
+	}
+	var resp struct {
+		Status *PainStatus `json:"painnoterate_status"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return PainStatus{}, fmt.Errorf("error parsing JSON: %w", err)
+	}
+	if resp.Status == nil {
+		return PainStatus{}, fmt.Errorf("%w: no pain status for prefecture %s", ErrAreaNotFound, prefectureCode)
+	}
+	return *resp.Status, nil
+}
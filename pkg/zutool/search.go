@@ -0,0 +1,44 @@
+package zutool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// SearchWeatherPoints looks up places whose name matches keyword, such as
+// "東京" or "神戸".
+func (c *Client) SearchWeatherPoints(ctx context.Context, keyword string) ([]WeatherPoint, error) {
+	body, err := c.get(ctx, "/getweatherpoint/"+url.PathEscape(keyword))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	}
+	if len(resp.Result) == 0 || string(resp.Result) == "null" {
+		return nil, nil
+	}
+
+	// The API returns the result list as a JSON-encoded string; accept a
+	// plain array as well in case that is ever fixed.
+	result := []byte(resp.Result)
+	var encoded string
+	if err := json.Unmarshal(result, &encoded); err == nil {
+		if encoded == "" {
+			return nil, nil
+		}
+		result = []byte(encoded)
+	}
+
+	var points []WeatherPoint
+	if err := json.Unmarshal(result, &points); err != nil {
+		return nil, fmt.Errorf("error parsing search result: %w", err)
+	}
+	return points, nil
+}
@@ -0,0 +1,122 @@
+package zutool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// WeatherData is the response of the getweatherstatus endpoint.
+type WeatherData struct {
+	PlaceName     string       `json:"place_name"`
+	PlaceID       string       `json:"place_id"`
+	PrefecturesID string       `json:"prefectures_id"`
+	DateTime      string       `json:"dateTime"`
+	Yesterday     []HourlyData `json:"yesterday"`
+	Today         []HourlyData `json:"today"`
+	Tomorrow      []HourlyData `json:"tomorrow"`
+	DayAfterTom   []HourlyData `json:"dayaftertomorrow"`
+}
+
+// HourlyData is a single hour of a WeatherData day. Values are kept as the
+// strings returned by the API; "#" marks a missing temperature or pressure,
+// and fields absent from the response are empty.
+type HourlyData struct {
+	Time          string `json:"time"`
+	Weather       string `json:"weather"`
+	Temp          string `json:"temp"`
+	Pressure      string `json:"pressure"`
+	PressureLevel string `json:"pressure_level"`
+}
+
+// WeatherPoint is a place returned by the getweatherpoint search endpoint.
+type WeatherPoint struct {
+	// CityCode is the area code accepted by GetWeatherStatus.
+	CityCode string `json:"city_code"`
+	Name     string `json:"name"`
+	NameKana string `json:"name_kata"`
+}
+
+// flexString decodes a JSON string, number or boolean as its text, and null
+// as "". The API is not consistent about quoting numeric fields.
+type flexString string
+
+func (s *flexString) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		*s = ""
+	case string:
+		*s = flexString(v)
+	case json.Number:
+		*s = flexString(v.String())
+	case bool:
+		*s = flexString(strconv.FormatBool(v))
+	default:
+		return fmt.Errorf("expected a string or number, got %s", data)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a getweatherstatus response. Numbers are accepted
+// where strings are expected, and tomorrow is also read from the API's
+// misspelled "tommorow" key.
+func (w *WeatherData) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		PlaceName     flexString   `json:"place_name"`
+		PlaceID       flexString   `json:"place_id"`
+		PrefecturesID flexString   `json:"prefectures_id"`
+		DateTime      flexString   `json:"dateTime"`
+		Yesterday     []HourlyData `json:"yesterday"`
+		Today         []HourlyData `json:"today"`
+		Tomorrow      []HourlyData `json:"tomorrow"`
+		Tommorow      []HourlyData `json:"tommorow"`
+		DayAfterTom   []HourlyData `json:"dayaftertomorrow"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Tomorrow == nil {
+		raw.Tomorrow = raw.Tommorow
+	}
+	*w = WeatherData{
+		PlaceName:     string(raw.PlaceName),
+		PlaceID:       string(raw.PlaceID),
+		PrefecturesID: string(raw.PrefecturesID),
+		DateTime:      string(raw.DateTime),
+		Yesterday:     raw.Yesterday,
+		Today:         raw.Today,
+		Tomorrow:      raw.Tomorrow,
+		DayAfterTom:   raw.DayAfterTom,
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes one hour of a forecast, accepting numbers where
+// strings are expected. Missing fields are left empty.
+func (h *HourlyData) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Time          flexString `json:"time"`
+		Weather       flexString `json:"weather"`
+		Temp          flexString `json:"temp"`
+		Pressure      flexString `json:"pressure"`
+		PressureLevel flexString `json:"pressure_level"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*h = HourlyData{
+		Time:          string(raw.Time),
+		Weather:       string(raw.Weather),
+		Temp:          string(raw.Temp),
+		Pressure:      string(raw.Pressure),
+		PressureLevel: string(raw.PressureLevel),
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package zutool
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+//go:embed weathercodes.json
+var weatherCodesJSON []byte
+
+// WeatherCode describes one of the forecast weather codes used by the API,
+// which follow the Japan Meteorological Agency's telop codes.
+type WeatherCode struct {
+	En string `json:"en"`
+	Ja string `json:"ja"`
+}
+
+var (
+	weatherCodesOnce sync.Once
+	weatherCodes     map[string]WeatherCode
+)
+
+// LookupWeatherCode returns the description of a weather code such as "101".
+func LookupWeatherCode(code string) (WeatherCode, bool) {
+	weatherCodesOnce.Do(func() {
+		if err := json.Unmarshal(weatherCodesJSON, &weatherCodes); err != nil {
+			panic("zutool: invalid embedded weather codes: " + err.Error())
+		}
+	})
+	wc, ok := weatherCodes[strings.TrimSpace(code)]
+	return wc, ok
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"goHeadache/internal/areas"
+	"goHeadache/pkg/heartrails"
+)
+
+var postalClient = heartrails.NewClient(nil)
+
+// parsePostal returns the seven digits of a Japanese postal code written as
+// 160-0022, 1600022 or 〒160-0022, in full-width digits too.
+func parsePostal(s string) (string, error) {
+	invalid := fmt.Errorf("invalid postal code %q (use seven digits, such as 160-0022)", s)
+	var digits []rune
+	for _, r := range strings.TrimPrefix(strings.TrimSpace(s), "〒") {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, r)
+		case r >= '０' && r <= '９':
+			digits = append(digits, '0'+r-'０')
+		case (r == '-' || r == '－' || r == 'ー') && len(digits) == 3:
+			// The hyphen after the first three digits.
+		default:
+			return "", invalid
+		}
+	}
+	if len(digits) != 7 {
+		return "", invalid
+	}
+	return string(digits), nil
+}
+
+// isPostal reports whether s is a postal code rather than an area code or
+// a place name.
+func isPostal(s string) bool {
+	_, err := parsePostal(s)
+	return err == nil
+}
+
+// formatPostal writes seven digits as a postal code, as in 160-0022.
+func formatPostal(code string) string {
+	return code[:3] + "-" + code[3:]
+}
+
+// postalArea is the area a postal code was found in.
+type postalArea struct {
+	Area string `json:"area"`
+	// Town is where the postal code is, such as 東京都新宿区新宿.
+	Town string `json:"town"`
+	// Nearest is set when the area list lacks the town's city, and Area is
+	// the area nearest to it, KM away.
+	Nearest bool    `json:"nearest,omitempty"`
+	KM      float64 `json:"km,omitempty"`
+}
+
+// postalCacheFile returns where the areas of the postal codes looked up are
+// kept: <user cache dir>/goHeadache/postal.json. Postal codes seldom move,
+// so they are kept for good.
+func postalCacheFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating cache directory: %v", err)
+	}
+	return filepath.Join(dir, "goHeadache", "postal.json"), nil
+}
+
+// loadPostalCache reads the postal codes looked up before. A missing or
+// unreadable file is an empty cache: they are looked up again.
+func loadPostalCache() map[string]postalArea {
+	cache := map[string]postalArea{}
+	path, err := postalCacheFile()
+	if err != nil {
+		return cache
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if json.Unmarshal(data, &cache) != nil || cache == nil {
+		return map[string]postalArea{}
+	}
+	return cache
+}
+
+// savePostalCache writes the postal codes looked up.
+func savePostalCache(cache map[string]postalArea) error {
+	path, err := postalCacheFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0o644)
+}
+
+// lookupPostal finds the area of a postal code: that of its city in the
+// embedded area list, else the area nearest to its town. Postal codes found
+// before are read from the cache, which is all there is with -offline.
+func lookupPostal(ctx context.Context, postal string) (postalArea, error) {
+	code, err := parsePostal(postal)
+	if err != nil {
+		return postalArea{}, err
+	}
+	cache := loadPostalCache()
+	if p, ok := cache[code]; ok {
+		return p, nil
+	}
+	if offlineMode {
+		return postalArea{}, fmt.Errorf("postal code %s is not cached (run once without -offline to look it up)", formatPostal(code))
+	}
+	towns, err := postalClient.SearchByPostal(ctx, code)
+	if err != nil {
+		return postalArea{}, fmt.Errorf("error looking up postal code %s: %w", formatPostal(code), err)
+	}
+	if len(towns) == 0 {
+		return postalArea{}, fmt.Errorf("unknown postal code %s", formatPostal(code))
+	}
+	t := towns[0]
+	p := postalArea{Town: t.Prefecture + t.City + t.Town}
+	for _, a := range areas.All() {
+		if a.FullName() == t.Prefecture+t.City {
+			p.Area = a.Code
+		}
+	}
+	if p.Area == "" {
+		lat, lon, ok := t.Coords()
+		if !ok {
+			return postalArea{}, fmt.Errorf("no known area for postal code %s in %s", formatPostal(code), p.Town)
+		}
+		a, km, ok := areaAt(lat, lon)
+		if !ok {
+			return postalArea{}, fmt.Errorf("no known area within %.0f km of postal code %s in %s", nearestAreaLimit, formatPostal(code), p.Town)
+		}
+		p.Area, p.Nearest, p.KM = a.Code, true, km
+	}
+	cache[code] = p
+	if err := savePostalCache(cache); err != nil {
+		debugLog.Warn("error saving the postal cache", "error", err)
+	}
+	return p, nil
+}
+
+// resolvePostal returns the area code of a postal code for the command line,
+// saying on stderr which area stands for the town when its city is not in
+// the area list.
+func resolvePostal(postal string) (string, error) {
+	p, err := lookupPostal(context.Background(), postal)
+	if err != nil {
+		return "", err
+	}
+	if p.Nearest {
+		a, _ := areas.Lookup(p.Area)
+		fmt.Fprintf(os.Stderr, "Using %s %s, the nearest area, %.0f km from %s\n", a.Code, a.FullName(), p.KM, p.Town)
+	}
+	return p.Area, nil
+}
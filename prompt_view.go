@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"goHeadache/internal/areas"
+	"goHeadache/pkg/zutool"
+)
+
+// promptMatchesShown is how many places matching a name the prompt lists.
+const promptMatchesShown = 8
+
+// placesFoundMsg reports the places matching query, searched from the
+// prompt.
+type placesFoundMsg struct {
+	query  string
+	points []zutool.WeatherPoint
+	err    error
+}
+
+// searchPlacesCmd finds the places matching query, in the embedded area
+// database when the search API cannot be reached.
+func searchPlacesCmd(query string) tea.Cmd {
+	return func() tea.Msg {
+		points, err := apiClient.SearchWeatherPoints(context.Background(), query)
+		if err != nil {
+			if offline := areas.Search(query); len(offline) > 0 {
+				return placesFoundMsg{query: query, points: areaPoints(offline)}
+			}
+			return placesFoundMsg{query: query, err: fmt.Errorf("error searching for %q: %w", query, err)}
+		}
+		return placesFoundMsg{query: query, points: points}
+	}
+}
+
+// lookupPostalCmd finds the area of the postal code query.
+func lookupPostalCmd(query string) tea.Cmd {
+	return func() tea.Msg {
+		p, err := lookupPostal(context.Background(), query)
+		if err != nil {
+			return placesFoundMsg{query: query, err: err}
+		}
+		a, _ := areas.Lookup(p.Area)
+		return placesFoundMsg{query: query, points: []zutool.WeatherPoint{{CityCode: p.Area, Name: a.FullName()}}}
+	}
+}
+
+// openPrompt shows the prompt switching to another area by its code or the
+// name of a place.
+func (m model) openPrompt() (model, tea.Cmd) {
+	m.prompt = true
+	m.promptErr = nil
+	m.promptQuery = ""
+	m.promptMatches = nil
+	m.promptInput = textinput.New()
+	m.promptInput.Prompt = ": "
+	m.promptInput.Placeholder = "13101, 160-0022, 千代田区, sapporo"
+	m.promptInput.CharLimit = 100
+	m.promptInput.SetWidth(40)
+	return m, m.promptInput.Focus()
+}
+
+// updatePrompt handles the keys of the prompt: enter switches to the area
+// code typed in or that of a postal code, or searches for the place named,
+// and then to the match chosen with up and down; esc closes the prompt.
+func (m model) updatePrompt(msg tea.KeyPressMsg) (model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m.quit()
+	case "esc":
+		m.prompt = false
+		return m, nil
+	case "up":
+		if m.promptCursor > 0 {
+			m.promptCursor--
+		}
+		return m, nil
+	case "down":
+		if m.promptCursor < len(m.promptMatches)-1 {
+			m.promptCursor++
+		}
+		return m, nil
+	case "enter":
+		query := strings.TrimSpace(m.promptInput.Value())
+		switch {
+		case len(m.promptMatches) > 0:
+			return m.promptSwitch(m.promptMatches[m.promptCursor].CityCode)
+		case query == "":
+			return m, nil
+		case isPostal(query):
+			m.promptErr = nil
+			m.promptQuery = query
+			return m, lookupPostalCmd(query)
+		case isAreaCode(query):
+			code, err := areas.Normalize(query)
+			if err != nil {
+				m.promptErr = err
+				return m, nil
+			}
+			return m.promptSwitch(code)
+		}
+		m.promptErr = nil
+		m.promptQuery = query
+		return m, searchPlacesCmd(query)
+	}
+	var cmd tea.Cmd
+	m.promptInput, cmd = m.promptInput.Update(msg)
+	if strings.TrimSpace(m.promptInput.Value()) != m.promptQuery {
+		// The search and its matches are of what was typed before.
+		m.promptQuery = ""
+		m.promptMatches = nil
+	}
+	return m, cmd
+}
+
+// placesFound switches to the only place found, or lists them all for the
+// prompt to choose from.
+func (m model) placesFound(msg placesFoundMsg) (model, tea.Cmd) {
+	if !m.prompt || msg.query != m.promptQuery {
+		return m, nil
+	}
+	m.promptQuery = ""
+	switch {
+	case msg.err != nil:
+		m.promptErr = msg.err
+		return m, nil
+	case len(msg.points) == 0:
+		m.promptErr = fmt.Errorf("no places found for %q", msg.query)
+		return m, nil
+	case len(msg.points) == 1:
+		return m.promptSwitch(msg.points[0].CityCode)
+	}
+	m.promptQuery = msg.query
+	m.promptMatches = msg.points
+	m.promptCursor = 0
+	return m, nil
+}
+
+// promptSwitch closes the prompt and loads the forecast of area code, and
+// its history too when the history is shown.
+func (m model) promptSwitch(code string) (model, tea.Cmd) {
+	m.prompt = false
+	m, fetch := m.switchArea(code)
+	if m.historyMode {
+		return withHistory(m, fetch)
+	}
+	return m, fetch
+}
+
+// promptBox renders the prompt.
+func (m model) promptBox() string {
+	text := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Text))
+	lines := []string{
+		dayHeaderStyle.MarginTop(0).Render(tr("Go to a place")), "",
+		text.Render(tr("Area code, postal code or place name:")),
+		m.promptInput.View(),
+	}
+	switch {
+	case m.promptErr != nil:
+		lines = append(lines, errorStyle.Render(trError(m.promptErr)))
+	case m.promptQuery != "" && m.promptMatches == nil:
+		lines = append(lines, statusStyle.Render(tr("Searching...")))
+	}
+	keys := promptKeys
+	if len(m.promptMatches) > 0 {
+		lines = append(lines, "", text.Render(trf("%d places match:", len(m.promptMatches))))
+		// Keep the cursor in the window of matches shown.
+		first := max(0, min(m.promptCursor-promptMatchesShown/2, len(m.promptMatches)-promptMatchesShown))
+		for i := first; i < len(m.promptMatches) && i < first+promptMatchesShown; i++ {
+			p := m.promptMatches[i]
+			style := text
+			if i == m.promptCursor {
+				style = text.Reverse(true)
+			}
+			lines = append(lines, style.Render(fmt.Sprintf("%s  %s", p.CityCode, p.Name)))
+		}
+		keys = promptMatchKeys
+	}
+	lines = append(lines, "", statusStyle.Render(keys.line()))
+	return popupStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
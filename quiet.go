@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// quietHours is a daily span in minutes after midnight; start after end
+// wraps past midnight. The zero value is never quiet.
+type quietHours struct {
+	start, end int
+	set        bool
+}
+
+// parseQuietHours parses "HH:MM-HH:MM". An empty string is never quiet.
+func parseQuietHours(s string) (quietHours, error) {
+	if s == "" {
+		return quietHours{}, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	start, err1 := time.Parse("15:04", strings.TrimSpace(from))
+	end, err2 := time.Parse("15:04", strings.TrimSpace(to))
+	if !ok || err1 != nil || err2 != nil {
+		return quietHours{}, fmt.Errorf("invalid quiet hours %q (use \"23:00-07:00\")", s)
+	}
+	return quietHours{start: start.Hour()*60 + start.Minute(), end: end.Hour()*60 + end.Minute(), set: true}, nil
+}
+
+// contains reports whether t is within the quiet hours.
+func (q quietHours) contains(t time.Time) bool {
+	if !q.set {
+		return false
+	}
+	m := t.Hour()*60 + t.Minute()
+	if q.start <= q.end {
+		return m >= q.start && m < q.end
+	}
+	return m >= q.start || m < q.end
+}
+
+// queuedAlert is an alert held back by quiet hours, as kept in the daemon
+// state.
+type queuedAlert struct {
+	AreaCode string    `json:"area_code"`
+	Place    string    `json:"place"`
+	Rule     string    `json:"rule"`
+	Severity string    `json:"severity"`
+	At       time.Time `json:"at"`
+	Body     string    `json:"body"`
+	// Notifiers are the notifiers that have yet to send the alert, after
+	// the others did; none means all of them.
+	Notifiers []string `json:"notifiers,omitempty"`
+}
+
+// pendingFor reports whether the notifier of name has yet to send q.
+func (q queuedAlert) pendingFor(name string) bool {
+	return len(q.Notifiers) == 0 || slices.Contains(q.Notifiers, name)
+}
+
+// queue holds a back until the quiet hours are over. It reports false when
+// a was already sent or queued.
+func (s *alertState) queue(a alert, now time.Time) bool {
+	key := a.key() + " queued"
+	if _, ok := s.Sent[key]; ok {
+		return false
+	}
+	for k := range s.Sent {
+		if strings.HasPrefix(k, a.key()+" ") {
+			return false
+		}
+	}
+	_, body := a.message()
+	s.Queued = append(s.Queued, queuedAlert{
+		AreaCode: a.areaCode,
+		Place:    a.placeName,
+		Rule:     a.rule,
+		Severity: a.severity,
+		At:       a.warning.at,
+		Body:     body,
+	})
+	s.Sent[key] = now
+	return true
+}
+
+// severityRank orders severities from info to severe.
+var severityRank = map[string]int{"info": 0, "warning": 1, "severe": 2}
+
+// quietDigests returns one alert per area summing up queued, and for each
+// the indices in queued of the alerts it sums up.
+func quietDigests(queued []queuedAlert) (digests []alert, members [][]int) {
+	byArea := map[string]int{}
+	for j, q := range queued {
+		i, ok := byArea[q.AreaCode]
+		if !ok {
+			i = len(digests)
+			byArea[q.AreaCode] = i
+			digests = append(digests, alert{
+				areaCode:  q.AreaCode,
+				placeName: q.Place,
+				rule:      "quiet-hours",
+				severity:  q.Severity,
+				warning:   pressureWarning{at: q.At},
+			})
+			members = append(members, nil)
+		}
+		d := &digests[i]
+		if severityRank[q.Severity] > severityRank[d.severity] {
+			d.severity = q.Severity
+		}
+		if d.body != "" {
+			d.body += "\n"
+		}
+		d.body += q.Body
+		members[i] = append(members[i], j)
+	}
+	for i := range digests {
+		d := &digests[i]
+		n := strings.Count(d.body, "\n") + 1
+		d.title = fmt.Sprintf("%d pressure alert(s) during quiet hours: %s", n, d.placeName)
+	}
+	return digests, members
+}
+
+// flushQueue sends the digests of the queued alerts whose quiet hours are
+// over. Each notifier gets a digest of the alerts it has yet to send, so
+// an alert stays queued for the notifiers that failed, until the next poll,
+// without the others repeating it.
+func (d *daemon) flushQueue(ctx context.Context, now time.Time) {
+	var due, rest []queuedAlert
+	for _, q := range d.state.Queued {
+		if d.isQuiet(q.Rule, now) {
+			rest = append(rest, q)
+		} else {
+			due = append(due, q)
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+	if d.dryRun {
+		digests, _ := quietDigests(due)
+		for _, a := range digests {
+			for _, n := range d.notifiers {
+				d.log.Printf("%s: would send the quiet hours digest via %s: %s", a.areaCode, n.name(), n.preview(a))
+			}
+		}
+		return
+	}
+	// sentBy are the notifiers that sent each alert of due in this flush.
+	sentBy := make([][]string, len(due))
+	for _, n := range d.notifiers {
+		var pending []int
+		var queued []queuedAlert
+		for i, q := range due {
+			if q.pendingFor(n.name()) {
+				pending = append(pending, i)
+				queued = append(queued, q)
+			}
+		}
+		digests, members := quietDigests(queued)
+		for i, a := range digests {
+			// Digests go out once, so they are keyed by when they are sent.
+			a.warning.at = now
+			sent, errs := d.state.dispatch(ctx, a, []notifier{n}, now)
+			for _, err := range errs {
+				d.log.Printf("%s: error sending the quiet hours digest: %v", a.areaCode, err)
+			}
+			if !sent {
+				continue
+			}
+			d.log.Printf("%s: sent the quiet hours digest of %s via %s", a.areaCode, a.placeName, n.name())
+			for _, j := range members[i] {
+				sentBy[pending[j]] = append(sentBy[pending[j]], n.name())
+			}
+		}
+	}
+	for i, q := range due {
+		// Notifiers no longer configured are dropped along with those
+		// that sent the alert.
+		var left []string
+		for _, n := range d.notifiers {
+			if q.pendingFor(n.name()) && !slices.Contains(sentBy[i], n.name()) {
+				left = append(left, n.name())
+			}
+		}
+		if len(left) > 0 {
+			q.Notifiers = left
+			rest = append(rest, q)
+		}
+	}
+	d.state.Queued = rest
+}
+
+// isQuiet reports whether alerts of the rule are held back at now, by the
+// daemon's or the rule's quiet hours.
+func (d *daemon) isQuiet(rule string, now time.Time) bool {
+	if d.quiet.contains(now) {
+		return true
+	}
+	for _, r := range d.rules {
+		if r.name == rule {
+			return r.quiet.contains(now)
+		}
+	}
+	return false
+}
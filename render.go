@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/term"
+)
+
+// defaultRenderWidth and defaultRenderHeight are the size -render-once
+// renders at when no -size is given and stdout is not a terminal.
+const (
+	defaultRenderWidth  = 100
+	defaultRenderHeight = 30
+)
+
+// parseRenderSize parses a terminal size such as "100x30".
+func parseRenderSize(s string) (width, height int, err error) {
+	w, h, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "x")
+	if ok {
+		var errW, errH error
+		width, errW = strconv.Atoi(w)
+		height, errH = strconv.Atoi(h)
+		if errW == nil && errH == nil && width > 0 && height > 0 {
+			return width, height, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid size %q (use WIDTHxHEIGHT, such as 100x30)", s)
+}
+
+// renderSize returns the size of the frame -render-once prints: size if
+// set, else that of the terminal stdout is, else the defaults.
+func renderSize(size string) (width, height int, err error) {
+	if size != "" {
+		return parseRenderSize(size)
+	}
+	if w, h, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 && h > 0 {
+		return w, h, nil
+	}
+	return defaultRenderWidth, defaultRenderHeight, nil
+}
+
+// renderOnce fetches the forecast of opts and writes the first screen the
+// TUI would show at width by height, with its colors, instead of running
+// it. A failed fetch is returned rather than rendered.
+func renderOnce(w io.Writer, opts forecastOptions, width, height int) error {
+	var m tea.Model = newForecastModel(opts)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: width, Height: height})
+	fm := m.(model)
+	msg := fetchWeatherCmd(fm.fetchCtx, fm.fetchID, fm.areaCode, true)()
+	if failed, ok := msg.(fetchErrorMsg); ok {
+		return failed.err
+	}
+	m, _ = m.Update(msg)
+	_, err := fmt.Fprintln(w, m.View().Content)
+	return err
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// maxRetryDelay caps the wait between manual retries.
+const maxRetryDelay = time.Minute
+
+// retryDelay returns how long the retry after the given number of failed
+// attempts waits: nothing for the first, then 2s, 4s, 8s and so on.
+func retryDelay(attempts int) time.Duration {
+	if attempts == 0 {
+		return 0
+	}
+	return min(time.Second<<min(attempts, 6), maxRetryDelay)
+}
+
+type retryTickMsg struct{}
+
+// retryTickCmd wakes the model up a second later to update the retry
+// countdown.
+func retryTickCmd() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return retryTickMsg{}
+	})
+}
+
+// retry schedules a new fetch after a fetch error, backing off
+// exponentially with every failed attempt.
+func (m model) retry() (model, tea.Cmd) {
+	if m.err == nil || !m.retryAt.IsZero() {
+		return m, nil
+	}
+	m.retryAt = time.Now().Add(retryDelay(m.retries))
+	m.retries++
+	return m.retryTick()
+}
+
+// retryTick starts the fetch once the retry is due.
+func (m model) retryTick() (model, tea.Cmd) {
+	if m.retryAt.IsZero() {
+		return m, nil
+	}
+	if time.Now().Before(m.retryAt) {
+		return m, retryTickCmd()
+	}
+	m.retryAt = time.Time{}
+	m.err = nil
+	m.loading = true
+	return m, m.fetch()
+}
+
+// retryStatus describes the retry state on the error screen, if there
+// has been one.
+func (m model) retryStatus() string {
+	if !m.retryAt.IsZero() {
+		wait := max(time.Until(m.retryAt).Round(time.Second), 0)
+		return trf("Retrying in %s (retry %d)...", wait, m.retries)
+	}
+	if m.retries > 0 {
+		return trf("Retry %d failed. The next retry waits %s.", m.retries, retryDelay(m.retries))
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+
+	"goHeadache/pkg/zutool"
+)
+
+// RiskWeights sets how much each factor contributes to the headache risk
+// score. Only the ratio between the weights matters.
+type RiskWeights struct {
+	// Level weights the API's pressure_level (0-4).
+	Level float64 `toml:"level_weight"`
+	// PressureChange weights the hour-over-hour pressure drop.
+	PressureChange float64 `toml:"pressure_change_weight"`
+	// TemperatureSwing weights the temperature change over three hours.
+	TemperatureSwing float64 `toml:"temperature_swing_weight"`
+	// Personal replaces the weighted score by the personal risk model once
+	// one is trained; see `goHeadache model`. It is off unless set.
+	Personal bool `toml:"personal"`
+	// model is the personal risk model loaded with the config, if any.
+	model *riskModel
+}
+
+var defaultRiskWeights = RiskWeights{Level: 0.5, PressureChange: 0.35, TemperatureSwing: 0.15}
+
+// Inputs at or beyond these values count fully towards their factor.
+const (
+	riskMaxLevel    = 4.0
+	riskMaxDropHPa  = 1.5 // per hour
+	riskMaxSwingDeg = 4.0 // over three hours
+)
+
+// temperatureSwings returns the absolute temperature change over the
+// preceding three hours for each entry.
+func temperatureSwings(data []zutool.HourlyData) (swings []float64, ok []bool) {
+	temps := make([]float64, len(data))
+	valid := make([]bool, len(data))
+	for i, entry := range data {
+		t := strings.TrimSpace(entry.Temp)
+		if t != "" && t != "#" {
+			temps[i] = parseFloat(t)
+			valid[i] = true
+		}
+	}
+	swings = make([]float64, len(data))
+	ok = make([]bool, len(data))
+	for i := 3; i < len(data); i++ {
+		if valid[i] && valid[i-3] {
+			swings[i] = math.Abs(temps[i] - temps[i-3])
+			ok[i] = true
+		}
+	}
+	return swings, ok
+}
+
+// riskScores combines pressure level, pressure drop and temperature swing
+// into a 0-100 score per hour. Factors that cannot be computed for an hour
+// count as zero. With a personal risk model the score is its prediction
+// instead.
+func riskScores(w RiskWeights, data []zutool.HourlyData, deltas []float64, deltaOK []bool) []int {
+	if w.model != nil {
+		return w.model.scores(data, deltas, deltaOK)
+	}
+	total := w.Level + w.PressureChange + w.TemperatureSwing
+	if total <= 0 {
+		w, total = defaultRiskWeights, 1
+	}
+	swings, swingOK := temperatureSwings(data)
+	scores := make([]int, len(data))
+	for i, entry := range data {
+		var level, drop, swing float64
+		if l, err := strconv.Atoi(strings.TrimSpace(entry.PressureLevel)); err == nil {
+			level = math.Min(float64(l)/riskMaxLevel, 1)
+		}
+		if deltaOK[i] && deltas[i] < 0 {
+			drop = math.Min(-deltas[i]/riskMaxDropHPa, 1)
+		}
+		if swingOK[i] {
+			swing = math.Min(swings[i]/riskMaxSwingDeg, 1)
+		}
+		score := (w.Level*level + w.PressureChange*drop + w.TemperatureSwing*swing) / total
+		scores[i] = int(math.Round(score * 100))
+	}
+	return scores
+}
+
+// riskColor returns the badge color for a risk score.
+func riskColor(score int) lipgloss.Style {
+	bg := theme.Risk[min(max(score/25, 0), len(theme.Risk)-1)]
+	return lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(theme.BadgeText)).Background(lipgloss.Color(bg))
+}
+
+// riskBadge renders a score as a colored badge.
+func riskBadge(score int) string {
+	return riskColor(score).Render(fmt.Sprintf(" %3d ", score))
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"goHeadache/pkg/zutool"
+)
+
+// riskFeature is an input of the personal risk model, scaled to 0-1.
+type riskFeature struct {
+	key  string
+	name string
+}
+
+// riskFeatures are the inputs of the personal risk model, in the order of
+// riskFeatureValues.
+var riskFeatures = []riskFeature{
+	{"level", "Pressure level"},
+	{"drop", "Pressure drop this hour"},
+	{"max_drop_6h", "Largest hourly drop in the last 6 hours"},
+	{"fall_3h", "Pressure fall over 3 hours"},
+	{"level_rise", "Level rise over 3 hours"},
+	{"temp_swing", "Temperature swing over 3 hours"},
+}
+
+// riskFeatureValues returns the features of each hour of data, a run of
+// consecutive hours. Inputs at or beyond the risk score's maximums count
+// fully.
+func riskFeatureValues(data []zutool.HourlyData, deltas []float64, deltaOK []bool) [][]float64 {
+	pressures, pressureOK := pressureValues(data)
+	swings, swingOK := temperatureSwings(data)
+	levels := make([]float64, len(data))
+	levelOK := make([]bool, len(data))
+	for i, entry := range data {
+		if l, err := strconv.Atoi(strings.TrimSpace(entry.PressureLevel)); err == nil {
+			levels[i], levelOK[i] = float64(l), true
+		}
+	}
+	clip := func(v float64) float64 { return math.Min(math.Max(v, 0), 1) }
+
+	out := make([][]float64, len(data))
+	for i := range data {
+		x := make([]float64, len(riskFeatures))
+		if levelOK[i] {
+			x[0] = clip(levels[i] / riskMaxLevel)
+		}
+		if deltaOK[i] {
+			x[1] = clip(-deltas[i] / riskMaxDropHPa)
+		}
+		for j := max(i-5, 0); j <= i; j++ {
+			if deltaOK[j] {
+				x[2] = math.Max(x[2], clip(-deltas[j]/riskMaxDropHPa))
+			}
+		}
+		if i >= 3 && pressureOK[i] && pressureOK[i-3] {
+			x[3] = clip((pressures[i-3] - pressures[i]) / (2 * riskMaxDropHPa))
+		}
+		if i >= 3 && levelOK[i] && levelOK[i-3] {
+			x[4] = clip((levels[i] - levels[i-3]) / 2)
+		}
+		if swingOK[i] {
+			x[5] = clip(swings[i] / riskMaxSwingDeg)
+		}
+		out[i] = x
+	}
+	return out
+}
+
+// riskModel is a logistic regression of whether a headache begins within
+// Horizon of an hour on the riskFeatures of the hour, trained on the diary
+// and the history.
+type riskModel struct {
+	TrainedAt time.Time          `json:"trained_at"`
+	Horizon   string             `json:"horizon"`
+	Areas     []string           `json:"areas"`
+	Episodes  int                `json:"episodes"`
+	Hours     int                `json:"hours"`
+	Positive  int                `json:"positive_hours"`
+	Bias      float64            `json:"bias"`
+	Weights   map[string]float64 `json:"weights"`
+}
+
+// minModelEpisodes is how many headaches within the history training needs.
+const minModelEpisodes = 5
+
+// predict returns the probability of a headache within the horizon for
+// features x.
+func (m *riskModel) predict(x []float64) float64 {
+	z := m.Bias
+	for i, f := range riskFeatures {
+		z += m.Weights[f.key] * x[i]
+	}
+	return 1 / (1 + math.Exp(-z))
+}
+
+// scores returns the personal risk of each hour of data as 0-100, the
+// probability of a headache within the horizon.
+func (m *riskModel) scores(data []zutool.HourlyData, deltas []float64, deltaOK []bool) []int {
+	scores := make([]int, len(data))
+	for i, x := range riskFeatureValues(data, deltas, deltaOK) {
+		scores[i] = int(math.Round(m.predict(x) * 100))
+	}
+	return scores
+}
+
+// trainingSample is an hour of the history with its features, and whether
+// a headache began within the horizon after it.
+type trainingSample struct {
+	x        []float64
+	positive bool
+}
+
+// trainingSamples returns the samples of the recorded hours of an area,
+// split into runs of consecutive hours so features never span a gap.
+func trainingSamples(hours map[time.Time]recordedHour, episodes []time.Time, horizon time.Duration) []trainingSample {
+	sorted := make([]historyHour, 0, len(hours))
+	for _, h := range hours {
+		sorted = append(sorted, h.historyHour)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	var samples []trainingSample
+	flush := func(run []historyHour) {
+		data := make([]zutool.HourlyData, len(run))
+		for i, h := range run {
+			data[i] = h.hourlyData()
+		}
+		deltas, deltaOK := pressureDeltas(nil, data)
+		for i, x := range riskFeatureValues(data, deltas, deltaOK) {
+			s := trainingSample{x: x}
+			for _, at := range episodes {
+				if !at.Before(run[i].Time) && at.Before(run[i].Time.Add(horizon)) {
+					s.positive = true
+					break
+				}
+			}
+			samples = append(samples, s)
+		}
+	}
+	start := 0
+	for i := 1; i <= len(sorted); i++ {
+		if i == len(sorted) || !sorted[i-1].Time.Add(time.Hour).Equal(sorted[i].Time) {
+			flush(sorted[start:i])
+			start = i
+		}
+	}
+	return samples
+}
+
+// fitLogistic fits a logistic regression to samples by gradient descent,
+// with a little L2 regularization so rare features do not run off.
+func fitLogistic(samples []trainingSample) (bias float64, weights []float64) {
+	const (
+		rate       = 0.5
+		iterations = 2000
+		lambda     = 0.01
+	)
+	weights = make([]float64, len(riskFeatures))
+	grad := make([]float64, len(weights))
+	n := float64(len(samples))
+	for range iterations {
+		var gradBias float64
+		clear(grad)
+		for _, s := range samples {
+			z := bias
+			for i, v := range s.x {
+				z += weights[i] * v
+			}
+			diff := 1 / (1 + math.Exp(-z))
+			if s.positive {
+				diff--
+			}
+			gradBias += diff
+			for i, v := range s.x {
+				grad[i] += diff * v
+			}
+		}
+		bias -= rate * gradBias / n
+		for i := range weights {
+			weights[i] -= rate * (grad[i]/n + lambda*weights[i])
+		}
+	}
+	return bias, weights
+}
+
+// trainRiskModel trains the model on samples.
+func trainRiskModel(samples []trainingSample, areaCodes []string, episodes int, horizon time.Duration) *riskModel {
+	bias, weights := fitLogistic(samples)
+	m := &riskModel{
+		TrainedAt: time.Now(),
+		Horizon:   fmt.Sprintf("%gh", horizon.Hours()),
+		Areas:     areaCodes,
+		Episodes:  episodes,
+		Hours:     len(samples),
+		Bias:      bias,
+		Weights:   map[string]float64{},
+	}
+	for _, s := range samples {
+		if s.positive {
+			m.Positive++
+		}
+	}
+	for i, f := range riskFeatures {
+		m.Weights[f.key] = weights[i]
+	}
+	return m
+}
+
+// riskModelFile returns the path of the personal risk model,
+// goHeadache/risk-model.json in the user data directory.
+func riskModelFile() (string, error) {
+	dir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goHeadache", "risk-model.json"), nil
+}
+
+// loadRiskModel reads the personal risk model; it is nil when none has
+// been trained.
+func loadRiskModel() (*riskModel, error) {
+	path, err := riskModelFile()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading the personal risk model: %v", err)
+	}
+	var m riskModel
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("error reading the personal risk model %s: %v (train it again with `goHeadache model train`)", path, err)
+	}
+	return &m, nil
+}
+
+// loadModel loads the personal risk model when w uses it.
+func (w *RiskWeights) loadModel() error {
+	if !w.Personal {
+		return nil
+	}
+	var err error
+	w.model, err = loadRiskModel()
+	return err
+}
+
+// save writes m as the personal risk model.
+func (m *riskModel) save() error {
+	path, err := riskModelFile()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// writeSummary prints what m learned: how much each feature raises the
+// odds of a headache when it counts fully.
+func (m *riskModel) writeSummary(w io.Writer) {
+	fmt.Fprintf(w, "Trained %s on %d headaches and %d hours of %s (%d hours with a headache within %s)\n",
+		m.TrainedAt.Format("2006-01-02 15:04"), m.Episodes, m.Hours, strings.Join(m.Areas, ", "), m.Positive, m.Horizon)
+	fmt.Fprintf(w, "Chance of a headache within %s in calm weather: %.1f%%\n", m.Horizon, 100/(1+math.Exp(-m.Bias)))
+	order := make([]int, len(riskFeatures))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return m.Weights[riskFeatures[order[i]].key] > m.Weights[riskFeatures[order[j]].key]
+	})
+	fmt.Fprintf(w, "\n  %-40s  %7s  %6s\n", "FEATURE", "WEIGHT", "ODDS")
+	for _, i := range order {
+		f := riskFeatures[i]
+		fmt.Fprintf(w, "  %-40s  %+7.2f  %5.2f×\n", f.name, m.Weights[f.key], math.Exp(m.Weights[f.key]))
+	}
+	fmt.Fprintln(w, "\nODDS is how much the feature at its fullest multiplies the odds of a headache; below 1× it lowers them.")
+}
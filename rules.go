@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"goHeadache/pkg/zutool"
+)
+
+// RuleConfig is a [[rules]] entry of the config file: a named condition on
+// the forecast that raises a daemon alert.
+type RuleConfig struct {
+	Name string `toml:"name"`
+	// When is the condition, such as "pressure_drop_hpa >= 5 within 6h".
+	// Several conditions can be joined with "or".
+	When string `toml:"when"`
+	// Severity is info, warning (the default) or severe.
+	Severity string `toml:"severity"`
+	// QuietHours is a daily span such as "23:00-07:00" during which the
+	// rule's alerts are held back, like those of [daemon] quiet_hours.
+	QuietHours string `toml:"quiet_hours"`
+}
+
+// defaultRuleWindow is how far ahead a condition without "within" looks.
+const defaultRuleWindow = 6
+
+// maxRuleWindow is the longest "within": the forecast ends the day after
+// tomorrow.
+const maxRuleWindow = 48
+
+// ruleMetric is a value of the forecast that conditions compare.
+type ruleMetric struct {
+	// span metrics are computed over the whole window rather than per hour.
+	span bool
+	// hour returns the value of the metric at hour i of all, and whether
+	// it is known.
+	hour func(all []upcomingHour, risks []int, i int) (float64, bool)
+}
+
+// ruleMetrics are the metrics conditions can use.
+var ruleMetrics = map[string]ruleMetric{
+	// pressure_level is zutool's pressure level (0-4).
+	"pressure_level": {hour: func(all []upcomingHour, _ []int, i int) (float64, bool) {
+		l, err := strconv.Atoi(strings.TrimSpace(all[i].entry.PressureLevel))
+		return float64(l), err == nil
+	}},
+	// pressure is the pressure in hPa.
+	"pressure": {hour: func(all []upcomingHour, _ []int, i int) (float64, bool) {
+		return hourPressure(all[i])
+	}},
+	// pressure_change is the change from the hour before in hPa.
+	"pressure_change": {hour: func(all []upcomingHour, _ []int, i int) (float64, bool) {
+		return all[i].delta, all[i].deltaOK
+	}},
+	// pressure_drop_rate is the fall from the hour before in hPa.
+	"pressure_drop_rate": {hour: func(all []upcomingHour, _ []int, i int) (float64, bool) {
+		return -all[i].delta, all[i].deltaOK
+	}},
+	// temp is the temperature in °C.
+	"temp": {hour: func(all []upcomingHour, _ []int, i int) (float64, bool) {
+		t := strings.TrimSpace(all[i].entry.Temp)
+		v, err := strconv.ParseFloat(t, 64)
+		return v, err == nil
+	}},
+	// risk is the 0-100 headache risk score.
+	"risk": {hour: func(_ []upcomingHour, risks []int, i int) (float64, bool) {
+		return float64(risks[i]), true
+	}},
+	// pressure_drop_hpa is the largest fall from an hour of the window to a
+	// later one.
+	"pressure_drop_hpa": {span: true},
+}
+
+func hourPressure(u upcomingHour) (float64, bool) {
+	p := strings.TrimSpace(u.entry.Pressure)
+	v, err := strconv.ParseFloat(p, 64)
+	return v, err == nil
+}
+
+// ruleCondition is one comparison of a rule, such as "pressure_level >= 3".
+type ruleCondition struct {
+	metric string
+	op     string
+	value  float64
+	hours  int // how far ahead to look
+}
+
+// parseCondition parses "<metric> <op> <number> [within <hours>h]".
+func parseCondition(s string, hours int) (ruleCondition, error) {
+	f := strings.Fields(s)
+	if len(f) != 3 && len(f) != 5 {
+		return ruleCondition{}, fmt.Errorf("%q is not \"<metric> <op> <number> [within <hours>h]\"", s)
+	}
+	c := ruleCondition{metric: f[0], op: f[1], hours: hours}
+	if _, ok := ruleMetrics[c.metric]; !ok {
+		return c, fmt.Errorf("unknown metric %q (use %s)", c.metric, strings.Join(ruleMetricNames(), ", "))
+	}
+	switch c.op {
+	case ">=", ">", "<=", "<", "==":
+	default:
+		return c, fmt.Errorf("unknown operator %q (use >=, >, <=, < or ==)", c.op)
+	}
+	v, err := strconv.ParseFloat(f[2], 64)
+	if err != nil {
+		return c, fmt.Errorf("%q is not a number", f[2])
+	}
+	c.value = v
+	if len(f) == 5 {
+		d, err := time.ParseDuration(f[4])
+		if f[3] != "within" || err != nil || d < time.Hour || d%time.Hour != 0 || d > maxRuleWindow*time.Hour {
+			return c, fmt.Errorf("%q is not \"within <hours>h\" of 1h to %dh", f[3]+" "+f[4], maxRuleWindow)
+		}
+		c.hours = int(d / time.Hour)
+	}
+	return c, nil
+}
+
+func ruleMetricNames() []string {
+	return []string{"pressure_level", "pressure", "pressure_change", "pressure_drop_rate", "pressure_drop_hpa", "temp", "risk"}
+}
+
+func (c ruleCondition) holds(v float64) bool {
+	switch c.op {
+	case ">=":
+		return v >= c.value
+	case ">":
+		return v > c.value
+	case "<=":
+		return v <= c.value
+	case "<":
+		return v < c.value
+	}
+	return v == c.value
+}
+
+// evaluate returns the first hour in the window at which c holds.
+func (c ruleCondition) evaluate(all []upcomingHour, risks []int, now time.Time) (pressureWarning, bool) {
+	if ruleMetrics[c.metric].span {
+		return c.evaluateDrop(all, now)
+	}
+	hour := ruleMetrics[c.metric].hour
+	for i, u := range all {
+		if !u.within(now, c.hours) {
+			continue
+		}
+		if v, ok := hour(all, risks, i); ok && c.holds(v) {
+			return hourWarning(u), true
+		}
+	}
+	return pressureWarning{}, false
+}
+
+// evaluateDrop checks the largest fall in pressure within the window.
+func (c ruleCondition) evaluateDrop(all []upcomingHour, now time.Time) (pressureWarning, bool) {
+	var window []upcomingHour
+	for _, u := range all {
+		if u.within(now, c.hours) {
+			window = append(window, u)
+		}
+	}
+	from, to, drop, ok := largestDrop(window)
+	if !ok || !c.holds(drop) {
+		return pressureWarning{}, false
+	}
+	w := hourWarning(from)
+	w.drop, w.until, w.pressure = drop, to.at, strings.TrimSpace(to.entry.Pressure)
+	return w, true
+}
+
+// largestDrop returns the largest fall in pressure from an hour of hours to
+// a later one. ok is false when the pressure never falls.
+func largestDrop(hours []upcomingHour) (from, to upcomingHour, drop float64, ok bool) {
+	var (
+		high     float64
+		highAt   upcomingHour
+		haveHigh bool
+	)
+	for _, u := range hours {
+		p, valid := hourPressure(u)
+		if !valid {
+			continue
+		}
+		if haveHigh && high-p > drop {
+			drop, from, to, ok = high-p, highAt, u, true
+		}
+		if !haveHigh || p > high {
+			high, highAt, haveHigh = p, u, true
+		}
+	}
+	return from, to, drop, ok
+}
+
+// hourWarning is the warning about the single hour u.
+func hourWarning(u upcomingHour) pressureWarning {
+	level, _ := strconv.Atoi(strings.TrimSpace(u.entry.PressureLevel))
+	w := pressureWarning{at: u.at, level: level, pressure: strings.TrimSpace(u.entry.Pressure)}
+	if u.deltaOK && u.delta < 0 {
+		w.drop = -u.delta
+	}
+	return w
+}
+
+// alertRule is a parsed rule.
+type alertRule struct {
+	name       string
+	severity   string
+	conditions []ruleCondition // any of them raises the alert
+	quiet      quietHours
+}
+
+// parseRule checks cfg and returns its rule.
+func parseRule(cfg RuleConfig) (alertRule, error) {
+	r := alertRule{name: cfg.Name, severity: cfg.Severity}
+	if r.name == "" {
+		return r, fmt.Errorf("a rule has no name")
+	}
+	switch r.severity {
+	case "":
+		r.severity = "warning"
+	case "info", "warning", "severe":
+	default:
+		return r, fmt.Errorf("rule %s: unknown severity %q (use info, warning or severe)", r.name, r.severity)
+	}
+	if strings.TrimSpace(cfg.When) == "" {
+		return r, fmt.Errorf("rule %s: when is empty", r.name)
+	}
+	for _, part := range strings.Split(cfg.When, " or ") {
+		c, err := parseCondition(part, defaultRuleWindow)
+		if err != nil {
+			return r, fmt.Errorf("rule %s: %v", r.name, err)
+		}
+		r.conditions = append(r.conditions, c)
+	}
+	var err error
+	if r.quiet, err = parseQuietHours(cfg.QuietHours); err != nil {
+		return r, fmt.Errorf("rule %s: %v", r.name, err)
+	}
+	return r, nil
+}
+
+// alertRules returns the rules of cfg or, when it has none, a single rule
+// made from the [notify] thresholds.
+func alertRules(cfg Config) ([]alertRule, error) {
+	if len(cfg.Rules) == 0 {
+		n := cfg.Notify
+		r := alertRule{name: "notify", severity: "warning"}
+		if n.Level > 0 {
+			r.conditions = append(r.conditions, ruleCondition{metric: "pressure_level", op: ">=", value: float64(n.Level), hours: n.Hours})
+		}
+		if n.Drop > 0 {
+			r.conditions = append(r.conditions, ruleCondition{metric: "pressure_drop_rate", op: ">=", value: n.Drop, hours: n.Hours})
+		}
+		if len(r.conditions) == 0 {
+			return nil, nil
+		}
+		return []alertRule{r}, nil
+	}
+	seen := map[string]bool{}
+	var rules []alertRule
+	for _, rc := range cfg.Rules {
+		r, err := parseRule(rc)
+		if err != nil {
+			return nil, err
+		}
+		if seen[r.name] {
+			return nil, fmt.Errorf("two rules are named %s", r.name)
+		}
+		seen[r.name] = true
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// evaluate returns the earliest warning of any condition of r in w.
+func (r alertRule) evaluate(w zutool.WeatherData, weights RiskWeights, now time.Time) (pressureWarning, bool) {
+	all, data := forecastHours(w, now)
+	deltas, ok := pressureDeltas(nil, data)
+	risks := riskScores(weights, data, deltas, ok)
+	var first pressureWarning
+	found := false
+	for _, c := range r.conditions {
+		if w, ok := c.evaluate(all, risks, now); ok && (!found || w.at.Before(first.at)) {
+			first, found = w, true
+		}
+	}
+	return first, found
+}
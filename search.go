@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+
+	"goHeadache/internal/areas"
+	"goHeadache/pkg/zutool"
+)
+
+// printWeatherPoints writes a numbered list of search results.
+func printWeatherPoints(w io.Writer, points []zutool.WeatherPoint) {
+	for i, p := range points {
+		fmt.Fprintf(w, "%3d) %s  %s (%s)\n", i+1, p.CityCode, p.Name, p.NameKana)
+	}
+}
+
+// searchPlaces finds places matching keyword with the getweatherpoint API.
+// With offline set, or when the API cannot be reached, the embedded area
+// database is searched instead.
+func searchPlaces(keyword string, offline bool) ([]zutool.WeatherPoint, error) {
+	if !offline {
+		points, err := apiClient.SearchWeatherPoints(context.Background(), keyword)
+		if err == nil {
+			return points, nil
+		}
+		fmt.Fprintf(os.Stderr, "Search API unavailable (%v), using the offline area list\n", err)
+	}
+	return areaPoints(areas.Search(keyword)), nil
+}
+
+// areaPoints converts embedded areas to search results.
+func areaPoints(list []areas.Area) []zutool.WeatherPoint {
+	points := make([]zutool.WeatherPoint, len(list))
+	for i, a := range list {
+		points[i] = zutool.WeatherPoint{CityCode: a.Code, Name: a.FullName(), NameKana: a.NameEn}
+	}
+	return points
+}
+
+// pickWeatherPoint lists points and asks the user to choose one by number.
+// An empty answer cancels the selection and returns ok=false.
+func pickWeatherPoint(in io.Reader, out io.Writer, points []zutool.WeatherPoint) (zutool.WeatherPoint, bool, error) {
+	printWeatherPoints(out, points)
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "Select a place [1-%d] (empty to cancel): ", len(points))
+		if !scanner.Scan() {
+			return zutool.WeatherPoint{}, false, scanner.Err()
+		}
+		answer := strings.TrimSpace(scanner.Text())
+		if answer == "" {
+			return zutool.WeatherPoint{}, false, nil
+		}
+		n, err := strconv.Atoi(answer)
+		if err != nil || n < 1 || n > len(points) {
+			fmt.Fprintf(out, "Please enter a number between 1 and %d.\n", len(points))
+			continue
+		}
+		return points[n-1], true, nil
+	}
+}
+
+// resolvePlace searches for keyword and returns the area code of the match.
+// A single match is used directly; several matches prompt the user when
+// stdin is a terminal.
+func resolvePlace(keyword string) (string, error) {
+	points, err := searchPlaces(keyword, false)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case len(points) == 0:
+		return "", fmt.Errorf("no places found for %q", keyword)
+	case len(points) == 1:
+		return points[0].CityCode, nil
+	case !term.IsTerminal(os.Stdin.Fd()):
+		printWeatherPoints(os.Stderr, points)
+		return "", fmt.Errorf("%d places match %q, please be more specific or pass an area code", len(points), keyword)
+	}
+
+	point, ok, err := pickWeatherPoint(os.Stdin, os.Stdout, points)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("no place selected")
+	}
+	return point.CityCode, nil
+}
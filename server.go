@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"goHeadache/internal/areas"
+	"goHeadache/pkg/zutool"
+)
+
+// maxServeHours is how far ahead /v1/risk can look.
+const maxServeHours = 72
+
+// apiServer serves forecasts as JSON, loaded through the cache so that
+// clients share the requests to the source.
+type apiServer struct {
+	cfg Config // for saved location names and the risk weights
+	mux *http.ServeMux
+}
+
+func newAPIServer(cfg Config) *apiServer {
+	s := &apiServer{cfg: cfg, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /v1/forecast/{area}", s.forecast)
+	s.mux.HandleFunc("GET /v1/risk/{area}", s.risk)
+	s.mux.HandleFunc("GET /v1/{$}", s.index)
+	s.mux.HandleFunc("GET /{$}", s.dashboard)
+	return s
+}
+
+func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The API is read-only, so any page may query it.
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	s.mux.ServeHTTP(w, r)
+}
+
+// apiForecast is the response of /v1/forecast/{area}.
+type apiForecast struct {
+	AreaCode  string `json:"area_code"`
+	Place     string `json:"place"`
+	FetchedAt string `json:"fetched_at"`
+	// Stale says why an old cached forecast was returned, if it was.
+	Stale string    `json:"stale,omitempty"`
+	Hours []apiHour `json:"hours"`
+}
+
+// apiHour is an hour of the forecast. Values the source lacks are null.
+type apiHour struct {
+	at             time.Time
+	Time           string   `json:"time"`
+	Day            string   `json:"day"` // yesterday, today, tomorrow or dayafter
+	Weather        string   `json:"weather"`
+	WeatherCode    string   `json:"weather_code"`
+	Temp           *float64 `json:"temp"`
+	Pressure       *float64 `json:"pressure"`
+	PressureLevel  *int     `json:"pressure_level"`
+	LevelName      string   `json:"level_name"`
+	PressureChange *float64 `json:"pressure_change"`
+	Risk           int      `json:"risk"`
+}
+
+// apiRisk is the response of /v1/risk/{area}.
+type apiRisk struct {
+	AreaCode  string `json:"area_code"`
+	Place     string `json:"place"`
+	FetchedAt string `json:"fetched_at"`
+	Stale     string `json:"stale,omitempty"`
+	// Risk is the score of the current hour, and MaxRisk the highest of
+	// the next Hours at MaxRiskAt.
+	Risk      int           `json:"risk"`
+	MaxRisk   int           `json:"max_risk"`
+	MaxRiskAt string        `json:"max_risk_at"`
+	Hours     []apiRiskHour `json:"hours"`
+}
+
+type apiRiskHour struct {
+	Time          string `json:"time"`
+	Risk          int    `json:"risk"`
+	PressureLevel *int   `json:"pressure_level"`
+}
+
+// apiDays are the names of the days of a forecast, from yesterday.
+var apiDays = []string{"yesterday", "today", "tomorrow", "dayafter"}
+
+// apiDay returns the name of the day of at.
+func apiDay(at, today time.Time) string {
+	for i, name := range apiDays {
+		if sameDay(at, today.AddDate(0, 0, i-1)) {
+			return name
+		}
+	}
+	return ""
+}
+
+// hours loads the forecast of the area of r with the risk score of every
+// hour. It writes the error response and returns false when it fails.
+func (s *apiServer) hours(w http.ResponseWriter, r *http.Request) (code string, res weatherResult, hours []apiHour, ok bool) {
+	code, res, hours, status, err := s.loadHours(r.Context(), r.PathValue("area"))
+	if err != nil {
+		writeAPIError(w, status, err)
+		return "", res, nil, false
+	}
+	return code, res, hours, true
+}
+
+// loadHours loads the forecast of area, an area code or saved location
+// name, with the risk score of every hour. On failure it returns the HTTP
+// status of the error.
+func (s *apiServer) loadHours(ctx context.Context, area string) (code string, res weatherResult, hours []apiHour, status int, err error) {
+	code = area
+	if loc, found := s.cfg.findLocation(code); found {
+		code = loc.Area
+	}
+	if code, err = areas.Normalize(code); err != nil {
+		return "", res, nil, http.StatusNotFound, err
+	}
+	res, err = loadWeather(ctx, code, true)
+	if errors.Is(err, zutool.ErrAreaNotFound) {
+		return "", res, nil, http.StatusNotFound, err
+	}
+	if err != nil {
+		return "", res, nil, http.StatusBadGateway, err
+	}
+	return code, res, apiHours(s.cfg.Risk, res.data, time.Now()), http.StatusOK, nil
+}
+
+// apiHours returns every hour of forecast w with its risk score, weighted by
+// weights.
+func apiHours(weights RiskWeights, w zutool.WeatherData, now time.Time) []apiHour {
+	var hours []apiHour
+	today := midnight(now)
+	all, data := forecastHours(w, now)
+	deltas, deltaOK := pressureDeltas(nil, data)
+	risks := riskScores(weights, data, deltas, deltaOK)
+	for i, u := range all {
+		h := apiHour{
+			at:          u.at,
+			Time:        u.at.Format(time.RFC3339),
+			Day:         apiDay(u.at, today),
+			Weather:     translateWeatherCode(u.entry.Weather),
+			WeatherCode: strings.TrimSpace(u.entry.Weather),
+			LevelName:   levelName(u.entry.PressureLevel),
+			Risk:        risks[i],
+		}
+		if t, err := strconv.ParseFloat(strings.TrimSpace(u.entry.Temp), 64); err == nil {
+			h.Temp = &t
+		}
+		if p, ok := hourPressure(u); ok {
+			h.Pressure = &p
+		}
+		if l, err := strconv.Atoi(strings.TrimSpace(u.entry.PressureLevel)); err == nil {
+			h.PressureLevel = &l
+		}
+		if u.deltaOK {
+			change := roundTenth(u.delta)
+			h.PressureChange = &change
+		}
+		hours = append(hours, h)
+	}
+	return hours
+}
+
+// forecast serves every hour of the forecast, or those of ?day=.
+func (s *apiServer) forecast(w http.ResponseWriter, r *http.Request) {
+	day := r.URL.Query().Get("day")
+	if _, err := dayIndices(day); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	code, res, hours, ok := s.hours(w, r)
+	if !ok {
+		return
+	}
+	out := apiForecast{AreaCode: code, Place: res.data.PlaceName, FetchedAt: res.fetchedAt.Format(time.RFC3339), Hours: []apiHour{}}
+	if res.stale != nil {
+		out.Stale = res.stale.Error()
+	}
+	for _, h := range hours {
+		if day == "" || strings.EqualFold(h.Day, day) {
+			out.Hours = append(out.Hours, h)
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// risk serves the risk score of the current hour and the next ?hours=
+// (default 24).
+func (s *apiServer) risk(w http.ResponseWriter, r *http.Request) {
+	ahead := 24
+	if v := r.URL.Query().Get("hours"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > maxServeHours {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("hours must be between 0 and %d", maxServeHours))
+			return
+		}
+		ahead = n
+	}
+	code, res, hours, ok := s.hours(w, r)
+	if !ok {
+		return
+	}
+	out := apiRisk{AreaCode: code, Place: res.data.PlaceName, FetchedAt: res.fetchedAt.Format(time.RFC3339), MaxRisk: -1, Hours: []apiRiskHour{}}
+	if res.stale != nil {
+		out.Stale = res.stale.Error()
+	}
+	current := time.Now().Truncate(time.Hour)
+	end := current.Add(time.Duration(ahead) * time.Hour)
+	for _, h := range hours {
+		if h.at.Before(current) || h.at.After(end) {
+			continue
+		}
+		if h.at.Equal(current) {
+			out.Risk = h.Risk
+		}
+		if h.Risk > out.MaxRisk {
+			out.MaxRisk, out.MaxRiskAt = h.Risk, h.Time
+		}
+		out.Hours = append(out.Hours, apiRiskHour{Time: h.Time, Risk: h.Risk, PressureLevel: h.PressureLevel})
+	}
+	if len(out.Hours) == 0 {
+		writeAPIError(w, http.StatusBadGateway, errors.New("the forecast has no upcoming hours"))
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// index lists the endpoints.
+func (s *apiServer) index(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"endpoints": []string{"/v1/forecast/{area}?day=today", "/v1/risk/{area}?hours=24"},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
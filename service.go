@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// serviceSpec is what a generated service runs.
+type serviceSpec struct {
+	exe string // absolute path of the goHeadache binary
+	// args are the goHeadache arguments, such as ["daemon", "13101"].
+	args []string
+	// configPath is passed in GOHEADACHE_CONFIG so the service reads the
+	// same config file as the user who installed it.
+	configPath string
+	// interval is how often a check service runs; zero for the daemon,
+	// which keeps running.
+	interval time.Duration
+}
+
+// serviceFile is a file written by service install.
+type serviceFile struct {
+	path    string
+	content string
+}
+
+const (
+	serviceName        = "goheadache"
+	launchdLabel       = "io.github.satoi8080.goheadache"
+	windowsTaskName    = "goHeadache"
+	serviceLogName     = "goHeadache.log"
+	serviceCheckSuffix = "-check"
+)
+
+// serviceFiles returns the files describing s on the current OS, and the
+// systemd unit or launchd label to enable. Windows has none; its scheduled
+// task is created by schtasks directly.
+func serviceFiles(s serviceSpec) ([]serviceFile, string, error) {
+	suffix := ""
+	if s.interval > 0 {
+		suffix = serviceCheckSuffix
+	}
+	switch runtime.GOOS {
+	case "windows":
+		return nil, "", nil
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, "", err
+		}
+		label := launchdLabel + strings.ReplaceAll(suffix, "-", ".")
+		logPath := filepath.Join(home, "Library", "Logs", serviceLogName)
+		return []serviceFile{{
+			path:    filepath.Join(home, "Library", "LaunchAgents", label+".plist"),
+			content: launchdPlist(s, label, logPath),
+		}}, label, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, "", err
+	}
+	dir = filepath.Join(dir, "systemd", "user")
+	name := serviceName + suffix
+	files := []serviceFile{{path: filepath.Join(dir, name+".service"), content: systemdService(s)}}
+	if s.interval == 0 {
+		return files, name + ".service", nil
+	}
+	files = append(files, serviceFile{path: filepath.Join(dir, name+".timer"), content: systemdTimer(s)})
+	return files, name + ".timer", nil
+}
+
+// systemdService returns the user unit running s.
+func systemdService(s serviceSpec) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	if s.interval > 0 {
+		b.WriteString("Description=goHeadache pressure check\n")
+	} else {
+		b.WriteString("Description=goHeadache pressure alerts\n")
+	}
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("After=network-online.target\n\n")
+	b.WriteString("[Service]\n")
+	if s.interval > 0 {
+		b.WriteString("Type=oneshot\n")
+		// check exits 1 and 2 for warnings, which are not failures.
+		b.WriteString("SuccessExitStatus=1 2\n")
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", systemdCommandLine(append([]string{s.exe}, s.args...)))
+	fmt.Fprintf(&b, "Environment=%s\n", systemdQuote("GOHEADACHE_CONFIG="+s.configPath))
+	if s.interval == 0 {
+		b.WriteString("Restart=on-failure\n")
+		b.WriteString("RestartSec=30\n\n")
+		b.WriteString("[Install]\n")
+		b.WriteString("WantedBy=default.target\n")
+	}
+	return b.String()
+}
+
+// systemdTimer returns the timer starting the check service of s.
+func systemdTimer(s serviceSpec) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Run the goHeadache pressure check periodically\n\n")
+	b.WriteString("[Timer]\n")
+	b.WriteString("OnBootSec=2min\n")
+	fmt.Fprintf(&b, "OnUnitActiveSec=%ds\n", int(s.interval/time.Second))
+	b.WriteString("Persistent=true\n\n")
+	b.WriteString("[Install]\n")
+	b.WriteString("WantedBy=timers.target\n")
+	return b.String()
+}
+
+// systemdCommandLine quotes args for ExecStart.
+func systemdCommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = systemdQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// systemdQuote quotes s as one word of a unit file when it needs it.
+// Percent signs are specifiers in unit files and are always doubled.
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;$") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`).Replace(s) + `"`
+}
+
+// launchdPlist returns the launch agent running s.
+func launchdPlist(s serviceSpec, label, logPath string) string {
+	esc := func(v string) string {
+		var b strings.Builder
+		_ = xml.EscapeText(&b, []byte(v))
+		return b.String()
+	}
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", esc(label))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, a := range append([]string{s.exe}, s.args...) {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", esc(a))
+	}
+	b.WriteString("\t</array>\n")
+	b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+	fmt.Fprintf(&b, "\t\t<key>GOHEADACHE_CONFIG</key>\n\t\t<string>%s</string>\n", esc(s.configPath))
+	b.WriteString("\t</dict>\n")
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	if s.interval > 0 {
+		fmt.Fprintf(&b, "\t<key>StartInterval</key>\n\t<integer>%d</integer>\n", int(s.interval/time.Second))
+	} else {
+		b.WriteString("\t<key>KeepAlive</key>\n\t<true/>\n")
+	}
+	fmt.Fprintf(&b, "\t<key>StandardErrorPath</key>\n\t<string>%s</string>\n", esc(logPath))
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+// windowsTask returns the name of the scheduled task of s and the schtasks
+// arguments creating it: at logon for the daemon, every interval for check.
+func windowsTask(s serviceSpec) (name string, args []string) {
+	name = windowsTaskName
+	if s.interval > 0 {
+		name += " check"
+	}
+	// Scheduled tasks cannot set environment variables, so cmd sets
+	// GOHEADACHE_CONFIG before starting goHeadache.
+	run := append([]string{s.exe}, s.args...)
+	for i, a := range run {
+		if strings.ContainsAny(a, " \t") {
+			run[i] = `"` + a + `"`
+		}
+	}
+	tr := fmt.Sprintf(`cmd /c "set "GOHEADACHE_CONFIG=%s" && %s"`, s.configPath, strings.Join(run, " "))
+	args = []string{"/Create", "/F", "/TN", name, "/TR", tr}
+	if s.interval > 0 {
+		args = append(args, "/SC", "MINUTE", "/MO", fmt.Sprint(int(s.interval/time.Minute)))
+	} else {
+		args = append(args, "/SC", "ONLOGON")
+	}
+	return name, args
+}
+
+// serviceExecutable returns the absolute path of the running binary, which
+// the service runs.
+func serviceExecutable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("error locating the goHeadache binary: %v", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	if strings.Contains(exe, string(filepath.Separator)+"go-build") {
+		return "", fmt.Errorf("%s is a temporary binary (go run); install goHeadache first, e.g. with go install", exe)
+	}
+	return exe, nil
+}
+
+// runService runs a service manager command, showing it and its output.
+func runService(name string, args ...string) error {
+	fmt.Printf("$ %s %s\n", name, strings.Join(args, " "))
+	cmd := exec.Command(name, args...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running %s: %v", name, err)
+	}
+	return nil
+}
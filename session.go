@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// sessionState is what the forecast screen remembers from one run to the
+// next, so that goHeadache without arguments resumes where it left off: the
+// area, the day shown, the theme given with -theme and how the screen was
+// set up with the keys.
+type sessionState struct {
+	Area          string   `json:"area,omitempty"`
+	Day           int      `json:"day"`
+	Theme         string   `json:"theme,omitempty"`
+	Graph         bool     `json:"graph,omitempty"`
+	Timeline      int      `json:"timeline,omitempty"`
+	HideNight     bool     `json:"hide_night,omitempty"`
+	MinLevel      int      `json:"min_level,omitempty"`
+	Sort          string   `json:"sort,omitempty"`
+	DayOverDay    bool     `json:"day_over_day,omitempty"`
+	HiddenColumns []string `json:"hidden_columns"`
+}
+
+// sortNames are the names of the table orders in the session file.
+var sortNames = map[tableSort]string{sortByTime: "time", sortByPressure: "pressure", sortByRisk: "risk"}
+
+// sessionFile returns where the session is kept:
+// <state dir>/goHeadache/session.json.
+func sessionFile() (string, error) {
+	dir, err := userStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goHeadache", "session.json"), nil
+}
+
+// loadSession reads the last session, ok=false when there is none. An
+// unreadable file is no session: at worst the screen starts from the
+// defaults.
+func loadSession() (s sessionState, ok bool) {
+	path, err := sessionFile()
+	if err != nil {
+		return s, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, false
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		debugLog.Warn("unreadable session", "path", path, "error", err)
+		return sessionState{}, false
+	}
+	return s, true
+}
+
+// save writes s as the last session.
+func (s sessionState) save() error {
+	path, err := sessionFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0o644)
+}
+
+// session returns the state of m to remember, the theme being that of
+// remember.
+func (m model) session() sessionState {
+	s := *m.remember
+	s.Area = m.areaCode
+	if m.dayFilter == "" {
+		s.Day = m.currentDay
+	}
+	s.Graph = m.graphMode
+	s.Timeline = m.timelineDays
+	s.HideNight = m.hideNight
+	s.MinLevel = m.minLevel
+	s.Sort = sortNames[m.sortBy]
+	s.DayOverDay = m.dayOverDay
+	s.HiddenColumns = []string{}
+	for name, hidden := range m.hiddenColumns {
+		if hidden {
+			s.HiddenColumns = append(s.HiddenColumns, name)
+		}
+	}
+	slices.Sort(s.HiddenColumns)
+	return s
+}
+
+// saveSession remembers the state of m, if it is to be remembered.
+func (m model) saveSession() {
+	if m.remember == nil || m.areaCode == "" {
+		return
+	}
+	if err := m.session().save(); err != nil {
+		debugLog.Warn("error saving the session", "error", err)
+	}
+}
+
+// restore sets the screen up as s left it. Values that are no longer valid,
+// such as a column that was renamed, are left at their defaults; see
+// newForecastModel for the config settings that win over s.
+func (m model) restore(s sessionState) model {
+	if m.dayFilter == "" && s.Day >= 0 && s.Day <= 3 {
+		m.currentDay = s.Day
+	}
+	m.graphMode = s.Graph
+	if s.Timeline == 2 || s.Timeline == 3 {
+		m.timelineDays = s.Timeline
+	}
+	m.hideNight = s.HideNight
+	if slices.Contains(levelFilters, s.MinLevel) {
+		m.minLevel = s.MinLevel
+	}
+	for order, name := range sortNames {
+		if name == s.Sort {
+			m.sortBy = order
+		}
+	}
+	m.dayOverDay = s.DayOverDay
+	if s.HiddenColumns != nil {
+		if hidden, err := parseHiddenColumns(s.HiddenColumns); err == nil {
+			m.hiddenColumns = hidden
+		}
+	}
+	return m
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+
+	"goHeadache/internal/areas"
+	"goHeadache/internal/source"
+	"goHeadache/pkg/jma"
+	"goHeadache/pkg/openmeteo"
+	"goHeadache/pkg/owm"
+)
+
+var (
+	openMeteoClient = openmeteo.NewClient(nil)
+	jmaClient       = jma.NewClient(nil)
+	owmClient       = owm.NewClient(nil)
+)
+
+// weatherSource is the source used for forecasts, chosen with -source or
+// source in the config file.
+var weatherSource source.Source = source.Zutool{Client: apiClient}
+
+// newWeatherSource returns the source of a -source or config value. New sources are
+// added here.
+func newWeatherSource(name string) (source.Source, error) {
+	switch name {
+	case "", "zutool":
+		return source.Zutool{Client: apiClient}, nil
+	case "open-meteo":
+		return source.OpenMeteo{Client: openMeteoClient}, nil
+	case "jma":
+		return source.JMA{Client: jmaClient}, nil
+	case "owm":
+		if owmClient.APIKey == "" {
+			return nil, fmt.Errorf("-source owm needs an API key: set owm_api_key in the config file or OWM_API_KEY")
+		}
+		return source.OWM{Client: owmClient}, nil
+	}
+	return nil, fmt.Errorf("unknown source %q (use zutool, jma, owm or open-meteo)", name)
+}
+
+// usesZutool reports whether forecasts come from zutool, which also has the
+// headache reports.
+func usesZutool() bool {
+	_, ok := weatherSource.(source.Zutool)
+	return ok
+}
+
+// locationOf returns the location of a forecast key. Area codes in the
+// embedded area list get its coordinates too, for sources that need them.
+func locationOf(key string) (source.Location, error) {
+	loc, err := source.ParseKey(key)
+	if err != nil || loc.AreaCode == "" {
+		return loc, err
+	}
+	if a, ok := areas.Lookup(loc.AreaCode); ok && (a.Lat != 0 || a.Lon != 0) {
+		loc.Lat, loc.Lon, loc.HasCoords = a.Lat, a.Lon, true
+	}
+	return loc, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// historyStats summarizes the recorded days of an area, as printed by
+// stats.
+type historyStats struct {
+	Area          string        `json:"area"`
+	Place         string        `json:"place"`
+	From          string        `json:"from"`
+	To            string        `json:"to"`
+	Days          int           `json:"days"`
+	Hours         int           `json:"hours"`
+	MeasuredHours int           `json:"measured_hours"`
+	Pressure      pressureStats `json:"pressure"`
+	// SevereDrops counts the spells of falls of at least dropSevereHPa
+	// within an hour; consecutive hours are one spell.
+	SevereDrops int        `json:"severe_drops"`
+	WorstDays   []dayStats `json:"worst_days"`
+}
+
+type pressureStats struct {
+	Min   float64   `json:"min"`
+	MinAt time.Time `json:"min_at"`
+	Max   float64   `json:"max"`
+	MaxAt time.Time `json:"max_at"`
+	Mean  float64   `json:"mean"`
+}
+
+// dayStats is a day of historyStats.WorstDays. MaxLevel is -1 when the day
+// has no pressure levels.
+type dayStats struct {
+	Day         string  `json:"day"`
+	MaxLevel    int     `json:"max_level"`
+	LargestDrop float64 `json:"largest_drop"`
+	Min         float64 `json:"min"`
+	Max         float64 `json:"max"`
+}
+
+// newHistoryStats computes the statistics of days, which are oldest first,
+// with the worst of them ranked by their highest pressure level, then by
+// their largest fall within an hour.
+func newHistoryStats(areaCode string, days []historyDay, worst int) historyStats {
+	s := historyStats{Area: areaCode, Days: len(days)}
+	if len(days) == 0 {
+		return s
+	}
+	s.Place = days[len(days)-1].place
+	s.From = days[0].day.Format(time.DateOnly)
+	s.To = days[len(days)-1].day.Format(time.DateOnly)
+
+	var (
+		sum, count float64
+		prev       historyHour
+		inDrop     bool
+		ranked     []dayStats
+	)
+	for _, d := range days {
+		s.Hours += len(d.hours)
+		s.MeasuredHours += d.observed
+		for _, h := range d.hours {
+			fall, ok := hourlyFall(prev, h)
+			severe := ok && -fall <= dropSevereHPa
+			if severe && !inDrop {
+				s.SevereDrops++
+			}
+			inDrop, prev = severe, h
+			if h.Pressure == nil {
+				continue
+			}
+			p := *h.Pressure
+			if count == 0 || p < s.Pressure.Min {
+				s.Pressure.Min, s.Pressure.MinAt = p, h.Time
+			}
+			if count == 0 || p > s.Pressure.Max {
+				s.Pressure.Max, s.Pressure.MaxAt = p, h.Time
+			}
+			sum += p
+			count++
+		}
+
+		ds := dayStats{Day: d.day.Format(time.DateOnly), MaxLevel: -1}
+		for _, h := range d.hours {
+			if h.Level != nil {
+				ds.MaxLevel = max(ds.MaxLevel, *h.Level)
+			}
+		}
+		drop, _ := d.largestDrop()
+		ds.LargestDrop = roundTenth(drop)
+		if lo, hi, ok := d.pressureRange(); ok {
+			ds.Min, ds.Max = roundTenth(lo), roundTenth(hi)
+		}
+		ranked = append(ranked, ds)
+	}
+	if count > 0 {
+		s.Pressure.Min = roundTenth(s.Pressure.Min)
+		s.Pressure.Max = roundTenth(s.Pressure.Max)
+		s.Pressure.Mean = roundTenth(sum / count)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].MaxLevel != ranked[j].MaxLevel {
+			return ranked[i].MaxLevel > ranked[j].MaxLevel
+		}
+		return ranked[i].LargestDrop > ranked[j].LargestDrop
+	})
+	s.WorstDays = ranked[:min(worst, len(ranked))]
+	return s
+}
+
+// writeTable prints s for people.
+func (s historyStats) writeTable(w io.Writer) {
+	fmt.Fprintf(w, "%s (%s): %d days from %s to %s, %d of %d hours measured\n",
+		s.Place, s.Area, s.Days, s.From, s.To, s.MeasuredHours, s.Hours)
+	if s.Pressure.MinAt.IsZero() {
+		fmt.Fprintln(w, "Pressure      no data")
+	} else {
+		fmt.Fprintf(w, "Pressure      min %s %s (%s) · max %s %s (%s) · mean %s %s\n",
+			units.formatPressure(s.Pressure.Min), units.pressure, s.Pressure.MinAt.Format("2006-01-02 15:04"),
+			units.formatPressure(s.Pressure.Max), units.pressure, s.Pressure.MaxAt.Format("2006-01-02 15:04"),
+			units.formatPressure(s.Pressure.Mean), units.pressure)
+	}
+	fmt.Fprintf(w, "Severe drops  %d (falls of %s or more)\n", s.SevereDrops, units.formatFall(-dropSevereHPa)+"/h")
+	if len(s.WorstDays) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nWorst days")
+	levelW := levelNameWidth() + 2
+	fmt.Fprintf(w, "  %-10s  %s  %-13s  %s\n", "DAY", padCells("LEVEL", levelW), "DROP", "RANGE")
+	for _, d := range s.WorstDays {
+		level := "-"
+		if d.MaxLevel >= 0 {
+			level = fmt.Sprintf("%d %s", d.MaxLevel, levelName(strconv.Itoa(d.MaxLevel)))
+		}
+		drop := "-"
+		if d.LargestDrop > 0 {
+			drop = units.formatDropRate(d.LargestDrop)
+		}
+		fmt.Fprintf(w, "  %-10s  %s  %-13s  %s\n", d.Day, padCells(level, levelW), drop, units.formatRange(d.Min, d.Max))
+	}
+}
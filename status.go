@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// statusMinCacheTTL is the least time status reuses a cached forecast for,
+// even with a shorter cache_ttl, since status bars run it every few
+// seconds.
+const statusMinCacheTTL = 5 * time.Minute
+
+// statusFailureBackoff is how long status answers from the cache after a
+// failed request instead of asking the API again, so a status bar does not
+// retry every few seconds while the API is down.
+const statusFailureBackoff = 2 * time.Minute
+
+// statusFetchTimeout bounds a request of status, which a bar waits for.
+const statusFetchTimeout = 10 * time.Second
+
+// errStatusBackoff marks forecasts status read from the cache because a
+// recent request failed.
+var errStatusBackoff = errors.New("waiting after a failed request")
+
+// statusFailedFile returns the file whose modification time is the last
+// failed request of status for areaCode.
+func statusFailedFile(areaCode string) (string, error) {
+	path, err := cacheFile(areaCode)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(path, ".json") + ".failed", nil
+}
+
+// loadStatusWeather is loadWeather for status bars: after a failed request
+// it only reads the cache until statusFailureBackoff has passed.
+func loadStatusWeather(ctx context.Context, areaCode string) (weatherResult, error) {
+	failed, err := statusFailedFile(areaCode)
+	if err != nil {
+		return weatherResult{}, err
+	}
+	if fi, err := os.Stat(failed); err == nil && !offlineMode {
+		if retry := fi.ModTime().Add(statusFailureBackoff); time.Now().Before(retry) {
+			c, err := readCache(areaCode)
+			if err != nil {
+				return weatherResult{}, fmt.Errorf("the last request failed; trying again at %s", retry.Format("15:04:05"))
+			}
+			return weatherResult{c.Data, c.FetchedAt, errStatusBackoff, "", true, ""}, nil
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, statusFetchTimeout)
+	defer cancel()
+	w, err := loadWeather(ctx, areaCode, true)
+	switch {
+	case offlineMode:
+	case err != nil || w.stale != nil:
+		// Only the time matters, so a failure to record it is ignored
+		// like one to write the cache.
+		if f, err := os.Create(failed); err == nil {
+			f.Close()
+		}
+	default:
+		_ = os.Remove(failed)
+	}
+	return w, err
+}
+
+// statusTrendHours is how far ahead the trend arrow of status looks.
+const statusTrendHours = 3
+
+// statusTrendHPa is the change within statusTrendHours that makes the
+// pressure rising or falling rather than steady.
+const statusTrendHPa = 1.0
+
+// statusInfo is what a status bar shows about an area.
+type statusInfo struct {
+	status   checkStatus
+	summary  string // as printed by check
+	pressure float64
+	havePres bool
+	level    int // of the current hour, -1 if unknown
+	// trend is the pressure change over the next statusTrendHours.
+	trend   float64
+	risk    int
+	maxRisk int
+	stale   bool
+}
+
+// newStatusInfo rates the next hours of w like check.
+func newStatusInfo(w weatherResult, weights RiskWeights, now time.Time, hours int) statusInfo {
+	s := statusInfo{level: -1, stale: w.stale != nil}
+	s.status, s.summary = checkSummary(w.data, now, hours)
+	all, data := forecastHours(w.data, now)
+	deltas, deltaOK := pressureDeltas(nil, data)
+	risks := riskScores(weights, data, deltas, deltaOK)
+	current := now.Truncate(time.Hour)
+	for i, u := range all {
+		if !u.within(now, hours) {
+			continue
+		}
+		s.maxRisk = max(s.maxRisk, risks[i])
+		if !u.at.Equal(current) {
+			continue
+		}
+		s.risk = risks[i]
+		s.pressure, s.havePres = hourPressure(u)
+		if l, err := strconv.Atoi(strings.TrimSpace(u.entry.PressureLevel)); err == nil {
+			s.level = l
+		}
+	}
+	if s.havePres {
+		for _, u := range all {
+			if u.at.Equal(current.Add(statusTrendHours * time.Hour)) {
+				if p, ok := hourPressure(u); ok {
+					s.trend = p - s.pressure
+				}
+			}
+		}
+	}
+	return s
+}
+
+// arrow shows whether the pressure is rising, falling or steady.
+func (s statusInfo) arrow() string {
+	switch {
+	case s.trend <= -statusTrendHPa:
+		return "↓"
+	case s.trend >= statusTrendHPa:
+		return "↑"
+	}
+	return "→"
+}
+
+// shortPressure is the pressure without its decimals, which in inHg keep
+// one.
+func (s statusInfo) shortPressure() string {
+	return fmt.Sprintf("%.*f", units.pressureDecimals()-1, units.pressureValue(s.pressure))
+}
+
+// text is the short form, such as "↓1003 L3 ⚠".
+func (s statusInfo) text() string {
+	var parts []string
+	if s.havePres {
+		parts = append(parts, s.arrow()+s.shortPressure())
+	}
+	if s.level >= 0 {
+		parts = append(parts, fmt.Sprintf("L%d", s.level))
+	}
+	if s.status >= checkModerate {
+		parts = append(parts, "⚠")
+	}
+	if len(parts) == 0 {
+		return "?"
+	}
+	return strings.Join(parts, " ")
+}
+
+// segment is the shortest form, such as "↓1003 ⚠", for tmux.
+func (s statusInfo) segment() string {
+	text := "?"
+	if s.havePres {
+		text = s.arrow() + s.shortPressure()
+	}
+	if s.status >= checkModerate {
+		text += " ⚠"
+	}
+	return text
+}
+
+// tooltip is the long form: the check summary, the current hour and the
+// risk scores.
+func (s statusInfo) tooltip() string {
+	lines := []string{s.summary}
+	if s.havePres {
+		lines = append(lines, fmt.Sprintf("Now %s %s, %s %s in %dh", units.formatPressure(s.pressure), units.pressure, units.formatChange(s.trend), units.pressure, statusTrendHours))
+	}
+	lines = append(lines, fmt.Sprintf("Risk %d now, up to %d", s.risk, s.maxRisk))
+	if s.stale {
+		lines = append(lines, "Outdated forecast from the cache")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// statusFormat renders statusInfo for a status bar, and the error shown
+// when the forecast cannot be loaded.
+type statusFormat struct {
+	render func(s statusInfo) string
+	error  func(err error) string
+	// cacheOnly formats never wait for the API: they show the cache and
+	// refresh it in the background when it is too old.
+	cacheOnly bool
+}
+
+// statusRefreshEnv is set for the background process refreshing the cache
+// for a cacheOnly format.
+const statusRefreshEnv = "GOHEADACHE_STATUS_REFRESH"
+
+// cachedStatusWeather returns the cached forecast of areaCode for a
+// cacheOnly format, starting a refresh in the background when it is older
+// than cacheTTL or missing.
+func cachedStatusWeather(areaCode string) (weatherResult, error) {
+	c, err := readCache(areaCode)
+	if (err != nil || time.Since(c.FetchedAt) >= cacheTTL) && !offlineMode {
+		refreshStatusInBackground()
+	}
+	if err != nil {
+		return weatherResult{}, fmt.Errorf("no cached forecast for area code %s yet", areaCode)
+	}
+	var stale error
+	if time.Since(c.FetchedAt) >= cacheTTL {
+		stale = errStatusBackoff
+	}
+	return weatherResult{c.Data, c.FetchedAt, stale, "", true, ""}, nil
+}
+
+// refreshStatusInBackground runs goHeadache again with the same arguments
+// and statusRefreshEnv set, without waiting for it.
+func refreshStatusInBackground() {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), statusRefreshEnv+"=1")
+	if cmd.Start() == nil {
+		_ = cmd.Process.Release()
+	}
+}
+
+// promptColors are the terminal colors of the checkStatus values in shell
+// prompts.
+var promptColors = map[checkStatus]string{
+	checkOK:       "32",
+	checkModerate: "33",
+	checkSevere:   "31",
+	checkUnknown:  "90",
+}
+
+// promptColor colors text by status, unless NO_COLOR is set.
+func promptColor(status checkStatus, text string) string {
+	if os.Getenv("NO_COLOR") != "" {
+		return text
+	}
+	return "\x1b[" + promptColors[status] + "m" + text + "\x1b[0m"
+}
+
+// statusColors are the colors of the checkStatus values in status bars that
+// take one.
+var statusColors = map[checkStatus]string{
+	checkOK:       "#22C55E",
+	checkModerate: "#F97316",
+	checkSevere:   "#EF4444",
+	checkUnknown:  unknownLevelColor,
+}
+
+var statusFormats = map[string]statusFormat{
+	// text is one line, for polybar and anything else that shows stdout.
+	"text": {
+		render: func(s statusInfo) string { return s.text() },
+		error:  func(err error) string { return "?" },
+	},
+	// waybar is a custom module's JSON, with the status as its class.
+	"waybar": {
+		render: func(s statusInfo) string {
+			return waybarJSON(s.text(), s.tooltip(), s.status.String(), s.maxRisk)
+		},
+		error: func(err error) string {
+			return waybarJSON("?", err.Error(), checkUnknown.String(), 0)
+		},
+	},
+	// tmux is a status-line segment colored with #[fg=...] by the status.
+	"tmux": {
+		render: func(s statusInfo) string {
+			return fmt.Sprintf("#[fg=%s]%s#[default]", statusColors[s.status], s.segment())
+		},
+		error: func(err error) string {
+			return fmt.Sprintf("#[fg=%s]?#[default]", statusColors[checkUnknown])
+		},
+	},
+	// prompt is a token such as "↓3" for shell prompts: the trend arrow
+	// and the current level, colored by the status. It never waits for the
+	// API, and prints nothing before the first forecast is cached.
+	"prompt": {
+		render: func(s statusInfo) string {
+			level := "?"
+			if s.level >= 0 {
+				level = strconv.Itoa(s.level)
+			}
+			return promptColor(s.status, s.arrow()+level)
+		},
+		error:     func(err error) string { return "" },
+		cacheOnly: true,
+	},
+	// i3blocks takes the full text, the short text and the color on
+	// separate lines.
+	"i3blocks": {
+		render: func(s statusInfo) string {
+			return strings.Join([]string{s.text(), s.text(), statusColors[s.status]}, "\n")
+		},
+		error: func(err error) string {
+			return strings.Join([]string{"?", "?", statusColors[checkUnknown]}, "\n")
+		},
+	},
+}
+
+func waybarJSON(text, tooltip, class string, percentage int) string {
+	out, _ := json.Marshal(struct {
+		Text       string `json:"text"`
+		Tooltip    string `json:"tooltip"`
+		Class      string `json:"class"`
+		Percentage int    `json:"percentage"`
+	}{text, tooltip, class, percentage})
+	return string(out)
+}
+
+// statusFormatNames returns the names of statusFormats for the usage.
+func statusFormatNames() string {
+	names := make([]string, 0, len(statusFormats))
+	for name := range statusFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+type statusTickMsg struct{}
+
+// statusTickCmd redraws the status bar in a second, to count down to the
+// next watch-mode refresh.
+func statusTickCmd() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return statusTickMsg{}
+	})
+}
+
+// statusBar describes the data on screen at now: the area, where the
+// forecast came from and when, whether it is live, from the cache or from
+// -fixture, and in watch mode when it is refreshed next, such as
+// "13101 千代田区 · zutool · fetched 11:05 · cached 12m · next refresh in 4:32".
+func (m model) statusBar(now time.Time) string {
+	parts := []string{strings.TrimSpace(m.areaCode + " " + m.weatherData.PlaceName)}
+	if m.substitute != "" {
+		parts[0] = trf("%s %s, nearest to %s", m.substitute, m.weatherData.PlaceName, m.areaCode)
+	}
+	src := weatherSource.Name()
+	if m.fallback != "" {
+		src = m.fallback
+	}
+	parts = append(parts, src, trf("fetched %s", formatClock(m.lastUpdated)))
+	age := formatAge(now.Sub(m.lastUpdated))
+	switch {
+	case fixtureData != nil:
+		parts = append(parts, tr("fixture"))
+	case errors.Is(m.stale, errOffline):
+		parts = append(parts, trf("offline, cached %s", age))
+	case m.stale != nil && m.cached:
+		parts = append(parts, trf("stale, cached %s", age))
+	case m.cached:
+		parts = append(parts, trf("cached %s", age))
+	default:
+		parts = append(parts, tr("live"))
+	}
+	if m.watchInterval > 0 {
+		wait := max(m.nextRefresh.Sub(now).Round(time.Second), 0)
+		parts = append(parts, trf("next refresh in %s", fmt.Sprintf("%d:%02d", int(wait.Minutes()), int(wait.Seconds())%60)))
+	}
+	status := strings.Join(parts, " · ")
+	if m.refreshErr != nil {
+		status += trf(" · refresh failed: %s", trError(m.refreshErr))
+	}
+	if m.notifyErr != nil {
+		status += " · " + trError(m.notifyErr)
+	}
+	return status
+}
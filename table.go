@@ -0,0 +1,372 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/table"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+
+	"goHeadache/pkg/zutool"
+)
+
+// appFrameWidth is the horizontal space taken by appStyle's border and padding.
+const appFrameWidth = 6
+
+// tableRow holds the values of one hour shown in the table.
+type tableRow struct {
+	entry    zutool.HourlyData
+	hour     string
+	temp     string
+	pressure string
+	delta    float64
+	deltaOK  bool
+	// vsDay is the change from the same hour of the day before, and
+	// vsDayMost marks the hour that changed the most.
+	vsDay     float64
+	vsDayOK   bool
+	vsDayMost bool
+	risk      int
+	rain      string         // chance of precipitation, "-" when unknown and "" without -rain
+	style     lipgloss.Style // cellStyle, or selectedRowStyle for the selected row
+	current   bool           // the row of the current hour
+	iconSet   string
+}
+
+// column describes a table column. Widths include the cell padding.
+type column struct {
+	name         string // in hide_columns
+	title, short string // header, and its abbreviation for narrow columns
+	unit         string // appended to the title when it fits
+	minW, prefW  int
+	// drop orders the optional columns: the highest is removed first when
+	// the terminal is too narrow. Zero means the column is always shown.
+	drop int
+	// cell renders the column's cell for r, w cells wide.
+	cell func(r tableRow, w int) string
+}
+
+// tableColumns are all table columns in display order.
+var tableColumns = []column{
+	{name: "time", title: "Time", short: "Time", minW: 7, prefW: 9, cell: func(r tableRow, w int) string {
+		s := r.style
+		if r.current {
+			s = currentCellStyle
+		}
+		return renderCell(s, w, r.hour)
+	}},
+	{name: "weather", title: "Weather", short: "Wx", minW: 8, prefW: 16, drop: 4, cell: func(r tableRow, w int) string {
+		return renderCell(r.style, w, weatherLabel(r.entry.Weather, r.iconSet))
+	}},
+	{name: "temp", title: "Temp", short: "T", unit: "(°C)", minW: 6, prefW: 8, drop: 3, cell: func(r tableRow, w int) string {
+		return renderCell(r.style, w, r.temp)
+	}},
+	{name: "pressure", title: "Pressure", short: "hPa", unit: "(hPa)", minW: 8, prefW: 11, cell: func(r tableRow, w int) string {
+		return renderCell(r.style, w, r.pressure)
+	}},
+	{name: "change", title: "Change", short: "Δ", unit: "(hPa/h)", minW: 8, prefW: 10, drop: 2, cell: func(r tableRow, w int) string {
+		return renderCell(deltaStyle(r.style, r.delta, r.deltaOK), w, formatDelta(r.delta, r.deltaOK))
+	}},
+	{name: "level", title: "Level", short: "Lv", minW: 5, prefW: 16, cell: func(r tableRow, w int) string {
+		level := strings.TrimSpace(r.entry.PressureLevel)
+		text := level
+		// Show the descriptions only when every one of them fits, so the
+		// column does not mix both forms.
+		if levelNameWidth()+2 <= w-2 {
+			text += " " + levelName(level)
+		}
+		return renderCell(levelCellStyle(r.style, level), w, text)
+	}},
+	{name: "risk", title: "Risk", short: "Risk", unit: "(0-100)", minW: 7, prefW: 9, drop: 1, cell: func(r tableRow, w int) string {
+		return renderCell(r.style, w, riskBadge(r.risk))
+	}},
+	rainColumn,
+}
+
+// rainColumn shows the chance of precipitation. It is only part of the
+// layout when -rain is set, and is the first to go on narrow terminals.
+var rainColumn = column{name: "rain", title: "Rain", short: "Rain", unit: "(%)", minW: 6, prefW: 10, drop: 5, cell: func(r tableRow, w int) string {
+	return renderCell(r.style, w, r.rain)
+}}
+
+// compactColumns are the columns of the compact layout: Time, Pressure and
+// Level, leaving the spare room to the level descriptions.
+var compactColumns = func() []column {
+	cols := []column{tableColumns[0], tableColumns[3], tableColumns[5]}
+	cols[0].prefW = cols[0].minW
+	cols[1].prefW = cols[1].minW
+	cols[2].prefW = 20
+	return cols
+}()
+
+// compactWidth is the terminal width below which the compact layout is used
+// even without -compact.
+const compactWidth = 50
+
+// renderCell renders text in a cell w wide, truncating it instead of letting
+// it wrap onto a second line.
+func renderCell(s lipgloss.Style, w int, text string) string {
+	return s.Width(w).Render(ansi.Truncate(text, max(w-2, 1), "…"))
+}
+
+// padCells pads s with spaces to w terminal cells. Unlike fmt's %-*s, which
+// counts runes, it counts full-width characters such as Japanese as two.
+func padCells(s string, w int) string {
+	return s + strings.Repeat(" ", max(w-ansi.StringWidth(s), 0))
+}
+
+// layoutColumns picks the columns that fit in width and their widths.
+// Optional columns are dropped until the minimum widths fit, then the spare
+// room goes first to reaching the preferred widths and then to all columns
+// evenly. timeW overrides the minimum width of the Time column.
+func layoutColumns(cols []column, width, timeW int) ([]column, []int) {
+	cols = append([]column(nil), cols...)
+	cols[0].minW = max(cols[0].minW, timeW)
+	cols[0].prefW = max(cols[0].prefW, timeW)
+	minTotal := func() int {
+		total := 0
+		for _, c := range cols {
+			total += c.minW
+		}
+		return total
+	}
+	for minTotal() > width {
+		worst := -1
+		for i, c := range cols {
+			if c.drop > 0 && (worst < 0 || c.drop > cols[worst].drop) {
+				worst = i
+			}
+		}
+		if worst < 0 {
+			break
+		}
+		cols = append(cols[:worst], cols[worst+1:]...)
+	}
+
+	widths := make([]int, len(cols))
+	spare := width
+	for i, c := range cols {
+		widths[i] = c.minW
+		spare -= c.minW
+	}
+	for i, c := range cols {
+		grow := min(max(spare, 0), c.prefW-c.minW)
+		widths[i] += grow
+		spare -= grow
+	}
+	for i := range widths {
+		if spare <= 0 {
+			break
+		}
+		extra := spare / (len(widths) - i)
+		widths[i] += extra
+		spare -= extra
+	}
+	return cols, widths
+}
+
+// columnTitle returns the header of c for a column w cells wide: the title
+// with its unit, the title alone, or its abbreviation, whichever fits first.
+// Units are those of the display.
+func columnTitle(c column, w int) string {
+	title := tr(c.title)
+	if withUnit := title + " " + units.label(c.unit); c.unit != "" && lipgloss.Width(withUnit) <= w {
+		return withUnit
+	}
+	if lipgloss.Width(title) <= w {
+		return title
+	}
+	return tr(units.label(c.short))
+}
+
+// tableLayout returns the columns shown for the current view and their
+// widths including padding. labels are the timeline's weekday labels.
+func (m model) tableLayout(labels []string) ([]column, []int) {
+	timeW := hourWidth() + 2
+	if labels != nil {
+		timeW += lipgloss.Width(labels[0] + " ")
+	}
+	return layoutColumns(m.columns(), m.contentWidth(), timeW)
+}
+
+// tableContents returns the bubbles table columns and rows for the hours of
+// data shown, with the row at selected rendered as the selection. The cells are rendered
+// here rather than by the table so that the colored level, change and risk
+// cells keep their colors, and so the selection covers whole cells.
+func (m model) tableContents(data []zutool.HourlyData, labels []string, highlightRow, selected int) ([]table.Column, []table.Row) {
+	cols, widths := m.tableLayout(labels)
+	tcols := make([]table.Column, len(cols))
+	sorted, arrow := m.sortBy.column()
+	for i, c := range cols {
+		w := widths[i]
+		title := columnTitle(c, w-2)
+		if c.title == sorted {
+			title = columnTitle(c, w-4) + " " + arrow
+		}
+		tcols[i] = table.Column{Title: renderCell(tableHeaderStyle, w, title), Width: w}
+	}
+
+	all := m.tableRows(data, labels)
+	shown := m.shownRows(data)
+	rows := make([]table.Row, len(shown))
+	for k, i := range shown {
+		r := all[i]
+		r.current = i == highlightRow
+		if k == selected {
+			r.style = selectedRowStyle
+			r.current = false
+		}
+		row := make(table.Row, len(cols))
+		for j, c := range cols {
+			row[j] = c.cell(r, tcols[j].Width)
+		}
+		rows[k] = row
+	}
+	return tcols, rows
+}
+
+// tableRows returns the values of every hour of data, styled as unselected
+// rows. labels are the timeline's weekday labels.
+func (m model) tableRows(data []zutool.HourlyData, labels []string) []tableRow {
+	deltas, deltaOK := pressureDeltas(m.pressureBefore(), data)
+	risks := riskScores(m.riskWeights, data, deltas, deltaOK)
+	vsDay, vsDayOK, most := m.dayOverDayChanges(data)
+	days := m.viewDays(len(data))
+	rows := make([]tableRow, len(data))
+	for i, entry := range data {
+		hour, _, temp, pressure := displayHourlyData(entry)
+		rain := ""
+		if m.rain != nil {
+			rain = "-"
+			if h, err := strconv.Atoi(strings.TrimSpace(entry.Time)); err == nil {
+				if v, ok := m.rain[days[i]][h]; ok {
+					rain = fmt.Sprintf("%d%%", v)
+				}
+			}
+		}
+		if labels != nil {
+			hour = labels[i] + " " + hour
+		}
+		rows[i] = tableRow{
+			entry:     entry,
+			hour:      hour,
+			temp:      temp,
+			pressure:  pressure,
+			delta:     deltas[i],
+			deltaOK:   deltaOK[i],
+			vsDay:     vsDay[i],
+			vsDayOK:   vsDayOK[i],
+			vsDayMost: i == most,
+			risk:      risks[i],
+			rain:      rain,
+			style:     cellStyle,
+			iconSet:   m.iconSet,
+		}
+	}
+	return rows
+}
+
+// newTable returns the hourly table. Left and right change the day, so only
+// vertical movement is bound.
+func newTable() table.Model {
+	km := table.DefaultKeyMap()
+	km.LineUp = key.NewBinding(key.WithKeys("up", "k"))
+	km.LineDown = key.NewBinding(key.WithKeys("down", "j"))
+	km.PageUp = key.NewBinding(key.WithKeys("pgup"))
+	km.PageDown = key.NewBinding(key.WithKeys("pgdown"))
+	km.HalfPageUp = key.NewBinding(key.WithKeys("ctrl+u"))
+	km.HalfPageDown = key.NewBinding(key.WithKeys("ctrl+d"))
+	km.GotoTop = key.NewBinding(key.WithKeys("home"))
+	km.GotoBottom = key.NewBinding(key.WithKeys("end"))
+	// tableContents renders and styles every cell itself.
+	plain := lipgloss.NewStyle()
+	return table.New(table.WithFocused(true), table.WithKeyMap(km),
+		table.WithStyles(table.Styles{Header: plain, Cell: plain, Selected: plain}))
+}
+
+// showsTable reports whether the current view is the hourly table, as
+// opposed to the graph, the history or an error.
+func (m model) showsTable() bool {
+	if m.graphMode || m.historyMode || m.loading || m.err != nil {
+		return false
+	}
+	if _, err := dayIndices(m.dayFilter); err != nil {
+		return false
+	}
+	_, data, _, _ := m.currentView()
+	return len(data) > 0
+}
+
+// syncTable loads the rows of the current view into the table. When only
+// the values changed the rows are replaced in place; when the layout or the
+// number of rows changed the table is rebuilt and the selected row restored.
+func (m *model) syncTable(headerLines int) {
+	_, data, labels, highlightRow := m.currentView()
+	cols, rows := m.tableContents(data, labels, highlightRow, m.table.Cursor())
+	// One more line for the table's header row.
+	height := contentHeight(*m, headerLines, len(rows)+1)
+	layout := fmt.Sprint(cols, height, m.contentWidth(), len(rows))
+	if layout == m.tableLayoutKey {
+		m.table.SetRows(rows)
+		return
+	}
+	m.tableLayoutKey = layout
+
+	cursor := m.table.Cursor()
+	// Clear the rows first: the table renders them against the new columns
+	// as soon as they are set, and the column count may have changed.
+	m.table.SetRows(nil)
+	m.table.SetColumns(cols)
+	m.table.SetWidth(m.contentWidth())
+	m.table.SetHeight(height)
+	m.table.SetRows(rows)
+	m.selectRow(cursor)
+}
+
+// selectRow moves the table selection to row n and scrolls it into view.
+// The table keeps its scroll offset across SetCursor, so the selection is
+// moved down from the top instead, which always leaves it visible.
+func (m *model) selectRow(n int) {
+	rows := m.table.Rows()
+	m.table.SetRows(nil)
+	m.table.SetRows(rows)
+	m.table.SetCursor(0)
+	if n > 0 {
+		m.table.MoveDown(n)
+	}
+}
+
+// isCompact reports whether the compact layout is in use.
+func (m model) isCompact() bool {
+	return m.compact || m.width < compactWidth
+}
+
+// columns returns the columns shown by the table layout in use, less those
+// hidden.
+func (m model) columns() []column {
+	cols := tableColumns
+	switch {
+	case m.isCompact():
+		cols = compactColumns
+	case m.rain == nil:
+		cols = tableColumns[:len(tableColumns)-1]
+	}
+	shown := make([]column, 0, len(cols)+1)
+	for _, c := range cols {
+		if !m.hiddenColumns[c.name] {
+			shown = append(shown, c)
+		}
+	}
+	if m.dayOverDay {
+		shown = append(shown, dayOverDayColumn)
+	}
+	return shown
+}
+
+// contentWidth returns the width available inside the app frame.
+func (m model) contentWidth() int {
+	return max(m.width-appFrameWidth, 1)
+}
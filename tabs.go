@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+
+	"charm.land/lipgloss/v2"
+)
+
+// dayTabs are the labels of the day tabs, by day index, with abbreviations
+// for narrow terminals.
+var dayTabs = []struct{ name, short string }{
+	{"Yesterday", "Yest"},
+	{"Today", "Today"},
+	{"Tomorrow", "Tmrw"},
+	{"Day After", "After"},
+}
+
+// tabSeparator is drawn between two tabs.
+const tabSeparator = "│"
+
+// showsTabs reports whether the day tab bar is shown. It is hidden when
+// -day pins the view to a single day, and in the history view.
+func (m model) showsTabs() bool {
+	return m.dayFilter == "" && !m.historyMode
+}
+
+// tabActive reports whether the tab of day is highlighted: the selected
+// day, or every day of the timeline when it is enabled.
+func (m model) tabActive(day int) bool {
+	if m.timelineDays > 0 {
+		return day >= 1 && day <= m.timelineDays
+	}
+	return day == m.currentDay
+}
+
+// tabLabels returns the tab labels, abbreviated when the full ones do not fit.
+func (m model) tabLabels() []string {
+	full := make([]string, len(dayTabs))
+	short := make([]string, len(dayTabs))
+	for i, t := range dayTabs {
+		full[i], short[i] = tr(t.name), tr(t.short)
+	}
+	// Each tab has one cell of padding on either side.
+	width := len(dayTabs)*3 - 1
+	for _, l := range full {
+		width += lipgloss.Width(l)
+	}
+	if width <= m.contentWidth() {
+		return full
+	}
+	return short
+}
+
+// tabBar renders the day tabs centered in the content width. offsets holds
+// the column each tab starts at, followed by where a further tab would.
+func (m model) tabBar() (bar string, offsets []int) {
+	labels := m.tabLabels()
+	tabs := make([]string, len(labels))
+	barWidth := 0
+	for i, l := range labels {
+		s := tabStyle
+		if m.tabActive(i) {
+			s = activeTabStyle
+		}
+		tabs[i] = s.Render(l)
+		barWidth += lipgloss.Width(tabs[i])
+	}
+	barWidth += len(tabs) - 1
+
+	x := max((m.contentWidth()-barWidth)/2, 0)
+	for _, t := range tabs {
+		offsets = append(offsets, x)
+		x += lipgloss.Width(t) + lipgloss.Width(tabSeparator)
+	}
+	offsets = append(offsets, x)
+
+	sep := tabStyle.Padding(0).Render(tabSeparator)
+	return strings.Repeat(" ", offsets[0]) + strings.Join(tabs, sep), offsets
+}
+
+// tabAt returns the day of the tab at column x of the content, or -1 when
+// there is none.
+func (m model) tabAt(x int) int {
+	if !m.showsTabs() {
+		return -1
+	}
+	_, offsets := m.tabBar()
+	for day := range len(offsets) - 1 {
+		if x >= offsets[day] && x < offsets[day+1]-1 {
+			return day
+		}
+	}
+	return -1
+}
+
+// selectDay shows day on its own, leaving the timeline if it was enabled.
+func (m *model) selectDay(day int) {
+	if day == m.currentDay && m.timelineDays == 0 {
+		return
+	}
+	m.currentDay = day
+	m.timelineDays = 0
+	m.gotoTop()
+}
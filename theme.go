@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/term"
+)
+
+// Theme is the color palette of the TUI. Colors are hex strings such as
+// "#1E293B" or ANSI color numbers such as "12".
+type Theme struct {
+	// Base names the preset a config file theme inherits unset colors from.
+	// It is ignored for presets.
+	Base string `toml:"base,omitempty"`
+
+	Border      string `toml:"border,omitempty"`
+	Title       string `toml:"title,omitempty"`
+	TitleBg     string `toml:"title_bg,omitempty"`
+	Header      string `toml:"header,omitempty"`
+	HeaderBg    string `toml:"header_bg,omitempty"`
+	Text        string `toml:"text,omitempty"`
+	Muted       string `toml:"muted,omitempty"`
+	Loading     string `toml:"loading,omitempty"`
+	Error       string `toml:"error,omitempty"`
+	ErrorBorder string `toml:"error_border,omitempty"`
+	Highlight   string `toml:"highlight,omitempty"`
+	HighlightBg string `toml:"highlight_bg,omitempty"`
+	// BadgeText is the text color on level and risk badges.
+	BadgeText string `toml:"badge_text,omitempty"`
+	// Levels are the colors of pressure levels 0 to 4.
+	Levels []string `toml:"levels,omitempty"`
+	// Risk are the colors of risk scores below 25, 50, 75 and from 75 up.
+	Risk       []string `toml:"risk,omitempty"`
+	DropWarn   string   `toml:"drop_warn,omitempty"`
+	DropSevere string   `toml:"drop_severe,omitempty"`
+}
+
+// themes are the built-in presets.
+var themes = map[string]Theme{
+	"light": {
+		Border:      "#0EA5E9",
+		Title:       "#1E3A5F",
+		TitleBg:     "#93C5FD",
+		Header:      "#0C2A4A",
+		HeaderBg:    "#60A5FA",
+		Text:        "#1E293B",
+		Muted:       "#475569",
+		Loading:     "#0369A1",
+		Error:       "#991B1B",
+		ErrorBorder: "#EF4444",
+		Highlight:   "#1E293B",
+		HighlightBg: "#FEF08A",
+		BadgeText:   "#FFFFFF",
+		Levels:      []string{"#16A34A", "#65A30D", "#CA8A04", "#EA580C", "#DC2626"},
+		Risk:        []string{"#16A34A", "#CA8A04", "#EA580C", "#DC2626"},
+		DropWarn:    "#EA580C",
+		DropSevere:  "#DC2626",
+	},
+	"dark": {
+		Border:      "#38BDF8",
+		Title:       "#0F172A",
+		TitleBg:     "#7DD3FC",
+		Header:      "#0F172A",
+		HeaderBg:    "#3B82F6",
+		Text:        "#E2E8F0",
+		Muted:       "#94A3B8",
+		Loading:     "#7DD3FC",
+		Error:       "#FCA5A5",
+		ErrorBorder: "#EF4444",
+		Highlight:   "#0F172A",
+		HighlightBg: "#FDE047",
+		BadgeText:   "#FFFFFF",
+		Levels:      []string{"#22C55E", "#84CC16", "#EAB308", "#F97316", "#EF4444"},
+		Risk:        []string{"#16A34A", "#CA8A04", "#EA580C", "#DC2626"},
+		DropWarn:    "#FB923C",
+		DropSevere:  "#F87171",
+	},
+	"high-contrast": {
+		Border:      "15",
+		Title:       "0",
+		TitleBg:     "15",
+		Header:      "0",
+		HeaderBg:    "14",
+		Text:        "15",
+		Muted:       "15",
+		Loading:     "15",
+		Error:       "9",
+		ErrorBorder: "9",
+		Highlight:   "0",
+		HighlightBg: "11",
+		BadgeText:   "0",
+		Levels:      []string{"10", "10", "11", "208", "9"},
+		Risk:        []string{"10", "11", "208", "9"},
+		DropWarn:    "11",
+		DropSevere:  "9",
+	},
+}
+
+// theme is the palette in use. applyTheme changes it.
+var theme Theme
+
+func init() {
+	applyTheme(themes["dark"])
+}
+
+// themeNames returns the preset names followed by the config file's themes.
+func themeNames(custom map[string]Theme) []string {
+	names := make([]string, 0, len(themes)+len(custom))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var extra []string
+	for name := range custom {
+		if _, ok := themes[name]; !ok {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	return append(names, extra...)
+}
+
+// resolveTheme returns the theme called name, looking in the config file's
+// themes before the presets. An empty name or "auto" picks light or dark
+// from the terminal background.
+func resolveTheme(name string, custom map[string]Theme) (Theme, error) {
+	if name == "" || name == "auto" {
+		if term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd()) && !lipgloss.HasDarkBackground(os.Stdin, os.Stdout) {
+			return themes["light"], nil
+		}
+		return themes["dark"], nil
+	}
+	if t, ok := custom[name]; ok {
+		baseName := t.Base
+		if baseName == "" {
+			baseName = "dark"
+		}
+		base, ok := themes[baseName]
+		if !ok {
+			return Theme{}, fmt.Errorf("theme %q: unknown base theme %q (use %s)", name, baseName, strings.Join(themeNames(nil), ", "))
+		}
+		return t.inherit(base), nil
+	}
+	if t, ok := themes[name]; ok {
+		return t, nil
+	}
+	return Theme{}, fmt.Errorf("unknown theme %q (use auto, %s)", name, strings.Join(themeNames(custom), ", "))
+}
+
+// inherit fills the colors t leaves unset from base.
+func (t Theme) inherit(base Theme) Theme {
+	v, b := reflect.ValueOf(&t).Elem(), reflect.ValueOf(base)
+	for i := range v.NumField() {
+		if v.Field(i).IsZero() {
+			v.Field(i).Set(b.Field(i))
+		}
+	}
+	if len(t.Levels) != len(base.Levels) {
+		t.Levels = base.Levels
+	}
+	if len(t.Risk) != len(base.Risk) {
+		t.Risk = base.Risk
+	}
+	return t
+}
+
+// applyTheme makes t the current palette and rebuilds the styles from it.
+func applyTheme(t Theme) {
+	theme = t
+
+	appStyle = lipgloss.NewStyle().
+		Padding(0, 1).
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(lipgloss.Color(t.Border))
+
+	dayHeaderStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(t.Title)).
+		Background(lipgloss.Color(t.TitleBg)).
+		PaddingLeft(2).
+		PaddingRight(2).
+		MarginTop(1).
+		MarginBottom(0).
+		Align(lipgloss.Center)
+
+	tableHeaderStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(t.Header)).
+		Background(lipgloss.Color(t.HeaderBg)).
+		PaddingLeft(1).
+		PaddingRight(1).
+		Align(lipgloss.Center)
+
+	cellStyle = lipgloss.NewStyle().
+		PaddingLeft(1).
+		PaddingRight(1).
+		Align(lipgloss.Center).
+		Foreground(lipgloss.Color(t.Text))
+
+	errorStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color(t.Error)).
+		Bold(true).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(t.ErrorBorder))
+
+	loadingStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color(t.Loading)).
+		Bold(true).
+		Padding(2).
+		Align(lipgloss.Center)
+
+	currentCellStyle = lipgloss.NewStyle().
+		PaddingLeft(1).
+		PaddingRight(1).
+		Align(lipgloss.Center).
+		Background(lipgloss.Color(t.HighlightBg)).
+		Foreground(lipgloss.Color(t.Highlight)).
+		Bold(true)
+
+	selectedRowStyle = lipgloss.NewStyle().
+		PaddingLeft(1).
+		PaddingRight(1).
+		Align(lipgloss.Center).
+		Background(lipgloss.Color(t.HighlightBg)).
+		Foreground(lipgloss.Color(t.Highlight)).
+		Bold(true)
+
+	popupStyle = lipgloss.NewStyle().
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(t.Border)).
+		Foreground(lipgloss.Color(t.Text))
+
+	bannerStyle = lipgloss.NewStyle().
+		Bold(true).
+		Padding(0, 1).
+		Align(lipgloss.Center).
+		Foreground(lipgloss.Color(t.BadgeText)).
+		Background(lipgloss.Color(t.DropWarn))
+
+	nowStyle = lipgloss.NewStyle().
+		Bold(true).
+		Align(lipgloss.Center).
+		Foreground(lipgloss.Color(t.Text))
+
+	tabStyle = lipgloss.NewStyle().
+		Padding(0, 1).
+		Foreground(lipgloss.Color(t.Muted))
+
+	activeTabStyle = lipgloss.NewStyle().
+		Padding(0, 1).
+		Bold(true).
+		Foreground(lipgloss.Color(t.Title)).
+		Background(lipgloss.Color(t.TitleBg))
+
+	sparklineStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color(t.Text)).
+		Align(lipgloss.Center)
+
+	statusStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color(t.Muted)).
+		Italic(true).
+		Align(lipgloss.Right)
+
+	footerStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color(t.Muted)).
+		Padding(0, 0).
+		MarginTop(1).
+		Border(lipgloss.NormalBorder(), true, false, false, false).
+		BorderForeground(lipgloss.Color(t.Muted)).
+		Align(lipgloss.Center)
+}
+
+// setupTheme resolves the theme selected by flagValue, or by the config file
+// when flagValue is empty, and applies it.
+func setupTheme(cfg Config, flagValue string) error {
+	name := cfg.Theme
+	if flagValue != "" {
+		name = flagValue
+	}
+	t, err := resolveTheme(name, cfg.Themes)
+	if err != nil {
+		return err
+	}
+	applyTheme(t)
+	return nil
+}
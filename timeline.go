@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+
+	"goHeadache/pkg/zutool"
+)
+
+// timelineData stitches Today and the following days into one continuous
+// list so overnight changes are not split across screens. labels holds the
+// weekday of each entry.
+func (m model) timelineData() (name string, data []zutool.HourlyData, labels []string) {
+	var names []string
+	for day := 1; day <= m.timelineDays; day++ {
+		dayName, dayData := m.getDayData(day)
+		weekday := tr(clockNow().AddDate(0, 0, day-1).Format("Mon"))
+		for range dayData {
+			labels = append(labels, weekday)
+		}
+		data = append(data, dayData...)
+		names = append(names, tr(dayName))
+	}
+	return strings.Join(names, " + "), data, labels
+}
+
+// viewDays returns the day of each of the n rows of the current view.
+func (m model) viewDays(n int) []int {
+	days := make([]int, 0, n)
+	if m.timelineDays == 0 {
+		for range n {
+			days = append(days, m.currentDay)
+		}
+		return days
+	}
+	for day := 1; day <= m.timelineDays; day++ {
+		_, dayData := m.getDayData(day)
+		for range dayData {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// currentView returns what the forecast screen shows: the selected day, or
+// the combined timeline when it is enabled. labels is nil for a single day;
+// highlight is the row of the current hour or -1.
+func (m model) currentView() (name string, data []zutool.HourlyData, labels []string, highlight int) {
+	highlight = -1
+	if m.timelineDays > 0 {
+		name, data, labels = m.timelineData()
+		if len(m.weatherData.Today) > 0 {
+			highlight = findCurrentRowIndex(m.weatherData.Today)
+		}
+		return name, data, labels, highlight
+	}
+	name, data = m.getDayData(m.currentDay)
+	if m.currentDay == 1 {
+		highlight = findCurrentRowIndex(data)
+	}
+	return name, data, nil, highlight
+}
+
+// cycleTimeline switches between the single-day view, the 48-hour timeline
+// (Today + Tomorrow) and the 72-hour timeline that adds the day after.
+func (m model) cycleTimeline() model {
+	switch m.timelineDays {
+	case 0:
+		m.timelineDays = 2
+	case 2:
+		m.timelineDays = 3
+	default:
+		m.timelineDays = 0
+	}
+	m.gotoTop()
+	if _, data, _, highlight := m.currentView(); highlight > 0 {
+		m.syncContent()
+		m.selectRow(m.rowOf(data, highlight))
+	}
+	return m
+}
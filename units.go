@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// displayUnits are the units temperatures and pressures are shown in.
+// Forecasts are parsed, thresholds configured and machine-readable output
+// written in °C and hPa; only what is shown to people converts.
+type displayUnits struct {
+	temp     string // C or F
+	pressure string // hPa, inHg or mmHg
+}
+
+// units are the display units selected by setupUnits.
+var units = displayUnits{temp: "C", pressure: "hPa"}
+
+// parseUnits applies a comma-separated list of units, such as "F,inHg", to
+// u. metric and imperial set both.
+func parseUnits(u displayUnits, s string) (displayUnits, error) {
+	for _, part := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "":
+		case "metric":
+			u = displayUnits{temp: "C", pressure: "hPa"}
+		case "imperial":
+			u = displayUnits{temp: "F", pressure: "inHg"}
+		case "c", "celsius":
+			u.temp = "C"
+		case "f", "fahrenheit":
+			u.temp = "F"
+		case "hpa", "mbar":
+			u.pressure = "hPa"
+		case "inhg":
+			u.pressure = "inHg"
+		case "mmhg":
+			u.pressure = "mmHg"
+		default:
+			return u, fmt.Errorf("unknown unit %q (use C, F, hPa, inHg, mmHg, metric or imperial)", strings.TrimSpace(part))
+		}
+	}
+	return u, nil
+}
+
+// setupUnits selects the units of the config file, overridden by
+// flagValue, for everything shown afterwards.
+func setupUnits(cfg Config, flagValue string) error {
+	u, err := parseUnits(displayUnits{temp: "C", pressure: "hPa"}, cfg.Units)
+	if err != nil {
+		return fmt.Errorf("invalid units in the config file: %v", err)
+	}
+	if u, err = parseUnits(u, flagValue); err != nil {
+		return err
+	}
+	units = u
+	return nil
+}
+
+// tempSymbol is the symbol of the temperature unit, such as "°F".
+func (u displayUnits) tempSymbol() string {
+	return "°" + u.temp
+}
+
+// tempValue converts a temperature in °C.
+func (u displayUnits) tempValue(celsius float64) float64 {
+	if u.temp == "F" {
+		return celsius*9/5 + 32
+	}
+	return celsius
+}
+
+// formatTemp formats a temperature in °C as a number in the display unit.
+func (u displayUnits) formatTemp(celsius float64) string {
+	return fmt.Sprintf("%.1f", u.tempValue(celsius))
+}
+
+// pressureValue converts a pressure, or a change of it, in hPa.
+func (u displayUnits) pressureValue(hpa float64) float64 {
+	switch u.pressure {
+	case "inHg":
+		return hpa * 0.0295300
+	case "mmHg":
+		return hpa * 0.750062
+	}
+	return hpa
+}
+
+// pressureDecimals is how many decimals show a pressure as precisely as a
+// tenth of a hPa.
+func (u displayUnits) pressureDecimals() int {
+	if u.pressure == "inHg" {
+		return 2
+	}
+	return 1
+}
+
+// formatPressure formats a pressure in hPa as a number in the display unit.
+func (u displayUnits) formatPressure(hpa float64) string {
+	return fmt.Sprintf("%.*f", u.pressureDecimals(), u.pressureValue(hpa))
+}
+
+// changeDecimals is how many decimals show a change of the pressure: one
+// more than pressures in inHg, as changes are small.
+func (u displayUnits) changeDecimals() int {
+	if u.pressure == "inHg" {
+		return 3
+	}
+	return 1
+}
+
+// formatChange formats a change of the pressure in hPa with its sign, such
+// as "-1.2" or "+0.0".
+func (u displayUnits) formatChange(hpa float64) string {
+	s := fmt.Sprintf("%+.*f", u.changeDecimals(), u.pressureValue(hpa))
+	if strings.TrimLeft(s, "-+0.") == "" {
+		return "+" + s[1:]
+	}
+	return s
+}
+
+// formatFall formats how far the pressure fell, in hPa, such as
+// "1.2 hPa".
+func (u displayUnits) formatFall(hpa float64) string {
+	return fmt.Sprintf("%.*f %s", u.changeDecimals(), u.pressureValue(hpa), u.pressure)
+}
+
+// formatDropRate formats a fall of the pressure within an hour, in hPa,
+// such as "-1.2 hPa/h".
+func (u displayUnits) formatDropRate(hpa float64) string {
+	return fmt.Sprintf("-%.*f %s/h", u.changeDecimals(), u.pressureValue(hpa), u.pressure)
+}
+
+// formatRange formats a range of the pressure in hPa, such as
+// "1003.2–1011.8 hPa".
+func (u displayUnits) formatRange(lo, hi float64) string {
+	return u.formatPressure(lo) + "–" + u.formatPressure(hi) + " " + u.pressure
+}
+
+// label replaces the °C and hPa in s, a unit or a text about the forecast,
+// with the display units.
+func (u displayUnits) label(s string) string {
+	return strings.NewReplacer("°C", u.tempSymbol(), "hPa", u.pressure).Replace(s)
+}
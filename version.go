@@ -0,0 +1,89 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// version, commit and buildDate are set at build time with -ldflags, as in
+// -ldflags "-X main.version=v1.2.3 -X main.commit=abc1234 -X main.buildDate=2026-10-14T11:00:00Z".
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// appVersion returns version, or the module version when the binary was
+// built with go install and no version was set.
+func appVersion() string {
+	if version != "dev" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return version
+}
+
+// build describes the binary: its version, the commit it was built from
+// and the time of that commit, modified when the tree had uncommitted
+// changes, and the Go version. The commit and its time come from -ldflags,
+// else from what go build records of the repository.
+type build struct {
+	version, commit, date string
+	modified              bool
+	goVersion             string
+}
+
+// buildInfo returns the build of the running binary.
+func buildInfo() build {
+	b := build{version: appVersion(), commit: commit, date: buildDate, goVersion: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if b.commit == "" {
+				b.commit = s.Value
+			}
+		case "vcs.time":
+			if b.date == "" {
+				b.date = s.Value
+			}
+		case "vcs.modified":
+			b.modified = s.Value == "true" && commit == ""
+		}
+	}
+	return b
+}
+
+// shortCommit returns the first 12 characters of the commit, "unknown" if
+// it is not known.
+func (b build) shortCommit() string {
+	switch {
+	case b.commit == "":
+		return "unknown"
+	case len(b.commit) > 12:
+		return b.commit[:12]
+	}
+	return b.commit
+}
+
+// commitLabel returns shortCommit, marked as modified when the tree had
+// uncommitted changes.
+func (b build) commitLabel() string {
+	if b.modified {
+		return b.shortCommit() + " (modified)"
+	}
+	return b.shortCommit()
+}
+
+// builtAt returns the build date, "unknown" if it is not known.
+func (b build) builtAt() string {
+	if b.date == "" {
+		return "unknown"
+	}
+	return b.date
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// WebhookConfig is a [[webhooks]] entry of the config file: a URL that
+// alerts are posted to as JSON.
+type WebhookConfig struct {
+	// Name identifies the webhook in log messages.
+	Name string `toml:"name"`
+	URL  string `toml:"url"`
+	// Format is the shape of the payload: slack, discord or json.
+	Format string `toml:"format"`
+	// Template is the text/template of the message, see alertTemplateData.
+	// It defaults to defaultWebhookTemplate.
+	Template string `toml:"template"`
+	// DigestTemplate is the template of digests, which sum up several
+	// alerts in Message. It defaults to defaultDigestTemplate.
+	DigestTemplate string `toml:"digest_template"`
+}
+
+const (
+	defaultWebhookTemplate = "{{.Title}}: {{.Message}}"
+	defaultDigestTemplate  = "{{.Title}}\n{{.Message}}"
+)
+
+const (
+	// webhookAttempts is how often a post is tried before giving up.
+	webhookAttempts = 3
+	// webhookBackoff is the wait before the first retry, doubled for each
+	// one after it.
+	webhookBackoff = 2 * time.Second
+)
+
+// alertTemplateData is what webhook templates can use, as in
+// "Pressure dropping {{printf "%.1f" .Drop}} hPa at {{.Time}} in {{.Place}}".
+type alertTemplateData struct {
+	Place    string
+	AreaCode string
+	// Rule and Severity are those of the rule that raised the alert.
+	Rule     string
+	Severity string
+	Time     string // "15:04"
+	Date     string // "2006-01-02"
+	// Until is the end of a span such as a drop over several hours, else
+	// empty.
+	Until string
+	Level int
+	// LevelName is the name of Level, such as "Warning".
+	LevelName string
+	// Drop is the hPa lost within the hour, or by Until, zero if none.
+	Drop     float64
+	Pressure string
+	// Title and Message are the text of the desktop notification.
+	Title   string
+	Message string
+}
+
+func newAlertTemplateData(a alert) alertTemplateData {
+	title, body := a.message()
+	var until string
+	if !a.warning.until.IsZero() {
+		until = a.warning.until.Format("15:04")
+	}
+	return alertTemplateData{
+		Place:     a.placeName,
+		AreaCode:  a.areaCode,
+		Rule:      a.rule,
+		Severity:  a.severity,
+		Time:      a.warning.at.Format("15:04"),
+		Date:      a.warning.at.Format("2006-01-02"),
+		Until:     until,
+		Level:     a.warning.level,
+		LevelName: levelName(fmt.Sprint(a.warning.level)),
+		Drop:      a.warning.drop,
+		Pressure:  a.warning.pressure,
+		Title:     title,
+		Message:   body,
+	}
+}
+
+// webhookNotifier posts alerts to a webhook.
+type webhookNotifier struct {
+	cfg        WebhookConfig
+	tmpl       *template.Template
+	digestTmpl *template.Template
+	client     *http.Client
+}
+
+// newWebhookNotifier checks cfg and returns its notifier, which posts with
+// client.
+func newWebhookNotifier(cfg WebhookConfig, client *http.Client) (*webhookNotifier, error) {
+	if cfg.Name == "" {
+		cfg.Name = "webhook"
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("webhook %s: url must be an http or https URL", cfg.Name)
+	}
+	switch cfg.Format {
+	case "":
+		cfg.Format = "json"
+	case "json", "slack", "discord":
+	default:
+		return nil, fmt.Errorf("webhook %s: unknown format %q (use slack, discord or json)", cfg.Name, cfg.Format)
+	}
+	tmpl, err := parseWebhookTemplate(cfg.Name, cfg.Template, defaultWebhookTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: invalid template: %v", cfg.Name, err)
+	}
+	digestTmpl, err := parseWebhookTemplate(cfg.Name, cfg.DigestTemplate, defaultDigestTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: invalid digest_template: %v", cfg.Name, err)
+	}
+	return &webhookNotifier{cfg: cfg, tmpl: tmpl, digestTmpl: digestTmpl, client: client}, nil
+}
+
+func parseWebhookTemplate(name, text, fallback string) (*template.Template, error) {
+	if text == "" {
+		text = fallback
+	}
+	return template.New(name).Option("missingkey=error").Parse(text)
+}
+
+func (n *webhookNotifier) name() string { return "webhook " + n.cfg.Name }
+
+// payload returns the JSON body posted for a.
+func (n *webhookNotifier) payload(a alert) ([]byte, error) {
+	data := newAlertTemplateData(a)
+	tmpl := n.tmpl
+	if a.isDigest() {
+		tmpl = n.digestTmpl
+	}
+	var text strings.Builder
+	if err := tmpl.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("error running template: %v", err)
+	}
+	switch n.cfg.Format {
+	case "slack":
+		return json.Marshal(map[string]string{"text": text.String()})
+	case "discord":
+		return json.Marshal(map[string]string{"content": text.String()})
+	}
+	return alertJSON(a, text.String())
+}
+
+// alertJSON is the body of json webhooks and the stdin of hooks: every field
+// of a, plus text when it is not empty.
+func alertJSON(a alert, text string) ([]byte, error) {
+	data := newAlertTemplateData(a)
+	return json.Marshal(struct {
+		Text      string  `json:"text,omitempty"`
+		Title     string  `json:"title"`
+		Message   string  `json:"message"`
+		AreaCode  string  `json:"area_code"`
+		Place     string  `json:"place"`
+		Rule      string  `json:"rule"`
+		Severity  string  `json:"severity"`
+		Time      string  `json:"time"`
+		Until     string  `json:"until,omitempty"`
+		Level     int     `json:"level"`
+		LevelName string  `json:"level_name"`
+		Drop      float64 `json:"drop"`
+		Pressure  string  `json:"pressure"`
+	}{text, data.Title, data.Message, a.areaCode, a.placeName, a.rule, a.severity, a.warning.at.Format(time.RFC3339),
+		formatTime(a.warning.until), data.Level, data.LevelName, data.Drop, data.Pressure})
+}
+
+// formatTime formats t as RFC 3339, or as "" when it is zero.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (n *webhookNotifier) preview(a alert) string {
+	body, err := n.payload(a)
+	if err != nil {
+		return err.Error()
+	}
+	return "POST " + redactURL(n.cfg.URL) + " " + string(body)
+}
+
+// send posts a, retrying network errors, rate limits and server errors.
+func (n *webhookNotifier) send(ctx context.Context, a alert) error {
+	body, err := n.payload(a)
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(ctx, body)
+		if err == nil || !retry || attempt+1 >= webhookAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(webhookBackoff << attempt):
+		}
+	}
+}
+
+// post makes a single request. retry reports whether the failure is worth
+// retrying.
+func (n *webhookNotifier) post(ctx context.Context, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", apiClient.UserAgent)
+	client := n.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Webhook URLs are secrets; keep them out of the message.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retry = resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return false, nil
+}
+
+// redactURL shortens a webhook URL to its scheme and host for display,
+// since the path usually holds the token.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "(invalid URL)"
+	}
+	if u.Path == "" || u.Path == "/" {
+		return u.Scheme + "://" + u.Host
+	}
+	return u.Scheme + "://" + u.Host + "/..."
+}